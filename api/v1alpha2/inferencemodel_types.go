@@ -89,6 +89,13 @@ type InferenceModelSpec struct {
 	// +optional
 	Criticality *Criticality `json:"criticality,omitempty"`
 
+	// Objectives defines the latency objectives of the model. Requests are routed to the endpoints
+	// predicted to meet the objectives. When no endpoint is, requests of Sheddable criticality are
+	// rejected, while requests of higher criticality are served on a best effort basis.
+	//
+	// +optional
+	Objectives *Objectives `json:"objectives,omitempty"`
+
 	// TargetModels allow multiple versions of a model for traffic splitting.
 	// If not specified, the target model name is defaulted to the modelName parameter.
 	// modelName is often in reference to a LoRA adapter.
@@ -125,6 +132,22 @@ type PoolObjectReference struct {
 	Name ObjectName `json:"name"`
 }
 
+// Objectives defines the latency objectives of a model. Unset objectives are not enforced.
+type Objectives struct {
+	// TTFTMilliseconds is the objective for the time to first token, in milliseconds.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TTFTMilliseconds *int32 `json:"ttftMilliseconds,omitempty"`
+
+	// TPOTMilliseconds is the objective for the time per output token after the first one, in
+	// milliseconds.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TPOTMilliseconds *int32 `json:"tpotMilliseconds,omitempty"`
+}
+
 // Criticality defines how important it is to serve the model compared to other models.
 // Criticality is intentionally a bounded enum to contain the possibilities that need to be supported by the load balancing algorithm. Any reference to the Criticality field must be optional (use a pointer), and set no default.
 // This allows us to union this with a oneOf field in the future should we wish to adjust/extend this behavior.
@@ -179,6 +179,11 @@ func (in *InferenceModelSpec) DeepCopyInto(out *InferenceModelSpec) {
 		*out = new(Criticality)
 		**out = **in
 	}
+	if in.Objectives != nil {
+		in, out := &in.Objectives, &out.Objectives
+		*out = new(Objectives)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.TargetModels != nil {
 		in, out := &in.TargetModels, &out.TargetModels
 		*out = make([]TargetModel, len(*in))
@@ -325,6 +330,31 @@ func (in *InferencePoolStatus) DeepCopy() *InferencePoolStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Objectives) DeepCopyInto(out *Objectives) {
+	*out = *in
+	if in.TTFTMilliseconds != nil {
+		in, out := &in.TTFTMilliseconds, &out.TTFTMilliseconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TPOTMilliseconds != nil {
+		in, out := &in.TPOTMilliseconds, &out.TPOTMilliseconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Objectives.
+func (in *Objectives) DeepCopy() *Objectives {
+	if in == nil {
+		return nil
+	}
+	out := new(Objectives)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PoolObjectReference) DeepCopyInto(out *PoolObjectReference) {
 	*out = *in
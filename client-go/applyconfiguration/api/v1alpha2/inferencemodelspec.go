@@ -26,6 +26,7 @@ import (
 type InferenceModelSpecApplyConfiguration struct {
 	ModelName    *string                                `json:"modelName,omitempty"`
 	Criticality  *apiv1alpha2.Criticality               `json:"criticality,omitempty"`
+	Objectives   *ObjectivesApplyConfiguration          `json:"objectives,omitempty"`
 	TargetModels []TargetModelApplyConfiguration        `json:"targetModels,omitempty"`
 	PoolRef      *PoolObjectReferenceApplyConfiguration `json:"poolRef,omitempty"`
 }
@@ -52,6 +53,14 @@ func (b *InferenceModelSpecApplyConfiguration) WithCriticality(value apiv1alpha2
 	return b
 }
 
+// WithObjectives sets the Objectives field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Objectives field is set to the value of the last call.
+func (b *InferenceModelSpecApplyConfiguration) WithObjectives(value *ObjectivesApplyConfiguration) *InferenceModelSpecApplyConfiguration {
+	b.Objectives = value
+	return b
+}
+
 // WithTargetModels adds the given value to the TargetModels field in the declarative configuration
 // and returns the receiver, so that objects can be build by chaining "With" function invocations.
 // If called multiple times, values provided by each call will be appended to the TargetModels field.
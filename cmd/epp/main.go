@@ -22,7 +22,9 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -40,7 +42,11 @@ import (
 	"sigs.k8s.io/gateway-api-inference-extension/internal/runnable"
 	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/handlers"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/recorder"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling"
+	schedulingtypes "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
 	runserver "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/server"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
 )
@@ -88,6 +94,17 @@ var (
 	logVerbosity  = flag.Int("v", logging.DEFAULT, "number for the log level verbosity")
 	secureServing = flag.Bool(
 		"secureServing", runserver.DefaultSecureServing, "Enables secure serving. Defaults to true.")
+	streaming = flag.Bool(
+		"streaming", runserver.DefaultUseStreaming,
+		"Expects the request bodies in the Envoy FULL_DUPLEX_STREAMED body mode. If disabled, the bodies are expected in the BUFFERED or BUFFERED_PARTIAL body modes.")
+	skipResponseBody = flag.Bool(
+		"skipResponseBody", false,
+		"Asks Envoy not to send the response bodies that no post-completion plugin or experiment needs, which requires "+
+			"its allow_mode_override option. The latency, size and token metrics of the skipped responses are not recorded.")
+	bodylessRequestPolicy = flag.String(
+		"bodylessRequestPolicy", runserver.DefaultBodylessPolicy,
+		"Policy routing the requests without a body other than the /v1/models ones, which the EPP answers with the "+
+			"InferenceModels: "+strings.Join(handlers.BodylessPolicies, ", ")+".")
 	certPath = flag.String(
 		"certPath", "", "The path to the certificate for secure serving. The certificate and private key files "+
 			"are assumed to be named tls.crt and tls.key, respectively. If not set, and secureServing is enabled, "+
@@ -103,6 +120,34 @@ var (
 	loraInfoMetric = flag.String("loraInfoMetric",
 		"vllm:lora_requests_info",
 		"Prometheus metric for the LoRA info metrics (must be in vLLM label format).")
+	// KV-cache capacity metrics
+	kvCacheConfigInfoMetric = flag.String("kvCacheConfigInfoMetric",
+		"vllm:cache_config_info",
+		"Prometheus metric for the KV-cache config info, used to compute the KV-cache token capacity (must be in vLLM label format).")
+	// Context length metrics
+	maxModelLenMetric = flag.String("maxModelLenMetric",
+		"",
+		"Prometheus metric for the maximum context length of the model server, read from its max_model_len label or else from its value. "+
+			"Pods can also set it with the llm-d.ai/max-model-len label or annotation.")
+	gatewayZone = flag.String("gatewayZone",
+		"",
+		"The topology zone of the gateway, used to prefer pods in the same zone for requests without the "+schedulingtypes.GatewayZoneHeader+" header.")
+	// Decision recording flags
+	recordPath = flag.String("recordPath",
+		"",
+		"Path of the file sampled scheduling decisions are recorded to, for offline replay. Recording is disabled if not set.")
+	recordSampleRate = flag.Float64("recordSampleRate",
+		recorder.DefaultSampleRate,
+		"Fraction of the requests whose scheduling decisions are recorded, in (0, 1].")
+	recordMaxFileSizeMB = flag.Int("recordMaxFileSizeMB",
+		recorder.DefaultMaxFileSize>>20,
+		"Size in megabytes beyond which the records file is rotated.")
+	recordMaxBackups = flag.Int("recordMaxBackups",
+		recorder.DefaultMaxBackups,
+		"Number of rotated records files kept.")
+	recordHeaders = flag.String("recordHeaders",
+		"",
+		"Comma separated allow-list of the request headers recorded along with the scheduling decisions.")
 
 	setupLog = ctrl.Log.WithName("setup")
 )
@@ -126,6 +171,10 @@ func run() error {
 		setupLog.Error(err, "Failed to validate flags")
 		return err
 	}
+	if err := scheduling.ConfigError(); err != nil {
+		setupLog.Error(err, "Failed to load the scheduler configuration")
+		return err
+	}
 
 	// Print all flag values
 	flags := make(map[string]any)
@@ -156,6 +205,8 @@ func run() error {
 		*totalQueuedRequestsMetric,
 		*kvCacheUsagePercentageMetric,
 		*loraInfoMetric,
+		*kvCacheConfigInfoMetric,
+		*maxModelLenMetric,
 	)
 	if err != nil {
 		setupLog.Error(err, "Failed to create metric mapping from flags.")
@@ -169,6 +220,21 @@ func run() error {
 
 	datastore := datastore.NewDatastore(ctx, pmf)
 
+	var decisionRecorder *recorder.Recorder
+	if *recordPath != "" {
+		recordConfig := recorder.DefaultConfig(*recordPath)
+		recordConfig.SampleRate = *recordSampleRate
+		recordConfig.MaxFileSize = int64(*recordMaxFileSizeMB) << 20
+		recordConfig.MaxBackups = *recordMaxBackups
+		if *recordHeaders != "" {
+			recordConfig.Headers = strings.Split(*recordHeaders, ",")
+		}
+		if decisionRecorder, err = recorder.NewRecorder(ctx, recordConfig); err != nil {
+			setupLog.Error(err, "Failed to create the decision recorder")
+			return err
+		}
+	}
+
 	serverRunner := &runserver.ExtProcServerRunner{
 		GrpcPort:                                 *grpcPort,
 		DestinationEndpointHintMetadataNamespace: *destinationEndpointHintMetadataNamespace,
@@ -177,7 +243,12 @@ func run() error {
 		Datastore:                                datastore,
 		SecureServing:                            *secureServing,
 		CertPath:                                 *certPath,
+		UseStreaming:                             *streaming,
+		SkipResponseBody:                         *skipResponseBody,
+		BodylessPolicy:                           *bodylessRequestPolicy,
 		RefreshPrometheusMetricsInterval:         *refreshPrometheusMetricsInterval,
+		GatewayZone:                              *gatewayZone,
+		Recorder:                                 decisionRecorder,
 	}
 	if err := serverRunner.SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "Failed to setup ext-proc controllers")
@@ -300,6 +371,9 @@ func validateFlags() error {
 	if *poolName == "" {
 		return fmt.Errorf("required %q flag not set", "poolName")
 	}
+	if !slices.Contains(handlers.BodylessPolicies, *bodylessRequestPolicy) {
+		return fmt.Errorf("invalid %q flag %q, want one of %v", "bodylessRequestPolicy", *bodylessRequestPolicy, handlers.BodylessPolicies)
+	}
 
 	return nil
 }
@@ -314,5 +388,11 @@ func verifyMetricMapping(mapping backendmetrics.MetricMapping, logger logr.Logge
 	if mapping.LoraRequestInfo == nil {
 		logger.Info("Not scraping metric: LoraRequestInfo")
 	}
+	if mapping.KVCacheConfigInfo == nil {
+		logger.Info("Not scraping metric: KVCacheConfigInfo")
+	}
+	if mapping.MaxModelLen == nil {
+		logger.Info("Not scraping metric: MaxModelLen")
+	}
 
 }
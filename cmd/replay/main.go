@@ -0,0 +1,96 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The replay command reruns the scheduling decisions recorded by the EPP through the scheduler,
+// configured by the same environment variables as the EPP, and reports the decisions that changed.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/recorder"
+)
+
+var (
+	maxDiffs     = flag.Int("maxDiffs", 20, "Maximum number of changed decisions listed in the text report.")
+	output       = flag.String("output", "text", "Format of the report, text or json.")
+	logVerbosity = flag.Int("v", 0, "number for the log level verbosity, only errors are logged if not set")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] records-file...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	flag.Parse()
+	// Unless verbosity is set, only errors are logged, to keep the report readable.
+	level := zapcore.ErrorLevel
+	if *logVerbosity > 0 {
+		level = zapcore.Level(int8(-1 * *logVerbosity))
+	}
+	ctrl.SetLogger(zap.New(zap.Level(uberzap.NewAtomicLevelAt(level)), zap.WriteTo(os.Stderr)))
+
+	if flag.NArg() == 0 {
+		return errors.New("at least one records file is required")
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+	records := []*recorder.Record{}
+	for _, path := range flag.Args() {
+		fileRecords, err := readRecords(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s - %w", path, err)
+		}
+		records = append(records, fileRecords...)
+	}
+
+	report, err := recorder.Replay(ctrl.SetupSignalHandler(), records, nil)
+	if err != nil {
+		return err
+	}
+
+	if *output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	return report.WriteText(os.Stdout, *maxDiffs)
+}
+
+func readRecords(path string) ([]*recorder.Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return recorder.ReadRecords(file)
+}
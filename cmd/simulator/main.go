@@ -0,0 +1,103 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The simulator replays a request trace against the scheduler of the EPP, configured by the same
+// environment variables, and simulated model servers, then reports the latencies, throughput and
+// prefix cache hit rate of the requests.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/simulator"
+)
+
+var (
+	tracePath = flag.String("trace", "",
+		"Path of the request trace to replay, one JSON request per line, e.g., "+
+			`{"arrival": 0.5, "model": "llama", "prompt": "...", "outputTokens": 128}.`)
+	pods          = flag.Int("pods", 4, "Number of simulated pods serving both prefill and decode.")
+	prefillPods   = flag.Int("prefillPods", 0, "Number of simulated pods dedicated to prefill, scheduled when PD_ENABLED is true.")
+	decodePods    = flag.Int("decodePods", 0, "Number of simulated pods dedicated to decode, scheduled when PD_ENABLED is true.")
+	kvCacheTokens = flag.Int("kvCacheTokens", simulator.DefaultEngineConfig().KVCacheTokens,
+		"Number of tokens the KV cache of a simulated pod holds.")
+	maxNumSeqs = flag.Int("maxNumSeqs", simulator.DefaultEngineConfig().MaxNumSeqs,
+		"Maximum number of requests running concurrently on a simulated pod.")
+	maxModelLen  = flag.Int("maxModelLen", 0, "Context length reported by the simulated pods, zero if unknown.")
+	output       = flag.String("output", "text", "Format of the report, text or json.")
+	logVerbosity = flag.Int("v", 0, "number for the log level verbosity, only errors are logged if not set")
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	flag.Parse()
+	// Unless verbosity is set, only errors are logged, to keep the report readable.
+	level := zapcore.ErrorLevel
+	if *logVerbosity > 0 {
+		level = zapcore.Level(int8(-1 * *logVerbosity))
+	}
+	ctrl.SetLogger(zap.New(zap.Level(uberzap.NewAtomicLevelAt(level)), zap.WriteTo(os.Stderr)))
+
+	if *tracePath == "" {
+		return errors.New("--trace is required")
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+	file, err := os.Open(*tracePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	trace, err := simulator.ReadTrace(file)
+	if err != nil {
+		return err
+	}
+
+	engine := simulator.DefaultEngineConfig()
+	engine.KVCacheTokens = *kvCacheTokens
+	engine.MaxNumSeqs = *maxNumSeqs
+	engine.MaxModelLen = *maxModelLen
+	sim, err := simulator.NewSimulation(&simulator.Config{Pods: *pods, PrefillPods: *prefillPods, DecodePods: *decodePods, Engine: engine})
+	if err != nil {
+		return err
+	}
+	report, err := sim.Run(ctrl.SetupSignalHandler(), trace)
+	if err != nil {
+		return err
+	}
+
+	if *output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	return report.WriteText(os.Stdout)
+}
@@ -0,0 +1,96 @@
+# Copyright 2025 The Kubernetes Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Generates the expectations of the conformance tests of the local KV-cache tokenizers.
+
+For each given model, the tokenizer.json of the model is downloaded into <dir>/<model>/ if
+missing, and <dir>/<model>/conformance.json records the token IDs and the chat prompts computed by
+the Hugging Face tokenizer and the Jinja chat template of the model, as the model server does. The
+directory has the layout of KVCACHE_TOKENIZERS_DIR, so that the Go chat templates of a deployment,
+chat_template.tmpl next to the tokenizer.json, are checked against the ones of the models.
+
+Usage: python3 hack/kvcache-conformance.py <dir> <model>...
+Requires the transformers and huggingface_hub packages, and HF_TOKEN for gated models.
+"""
+
+import json
+import os
+import shutil
+import sys
+
+from huggingface_hub import hf_hub_download
+from transformers import AutoTokenizer
+
+PROMPTS = [
+    "Hello world!",
+    "  leading and trailing spaces  ",
+    "Line one\nLine two\r\n\n\tTabbed line",
+    "Numbers: 1 12 123 1234 12345 3.14159 -42",
+    "Contractions: I'm, you're, it's, we'll, they'd, I've, don't. SHOUTING'S FINE",
+    "Unicode: café naïve 日本語のテキスト 한국어 Ελληνικά русский",
+    "Emoji: 👋🏽 🤖 🚀✨ and symbols ©®™ ∑∫√",
+    "def f(x):\n    return {'a': [x, x ** 2]}  # code\n",
+    "Repeated     spaces    and\n\n\n\nnewlines",
+    "<|endoftext|> special tokens in the text <|endoftext|>",
+]
+
+CHATS = [
+    [{"role": "user", "content": "Hello!"}],
+    [
+        {"role": "system", "content": "You are a helpful assistant. "},
+        {"role": "user", "content": "What is the capital of France?"},
+        {"role": "assistant", "content": " Paris.\n"},
+        {"role": "user", "content": "And of Japan?"},
+    ],
+    [{"role": "user", "content": "Unicode: 日本語 and emoji 🚀\n\tindented"}],
+]
+
+
+def generate(out_dir, model):
+    model_dir = os.path.join(out_dir, model)
+    os.makedirs(model_dir, exist_ok=True)
+    tokenizer_file = os.path.join(model_dir, "tokenizer.json")
+    if not os.path.exists(tokenizer_file):
+        shutil.copy(hf_hub_download(model, "tokenizer.json"), tokenizer_file)
+
+    tokenizer = AutoTokenizer.from_pretrained(model)
+    expectations = {
+        "prompts": [
+            {"text": text, "ids": tokenizer(text, add_special_tokens=True).input_ids} for text in PROMPTS
+        ],
+        "chats": [],
+    }
+    if tokenizer.chat_template:
+        for messages in CHATS:
+            prompt = tokenizer.apply_chat_template(messages, tokenize=False, add_generation_prompt=True)
+            expectations["chats"].append({
+                "messages": messages,
+                "prompt": prompt,
+                "ids": tokenizer(prompt, add_special_tokens=False).input_ids,
+            })
+
+    with open(os.path.join(model_dir, "conformance.json"), "w", encoding="utf-8") as f:
+        json.dump(expectations, f, ensure_ascii=False, indent=1)
+    print(f"Generated {len(expectations['prompts'])} prompts and {len(expectations['chats'])} chats for {model}")
+
+
+def main():
+    if len(sys.argv) < 3:
+        sys.exit(__doc__)
+    for model in sys.argv[2:]:
+        generate(sys.argv[1], model)
+
+
+if __name__ == "__main__":
+    main()
@@ -103,13 +103,18 @@ func refreshPrometheusMetrics(logger logr.Logger, datastore Datastore) {
 		return
 	}
 
+	rolePods := make(map[PodRole]int, len(PodRoles))
 	for _, pod := range podMetrics {
 		kvCacheTotal += pod.GetMetrics().KVCacheUsagePercent
 		queueTotal += pod.GetMetrics().WaitingQueueSize
+		rolePods[pod.GetPod().Role]++
 	}
 
 	podTotalCount := len(podMetrics)
 	metrics.RecordInferencePoolAvgKVCache(pool.Name, kvCacheTotal/float64(podTotalCount))
 	metrics.RecordInferencePoolAvgQueueSize(pool.Name, float64(queueTotal/podTotalCount))
 	metrics.RecordinferencePoolReadyPods(pool.Name, float64(podTotalCount))
+	for _, role := range PodRoles {
+		metrics.RecordInferencePoolRolePods(pool.Name, role.String(), float64(rolePods[role]))
+	}
 }
@@ -33,6 +33,13 @@ const (
 	LoraInfoRunningAdaptersMetricName = "running_lora_adapters"
 	LoraInfoWaitingAdaptersMetricName = "waiting_lora_adapters"
 	LoraInfoMaxAdaptersMetricName     = "max_lora"
+
+	// KV-cache config info labels based on vLLM
+	CacheConfigBlockSizeLabel    = "block_size"
+	CacheConfigNumGPUBlocksLabel = "num_gpu_blocks"
+
+	// MaxModelLenLabel is the label holding the maximum context length of info metrics.
+	MaxModelLenLabel = "max_model_len"
 )
 
 type PodMetricsClientImpl struct {
@@ -102,6 +109,34 @@ func (p *PodMetricsClientImpl) promToPodMetrics(
 		}
 	}
 
+	if p.MetricMapping.KVCacheConfigInfo != nil {
+		cacheConfig, err := p.getMetric(metricFamilies, *p.MetricMapping.KVCacheConfigInfo)
+		if err == nil {
+			capacity, err := kvCacheTokenCapacity(cacheConfig)
+			if err == nil {
+				updated.KvCacheMaxTokenCapacity = capacity
+			} else {
+				errs = multierr.Append(errs, err)
+			}
+		} else {
+			errs = multierr.Append(errs, err)
+		}
+	}
+
+	if p.MetricMapping.MaxModelLen != nil {
+		maxModelLenMetric, err := p.getMetric(metricFamilies, *p.MetricMapping.MaxModelLen)
+		if err == nil {
+			maxModelLen, err := maxModelLen(maxModelLenMetric)
+			if err == nil {
+				updated.MaxModelLen = maxModelLen
+			} else {
+				errs = multierr.Append(errs, err)
+			}
+		} else {
+			errs = multierr.Append(errs, err)
+		}
+	}
+
 	// Handle LoRA metrics (only if all LoRA MetricSpecs are present)
 	if p.MetricMapping.LoraRequestInfo != nil {
 		loraMetrics, err := p.getLatestLoraMetric(metricFamilies)
@@ -142,6 +177,43 @@ func (p *PodMetricsClientImpl) promToPodMetrics(
 	return updated, errs
 }
 
+// maxModelLen returns the maximum context length from the max_model_len label of an info metric, or
+// else from the value of a gauge.
+func maxModelLen(metric *dto.Metric) (int, error) {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == MaxModelLenLabel {
+			value, err := strconv.Atoi(label.GetValue())
+			if err != nil {
+				return 0, fmt.Errorf("invalid max model length label %q: %w", label.GetName(), err)
+			}
+			return value, nil
+		}
+	}
+	return int(metric.GetGauge().GetValue()), nil
+}
+
+// kvCacheTokenCapacity returns the number of tokens the KV-cache can hold, from the labels of the
+// vLLM `vllm:cache_config_info` info metric: the number of GPU blocks times the block size.
+func kvCacheTokenCapacity(cacheConfig *dto.Metric) (int, error) {
+	blockSize, numBlocks := -1, -1
+	for _, label := range cacheConfig.GetLabel() {
+		var err error
+		switch label.GetName() {
+		case CacheConfigBlockSizeLabel:
+			blockSize, err = strconv.Atoi(label.GetValue())
+		case CacheConfigNumGPUBlocksLabel:
+			numBlocks, err = strconv.Atoi(label.GetValue())
+		}
+		if err != nil {
+			return 0, fmt.Errorf("invalid KV-cache config label %q: %w", label.GetName(), err)
+		}
+	}
+	if blockSize < 0 || numBlocks < 0 {
+		return 0, fmt.Errorf("KV-cache config metric is missing the %q or %q label", CacheConfigBlockSizeLabel, CacheConfigNumGPUBlocksLabel)
+	}
+	return blockSize * numBlocks, nil
+}
+
 // getLatestLoraMetric gets latest lora metric series in gauge metric family `vllm:lora_requests_info`
 // reason its specially fetched is because each label key value pair permutation generates new series
 // and only most recent is useful. The value of each series is the creation timestamp so we can
@@ -32,6 +32,8 @@ type MetricMapping struct {
 	TotalQueuedRequests *MetricSpec
 	KVCacheUtilization  *MetricSpec
 	LoraRequestInfo     *MetricSpec
+	KVCacheConfigInfo   *MetricSpec
+	MaxModelLen         *MetricSpec
 }
 
 // stringToMetricSpec converts a string to a MetricSpec.
@@ -93,7 +95,7 @@ func stringToMetricSpec(specStr string) (*MetricSpec, error) {
 }
 
 // NewMetricMapping creates a MetricMapping from string values.
-func NewMetricMapping(queuedStr, kvUsageStr, loraReqInfoStr string) (*MetricMapping, error) {
+func NewMetricMapping(queuedStr, kvUsageStr, loraReqInfoStr, kvCacheConfigInfoStr, maxModelLenStr string) (*MetricMapping, error) {
 	queuedSpec, err := stringToMetricSpec(queuedStr)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing WaitingRequests: %w", err)
@@ -106,10 +108,20 @@ func NewMetricMapping(queuedStr, kvUsageStr, loraReqInfoStr string) (*MetricMapp
 	if err != nil {
 		return nil, fmt.Errorf("error parsing loraReqInfoStr: %w", err)
 	}
+	kvCacheConfigInfoSpec, err := stringToMetricSpec(kvCacheConfigInfoStr)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing kvCacheConfigInfoStr: %w", err)
+	}
+	maxModelLenSpec, err := stringToMetricSpec(maxModelLenStr)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing maxModelLenStr: %w", err)
+	}
 	mapping := &MetricMapping{
 		TotalQueuedRequests: queuedSpec,
 		KVCacheUtilization:  kvUsageSpec,
 		LoraRequestInfo:     loraReqInfoSpec,
+		KVCacheConfigInfo:   kvCacheConfigInfoSpec,
+		MaxModelLen:         maxModelLenSpec,
 	}
 
 	return mapping, nil
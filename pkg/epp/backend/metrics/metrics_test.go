@@ -394,21 +394,66 @@ func TestPromToPodMetrics(t *testing.T) {
 				"vllm:lora_requests_info": makeMetricFamily("vllm:lora_requests_info",
 					makeMetric(map[string]string{"running_lora_adapters": "lora1,lora2", "waiting_lora_adapters": "lora3", "max_lora": "3"}, 3000.0, 1000),
 				),
+				"vllm:cache_config_info": makeMetricFamily("vllm:cache_config_info",
+					makeMetric(map[string]string{"block_size": "16", "num_gpu_blocks": "1000", "cache_dtype": "auto"}, 1.0, 1000),
+				),
 			},
 			mapping: &MetricMapping{
 				TotalQueuedRequests: &MetricSpec{MetricName: "vllm_waiting"},
 				KVCacheUtilization:  &MetricSpec{MetricName: "vllm_usage"},
 				LoraRequestInfo:     &MetricSpec{MetricName: "vllm:lora_requests_info"},
+				KVCacheConfigInfo:   &MetricSpec{MetricName: "vllm:cache_config_info"},
 			},
 			existingMetrics: &Metrics{},
 			expectedMetrics: &Metrics{
-				WaitingQueueSize:    7,
-				KVCacheUsagePercent: 0.8,
-				ActiveModels:        map[string]int{"lora1": 0, "lora2": 0},
-				WaitingModels:       map[string]int{"lora3": 0},
-				MaxActiveModels:     3,
+				WaitingQueueSize:        7,
+				KVCacheUsagePercent:     0.8,
+				ActiveModels:            map[string]int{"lora1": 0, "lora2": 0},
+				WaitingModels:           map[string]int{"lora3": 0},
+				MaxActiveModels:         3,
+				KvCacheMaxTokenCapacity: 16000,
 			},
 		},
+		{
+			name: "KV-cache config info without block size",
+			metricFamilies: map[string]*dto.MetricFamily{
+				"vllm:cache_config_info": makeMetricFamily("vllm:cache_config_info",
+					makeMetric(map[string]string{"num_gpu_blocks": "1000"}, 1.0, 1000),
+				),
+			},
+			mapping: &MetricMapping{
+				KVCacheConfigInfo: &MetricSpec{MetricName: "vllm:cache_config_info"},
+			},
+			existingMetrics: &Metrics{},
+			expectedMetrics: &Metrics{},
+			expectedErr:     errors.New("KV-cache config metric is missing the \"block_size\" or \"num_gpu_blocks\" label"),
+		},
+		{
+			name: "max model length from info metric label",
+			metricFamilies: map[string]*dto.MetricFamily{
+				"model_config_info": makeMetricFamily("model_config_info",
+					makeMetric(map[string]string{"max_model_len": "131072"}, 1.0, 1000),
+				),
+			},
+			mapping: &MetricMapping{
+				MaxModelLen: &MetricSpec{MetricName: "model_config_info"},
+			},
+			existingMetrics: &Metrics{ActiveModels: map[string]int{}, WaitingModels: map[string]int{}},
+			expectedMetrics: &Metrics{ActiveModels: map[string]int{}, WaitingModels: map[string]int{}, MaxModelLen: 131072},
+		},
+		{
+			name: "max model length from gauge value",
+			metricFamilies: map[string]*dto.MetricFamily{
+				"max_model_len": makeMetricFamily("max_model_len",
+					makeMetric(nil, 8192, 1000),
+				),
+			},
+			mapping: &MetricMapping{
+				MaxModelLen: &MetricSpec{MetricName: "max_model_len"},
+			},
+			existingMetrics: &Metrics{ActiveModels: map[string]int{}, WaitingModels: map[string]int{}},
+			expectedMetrics: &Metrics{ActiveModels: map[string]int{}, WaitingModels: map[string]int{}, MaxModelLen: 8192},
+		},
 		{
 			name:           "missing metrics",
 			metricFamilies: map[string]*dto.MetricFamily{}, // No metrics
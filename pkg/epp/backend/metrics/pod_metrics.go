@@ -19,6 +19,7 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -28,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+	podutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/pod"
 )
 
 const (
@@ -36,6 +38,13 @@ const (
 	rolePrefill         = "prefill"
 	roleDecode          = "decode"
 	roleBoth            = "both"
+	roleEncode          = "encode"
+	// capacityKey is the annotation, or label, holding the relative capacity of a pod, e.g., "2" for a
+	// pod serving twice as many requests as a reference pod of capacity "1".
+	capacityKey = "llm-d.ai/capacity"
+	// maxModelLenKey is the annotation, or label, holding the maximum context length in tokens of the
+	// model served by a pod, e.g., "131072".
+	maxModelLenKey = "llm-d.ai/max-model-len"
 )
 
 type podMetrics struct {
@@ -68,37 +77,87 @@ func (pm *podMetrics) GetMetrics() *Metrics {
 }
 
 func (pm *podMetrics) UpdatePod(in *corev1.Pod) {
-	pm.pod.Store(toInternalPod(in))
+	pod := toInternalPod(in)
+	if old := pm.pod.Swap(pod); pod.Role == Unknown && (old == nil || old.RoleLabel != pod.RoleLabel) {
+		pm.logger.V(logutil.DEFAULT).Info("Pod has an unrecognized role label, excluding it from scheduling",
+			"label", roleLabel, "role", pod.RoleLabel)
+	}
+}
+
+// podRoles maps the values of the role label to the pod roles.
+var podRoles = map[string]PodRole{
+	rolePrefill: Prefill,
+	roleDecode:  Decode,
+	roleBoth:    Both,
+	roleEncode:  Encode,
 }
 
 func podLabelToRole(in *corev1.Pod) PodRole {
 	roleLabel, ok := in.ObjectMeta.Labels[roleLabel]
+	if !ok {
+		// role label is missing
+		return Both
+	}
+	if role, ok := podRoles[roleLabel]; ok {
+		return role
+	}
+	return Unknown
+}
 
-	if ok {
-		switch roleLabel {
-		case rolePrefill:
-			return Prefill
-		case roleDecode:
-			return Decode
-		case roleBoth:
-			return Both
-		default:
-			return Unknown
-		}
+// podAnnotationOrLabel returns the value of the given key from the pod annotations, or else labels.
+func podAnnotationOrLabel(in *corev1.Pod, key string) (string, bool) {
+	if value, ok := in.ObjectMeta.Annotations[key]; ok {
+		return value, true
 	}
+	value, ok := in.ObjectMeta.Labels[key]
+	return value, ok
+}
 
-	// role label is missing
-	return Both
+// podCapacity returns the relative capacity of the pod from its capacity annotation, or label, and 1
+// if neither is set to a positive number.
+func podCapacity(in *corev1.Pod) float64 {
+	value, ok := podAnnotationOrLabel(in, capacityKey)
+	if !ok {
+		return 1
+	}
+	capacity, err := strconv.ParseFloat(value, 64)
+	if err != nil || capacity <= 0 {
+		return 1
+	}
+	return capacity
+}
+
+// podMaxModelLen returns the maximum context length of the pod from its max-model-len annotation, or
+// label, and 0 if neither is set to a positive number.
+func podMaxModelLen(in *corev1.Pod) int {
+	value, ok := podAnnotationOrLabel(in, maxModelLenKey)
+	if !ok {
+		return 0
+	}
+	maxModelLen, err := strconv.Atoi(value)
+	if err != nil || maxModelLen <= 0 {
+		return 0
+	}
+	return maxModelLen
 }
 
 func toInternalPod(in *corev1.Pod) *Pod {
+	// Topology is set on the pod by the user, or copied from its node by the pod reconciler.
+	zone, _ := podAnnotationOrLabel(in, podutil.TopologyZoneKey)
+	rack, _ := podAnnotationOrLabel(in, podutil.TopologyRackKey)
 	return &Pod{
 		NamespacedName: types.NamespacedName{
 			Name:      in.Name,
 			Namespace: in.Namespace,
 		},
-		Address: in.Status.PodIP,
-		Role:    podLabelToRole(in),
+		Address:     in.Status.PodIP,
+		Role:        podLabelToRole(in),
+		RoleLabel:   in.ObjectMeta.Labels[roleLabel],
+		Capacity:    podCapacity(in),
+		MaxModelLen: podMaxModelLen(in),
+		NodeName:    in.Spec.NodeName,
+		Zone:        zone,
+		Rack:        rack,
 	}
 }
 
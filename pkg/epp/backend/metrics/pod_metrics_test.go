@@ -83,6 +83,131 @@ func TestMetricsRefresh(t *testing.T) {
 	assert.EventuallyWithT(t, condition, time.Second, time.Millisecond)
 }
 
+func TestPodRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   PodRole
+	}{
+		{
+			name: "no role label",
+			want: Both,
+		},
+		{
+			name:   "encode",
+			labels: map[string]string{roleLabel: "encode"},
+			want:   Encode,
+		},
+		{
+			name:   "prefill",
+			labels: map[string]string{roleLabel: "prefill"},
+			want:   Prefill,
+		},
+		{
+			name:   "unrecognized role",
+			labels: map[string]string{roleLabel: "draft"},
+			want:   Unknown,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := toInternalPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: test.labels}})
+			if pod.Role != test.want {
+				t.Errorf("Unexpected role, want %v, got %v", test.want, pod.Role)
+			}
+			if pod.RoleLabel != test.labels[roleLabel] {
+				t.Errorf("Unexpected role label, want %q, got %q", test.labels[roleLabel], pod.RoleLabel)
+			}
+		})
+	}
+}
+
+func TestPodCapacity(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		labels      map[string]string
+		want        float64
+	}{
+		{
+			name: "not set",
+			want: 1,
+		},
+		{
+			name:        "annotation",
+			annotations: map[string]string{capacityKey: "2.5"},
+			want:        2.5,
+		},
+		{
+			name:   "label",
+			labels: map[string]string{capacityKey: "4"},
+			want:   4,
+		},
+		{
+			name:        "annotation takes precedence over label",
+			annotations: map[string]string{capacityKey: "2"},
+			labels:      map[string]string{capacityKey: "4"},
+			want:        2,
+		},
+		{
+			name:        "invalid",
+			annotations: map[string]string{capacityKey: "fast"},
+			want:        1,
+		},
+		{
+			name:        "not positive",
+			annotations: map[string]string{capacityKey: "0"},
+			want:        1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations, Labels: test.labels}}
+			if got := toInternalPod(pod).Capacity; got != test.want {
+				t.Errorf("Unexpected capacity, want %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestPodMaxModelLen(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		labels      map[string]string
+		want        int
+	}{
+		{
+			name: "not set",
+			want: 0,
+		},
+		{
+			name:   "label",
+			labels: map[string]string{maxModelLenKey: "131072"},
+			want:   131072,
+		},
+		{
+			name:        "annotation takes precedence over label",
+			annotations: map[string]string{maxModelLenKey: "8192"},
+			labels:      map[string]string{maxModelLenKey: "131072"},
+			want:        8192,
+		},
+		{
+			name:        "invalid",
+			annotations: map[string]string{maxModelLenKey: "128k"},
+			want:        0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations, Labels: test.labels}}
+			if got := toInternalPod(pod).MaxModelLen; got != test.want {
+				t.Errorf("Unexpected max model length, want %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
 type fakeDataStore struct{}
 
 func (f *fakeDataStore) PoolGet() (*v1alpha2.InferencePool, error) {
@@ -50,7 +50,7 @@ func (f *PodMetricsFactory) NewPodMetrics(parentCtx context.Context, in *corev1.
 		done:     make(chan struct{}),
 		logger:   log.FromContext(parentCtx).WithValues("pod", pod.NamespacedName),
 	}
-	pm.pod.Store(pod)
+	pm.UpdatePod(in)
 	pm.metrics.Store(newMetrics())
 
 	pm.startRefreshLoop(parentCtx)
@@ -65,19 +65,80 @@ type PodMetrics interface {
 	String() string
 }
 
+// PodRole is the stage of disaggregated requests a pod serves, set by its role label.
 type PodRole int
 
 const (
 	Prefill PodRole = iota
 	Decode
 	Both
+	// Unknown is the role of pods with an unrecognized role label, which are excluded from scheduling.
 	Unknown
+	// Encode pods encode the multimodal inputs of requests, e.g., images, ahead of their prefill.
+	Encode
 )
 
+// PodRoles lists all the pod roles.
+var PodRoles = []PodRole{Prefill, Decode, Both, Encode, Unknown}
+
+func (r PodRole) String() string {
+	switch r {
+	case Prefill:
+		return rolePrefill
+	case Decode:
+		return roleDecode
+	case Both:
+		return roleBoth
+	case Encode:
+		return roleEncode
+	default:
+		return "unknown"
+	}
+}
+
 type Pod struct {
 	NamespacedName types.NamespacedName
 	Address        string
 	Role           PodRole
+	// RoleLabel is the value of the role label of the pod, empty if not set.
+	RoleLabel string
+	// Capacity is the capacity of the pod relative to the other pods of the pool, e.g., 2 for a pod on
+	// an accelerator serving twice as many requests. Zero is treated as 1.
+	Capacity float64
+	// MaxModelLen is the maximum context length in tokens of the model served by the pod, as
+	// configured on the pod, zero if unknown. The scraped Metrics.MaxModelLen takes precedence.
+	MaxModelLen int
+	// NodeName, Zone and Rack locate the pod in the cluster topology, empty if unknown.
+	NodeName string
+	Zone     string
+	Rack     string
+}
+
+// Locality is the closest topology domain shared by two pods.
+type Locality string
+
+const (
+	LocalityNode   Locality = "node"
+	LocalityRack   Locality = "rack"
+	LocalityZone   Locality = "zone"
+	LocalityRemote Locality = "remote"
+)
+
+// LocalityTo returns the closest topology domain the pod shares with the other pod. Unknown topology
+// domains are never shared.
+func (p *Pod) LocalityTo(other *Pod) Locality {
+	switch {
+	case p == nil || other == nil:
+		return LocalityRemote
+	case p.NodeName != "" && p.NodeName == other.NodeName:
+		return LocalityNode
+	case p.Rack != "" && p.Rack == other.Rack && p.Zone == other.Zone:
+		return LocalityRack
+	case p.Zone != "" && p.Zone == other.Zone:
+		return LocalityZone
+	default:
+		return LocalityRemote
+	}
 }
 
 func (p *Pod) String() string {
@@ -87,6 +148,14 @@ func (p *Pod) String() string {
 	return fmt.Sprintf("%+v", *p)
 }
 
+// CapacityWeight returns the relative capacity of the pod, defaulting to 1.
+func (p *Pod) CapacityWeight() float64 {
+	if p == nil || p.Capacity <= 0 {
+		return 1
+	}
+	return p.Capacity
+}
+
 func (p *Pod) Clone() *Pod {
 	if p == nil {
 		return nil
@@ -96,8 +165,14 @@ func (p *Pod) Clone() *Pod {
 			Name:      p.NamespacedName.Name,
 			Namespace: p.NamespacedName.Namespace,
 		},
-		Address: p.Address,
-		Role:    p.Role,
+		Address:     p.Address,
+		Role:        p.Role,
+		RoleLabel:   p.RoleLabel,
+		Capacity:    p.Capacity,
+		MaxModelLen: p.MaxModelLen,
+		NodeName:    p.NodeName,
+		Zone:        p.Zone,
+		Rack:        p.Rack,
 	}
 }
 
@@ -111,6 +186,9 @@ type Metrics struct {
 	WaitingQueueSize        int
 	KVCacheUsagePercent     float64
 	KvCacheMaxTokenCapacity int
+	// MaxModelLen is the maximum context length in tokens scraped from the model server, zero if
+	// unknown.
+	MaxModelLen int
 
 	// UpdateTime record the last time when the metrics were updated.
 	UpdateTime time.Time
@@ -130,6 +208,16 @@ func (m *Metrics) String() string {
 	return fmt.Sprintf("%+v", *m)
 }
 
+// FreeKVCacheTokens estimates the number of tokens that can still be allocated in the KV-cache, and
+// returns false if the KV-cache capacity is unknown.
+func (m *Metrics) FreeKVCacheTokens() (int, bool) {
+	if m.KvCacheMaxTokenCapacity <= 0 {
+		return 0, false
+	}
+	free := int(float64(m.KvCacheMaxTokenCapacity) * (1 - m.KVCacheUsagePercent))
+	return max(free, 0), true
+}
+
 func (m *Metrics) Clone() *Metrics {
 	if m == nil {
 		return nil
@@ -150,6 +238,7 @@ func (m *Metrics) Clone() *Metrics {
 		WaitingQueueSize:        m.WaitingQueueSize,
 		KVCacheUsagePercent:     m.KVCacheUsagePercent,
 		KvCacheMaxTokenCapacity: m.KvCacheMaxTokenCapacity,
+		MaxModelLen:             m.MaxModelLen,
 		UpdateTime:              m.UpdateTime,
 	}
 	return clone
@@ -25,10 +25,13 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
 	podutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/pod"
@@ -60,6 +63,11 @@ func (c *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return ctrl.Result{}, err
 	}
 
+	pod, err := podutil.WithNodeTopology(ctx, c.Client, pod)
+	if err != nil {
+		logger.V(logutil.DEBUG).Info("Unable to get the topology of the pod node", "name", req.NamespacedName, "error", err)
+	}
+
 	c.updateDatastore(logger, pod)
 	return ctrl.Result{}, nil
 }
@@ -84,12 +92,33 @@ func (c *PodReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			return c.Datastore.PoolLabelsMatch(pod.GetLabels())
 		},
 	}
+	// The topology of the pods is copied from the labels of their node, so the pods are reconciled
+	// again when these labels change.
+	nodeFilter := predicate.Funcs{
+		CreateFunc: func(event.CreateEvent) bool { return false },
+		UpdateFunc: func(ue event.UpdateEvent) bool {
+			return podutil.NodeTopologyChanged(ue.ObjectOld.(*corev1.Node), ue.ObjectNew.(*corev1.Node))
+		},
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Pod{}).
-		WithEventFilter(filter).
+		For(&corev1.Pod{}, builder.WithPredicates(filter)).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(c.nodePods), builder.WithPredicates(nodeFilter)).
 		Complete(c)
 }
 
+// nodePods returns the requests reconciling the pods of the datastore running on the given node.
+func (c *PodReconciler) nodePods(_ context.Context, node client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+	for _, pm := range c.Datastore.PodGetAll() {
+		if pm.GetPod().NodeName == node.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: pm.GetPod().NamespacedName})
+		}
+	}
+	return requests
+}
+
 func (c *PodReconciler) updateDatastore(logger logr.Logger, pod *corev1.Pod) {
 	namespacedName := types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}
 	if !podutil.IsPodReady(pod) || !c.Datastore.PoolLabelsMatch(pod.Labels) {
@@ -31,9 +31,11 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
 	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	podutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/pod"
 	utiltest "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/testing"
 )
 
@@ -207,3 +209,55 @@ func TestPodReconciler(t *testing.T) {
 		})
 	}
 }
+
+func TestPodReconcilerNodeTopology(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{corev1.LabelTopologyZone: "zone-a"}}}
+	pod := utiltest.FromBase(basePod1).Labels(map[string]string{"some-key": "some-val"}).ReadyCondition().ObjRef()
+	pod.Spec.NodeName = node.Name
+	otherPod := utiltest.FromBase(basePod2).Labels(map[string]string{"some-key": "some-val"}).ReadyCondition().ObjRef()
+	otherPod.Spec.NodeName = "node-2"
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(node, pod, otherPod).Build()
+
+	store := datastore.NewDatastore(t.Context(), pmf)
+	_ = store.PoolSet(t.Context(), fakeClient, &v1alpha2.InferencePool{
+		Spec: v1alpha2.InferencePoolSpec{
+			TargetPortNumber: int32(8000),
+			Selector:         map[v1alpha2.LabelKey]v1alpha2.LabelValue{"some-key": "some-val"},
+		},
+	})
+	podReconciler := &PodReconciler{Client: fakeClient, Datastore: store}
+	zone := func() string {
+		for _, pm := range store.PodGetAll() {
+			if pm.GetPod().NamespacedName.Name == pod.Name {
+				return pm.GetPod().Zone
+			}
+		}
+		return ""
+	}
+
+	if got := zone(); got != "zone-a" {
+		t.Errorf("Unexpected zone, want zone-a, got %q", got)
+	}
+
+	// A change of the topology labels of the node reconciles its pods again.
+	updated := node.DeepCopy()
+	updated.Labels[corev1.LabelTopologyZone] = "zone-b"
+	if !podutil.NodeTopologyChanged(node, updated) {
+		t.Error("Expected the node topology to have changed")
+	}
+	if err := fakeClient.Update(context.Background(), updated); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	requests := podReconciler.nodePods(context.Background(), updated)
+	if diff := cmp.Diff([]reconcile.Request{{NamespacedName: types.NamespacedName{Name: pod.Name}}}, requests); diff != "" {
+		t.Fatalf("Unexpected requests (-want +got): %v", diff)
+	}
+	if _, err := podReconciler.Reconcile(context.Background(), requests[0]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := zone(); got != "zone-b" {
+		t.Errorf("Unexpected zone, want zone-b, got %q", got)
+	}
+}
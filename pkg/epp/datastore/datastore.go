@@ -70,6 +70,12 @@ type Datastore interface {
 
 	// Clears the store state, happens when the pool gets deleted.
 	Clear()
+
+	// Subscribe registers the handler for the pod, pool and model events until the returned function
+	// is called or the given context is done. The handler is first called with the current state: the
+	// pool, the models and an added event per pod. Events are delivered in order, asynchronously, and
+	// may be delivered twice around the subscription, so handlers should be idempotent.
+	Subscribe(ctx context.Context, handler EventHandler) (unsubscribe func())
 }
 
 func NewDatastore(parentCtx context.Context, pmf *backendmetrics.PodMetricsFactory) Datastore {
@@ -79,6 +85,7 @@ func NewDatastore(parentCtx context.Context, pmf *backendmetrics.PodMetricsFacto
 		models:          make(map[string]*v1alpha2.InferenceModel),
 		pods:            &sync.Map{},
 		pmf:             pmf,
+		events:          newEventBus(),
 	}
 
 	return store
@@ -93,16 +100,44 @@ type datastore struct {
 	// key: InferenceModel.Spec.ModelName, value: *InferenceModel
 	models map[string]*v1alpha2.InferenceModel
 	// key: types.NamespacedName, value: backendmetrics.PodMetrics
-	pods *sync.Map
-	pmf  *backendmetrics.PodMetricsFactory
+	pods   *sync.Map
+	pmf    *backendmetrics.PodMetricsFactory
+	events *eventBus
 }
 
 func (ds *datastore) Clear() {
 	ds.poolAndModelsMu.Lock()
 	defer ds.poolAndModelsMu.Unlock()
+	events := []Event{{Type: PoolChanged}}
+	for modelName := range ds.models {
+		events = append(events, Event{Type: ModelChanged, ModelName: modelName})
+	}
+	ds.pods.Range(func(k, v any) bool {
+		events = append(events, Event{Type: PodRemoved, Pod: v.(backendmetrics.PodMetrics).GetPod()})
+		return true
+	})
 	ds.pool = nil
 	ds.models = make(map[string]*v1alpha2.InferenceModel)
 	ds.pods.Clear()
+	ds.events.publish(events...)
+}
+
+func (ds *datastore) Subscribe(ctx context.Context, handler EventHandler) func() {
+	return ds.events.subscribe(ctx, handler, func() []Event {
+		ds.poolAndModelsMu.RLock()
+		events := []Event{}
+		if ds.pool != nil {
+			events = append(events, Event{Type: PoolChanged, Pool: ds.pool})
+		}
+		for modelName, model := range ds.models {
+			events = append(events, Event{Type: ModelChanged, ModelName: modelName, Model: model})
+		}
+		ds.poolAndModelsMu.RUnlock()
+		for _, pm := range ds.PodGetAll() {
+			events = append(events, Event{Type: PodAdded, Pod: pm.GetPod()})
+		}
+		return events
+	})
 }
 
 // /// InferencePool APIs ///
@@ -117,6 +152,7 @@ func (ds *datastore) PoolSet(ctx context.Context, client client.Client, pool *v1
 
 	oldPool := ds.pool
 	ds.pool = pool
+	ds.events.publish(Event{Type: PoolChanged, Pool: pool})
 	if oldPool == nil || !reflect.DeepEqual(pool.Spec.Selector, oldPool.Spec.Selector) {
 		logger.V(logutil.DEFAULT).Info("Updating inference pool endpoints", "selector", pool.Spec.Selector)
 		// A full resync is required to address two cases:
@@ -175,6 +211,7 @@ func (ds *datastore) ModelSetIfOlder(infModel *v1alpha2.InferenceModel) bool {
 	}
 	// Set the model.
 	ds.models[infModel.Spec.ModelName] = infModel
+	ds.events.publish(Event{Type: ModelChanged, ModelName: infModel.Spec.ModelName, Model: infModel})
 	return true
 }
 
@@ -207,6 +244,7 @@ func (ds *datastore) ModelResync(ctx context.Context, c client.Client, modelName
 		return false, nil
 	}
 	ds.models[modelName] = oldest
+	ds.events.publish(Event{Type: ModelChanged, ModelName: modelName, Model: oldest})
 	return true, nil
 }
 
@@ -222,6 +260,7 @@ func (ds *datastore) ModelDelete(namespacedName types.NamespacedName) *v1alpha2.
 	for _, m := range ds.models {
 		if m.Name == namespacedName.Name && m.Namespace == namespacedName.Namespace {
 			delete(ds.models, m.Spec.ModelName)
+			ds.events.publish(Event{Type: ModelChanged, ModelName: m.Spec.ModelName})
 			return m
 		}
 	}
@@ -263,15 +302,22 @@ func (ds *datastore) PodUpdateOrAddIfNotExist(pod *corev1.Pod) bool {
 		Namespace: pod.Namespace,
 	}
 	var pm backendmetrics.PodMetrics
+	var old *backendmetrics.Pod
 	existing, ok := ds.pods.Load(namespacedName)
 	if !ok {
 		pm = ds.pmf.NewPodMetrics(ds.parentCtx, pod, ds)
 		ds.pods.Store(namespacedName, pm)
 	} else {
 		pm = existing.(backendmetrics.PodMetrics)
+		old = pm.GetPod()
 	}
 	// Update pod properties if anything changed.
 	pm.UpdatePod(pod)
+	if !ok {
+		ds.events.publish(Event{Type: PodAdded, Pod: pm.GetPod()})
+	} else if updated := pm.GetPod(); !reflect.DeepEqual(old, updated) {
+		ds.events.publish(Event{Type: PodUpdated, Pod: updated})
+	}
 	return ok
 }
 
@@ -280,6 +326,7 @@ func (ds *datastore) PodDelete(namespacedName types.NamespacedName) {
 	if ok {
 		pmr := v.(backendmetrics.PodMetrics)
 		pmr.StopRefreshLoop()
+		ds.events.publish(Event{Type: PodRemoved, Pod: pmr.GetPod()})
 	}
 }
 
@@ -300,7 +347,11 @@ func (ds *datastore) podResyncAll(ctx context.Context, ctrlClient client.Client)
 		}
 		namespacedName := types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}
 		activePods[pod.Name] = true
-		if ds.PodUpdateOrAddIfNotExist(&pod) {
+		topologyPod, err := podutil.WithNodeTopology(ctx, ctrlClient, &pod)
+		if err != nil {
+			logger.V(logutil.DEBUG).Info("Unable to get the topology of the pod node", "name", namespacedName, "error", err)
+		}
+		if ds.PodUpdateOrAddIfNotExist(topologyPod) {
 			logger.V(logutil.DEFAULT).Info("Pod added", "name", namespacedName)
 		} else {
 			logger.V(logutil.DEFAULT).Info("Pod already exists", "name", namespacedName)
@@ -0,0 +1,165 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datastore
+
+import (
+	"context"
+	"sync"
+
+	"sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+)
+
+// EventType is the type of a datastore event.
+type EventType string
+
+const (
+	// PodAdded is published when a pod is added to the datastore.
+	PodAdded EventType = "PodAdded"
+	// PodUpdated is published when the properties of a pod in the datastore change, e.g., its labels.
+	PodUpdated EventType = "PodUpdated"
+	// PodRemoved is published when a pod is removed from the datastore, including when the pool is
+	// deleted.
+	PodRemoved EventType = "PodRemoved"
+	// PoolChanged is published when the pool is set or deleted.
+	PoolChanged EventType = "PoolChanged"
+	// ModelChanged is published when a model is set or deleted.
+	ModelChanged EventType = "ModelChanged"
+)
+
+// Event is a change of the datastore.
+type Event struct {
+	Type EventType
+	// Pod is the pod of pod events.
+	Pod *backendmetrics.Pod
+	// Pool is the pool of PoolChanged events, nil when the pool was deleted.
+	Pool *v1alpha2.InferencePool
+	// ModelName is the model name of ModelChanged events.
+	ModelName string
+	// Model is the model of ModelChanged events, nil when the model was deleted.
+	Model *v1alpha2.InferenceModel
+}
+
+// EventHandler handles datastore events.
+type EventHandler func(event Event)
+
+// eventBus dispatches the datastore events to the subscribers. Each subscriber receives the events
+// in order on its own goroutine, so that publishing never blocks the datastore, and handlers may
+// safely call back into the datastore.
+type eventBus struct {
+	mu          sync.Mutex
+	nextID      int
+	subscribers map[int]*subscriber
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subscribers: make(map[int]*subscriber)}
+}
+
+// subscribe registers the handler until the returned function is called or the context is done.
+// The handler first receives the events returned by snapshot, which is called once the handler is
+// registered, so that no event is missed in between.
+func (b *eventBus) subscribe(ctx context.Context, handler EventHandler, snapshot func() []Event) func() {
+	sub := &subscriber{handler: handler, notify: make(chan struct{}, 1), done: make(chan struct{})}
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = sub
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, id)
+			b.mu.Unlock()
+			close(sub.done)
+		})
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			unsubscribe()
+		case <-sub.done:
+		}
+	}()
+
+	initial := snapshot()
+	sub.mu.Lock()
+	sub.queue = append(initial, sub.queue...)
+	sub.mu.Unlock()
+	sub.signal()
+	go sub.run()
+	return unsubscribe
+}
+
+func (b *eventBus) publish(events ...Event) {
+	if len(events) == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.subscribers {
+		sub.enqueue(events)
+	}
+}
+
+type subscriber struct {
+	handler EventHandler
+
+	mu     sync.Mutex
+	queue  []Event
+	notify chan struct{}
+	done   chan struct{}
+}
+
+func (s *subscriber) enqueue(events []Event) {
+	s.mu.Lock()
+	s.queue = append(s.queue, events...)
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *subscriber) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *subscriber) run() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.notify:
+		}
+		s.mu.Lock()
+		events := s.queue
+		s.queue = nil
+		s.mu.Unlock()
+		for _, event := range events {
+			select {
+			case <-s.done:
+				return
+			default:
+				s.handler(event)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datastore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	testutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/testing"
+)
+
+// eventRecorder records the type and name of the received events.
+type eventRecorder chan string
+
+func (r eventRecorder) handle(event Event) {
+	switch {
+	case event.Pod != nil:
+		r <- string(event.Type) + "/" + event.Pod.NamespacedName.Name
+	case event.ModelName != "":
+		r <- string(event.Type) + "/" + event.ModelName
+	default:
+		r <- string(event.Type)
+	}
+}
+
+func (r eventRecorder) next(t *testing.T, count int) []string {
+	t.Helper()
+	got := []string{}
+	for range count {
+		select {
+		case event := <-r:
+			got = append(got, event)
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for events, got %v", got)
+		}
+	}
+	return got
+}
+
+func TestSubscribe(t *testing.T) {
+	pmf := backendmetrics.NewPodMetricsFactory(&backendmetrics.FakePodMetricsClient{}, time.Second)
+	ds := NewDatastore(t.Context(), pmf)
+	ds.PodUpdateOrAddIfNotExist(pod1)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	recorder := make(eventRecorder, 10)
+	ds.Subscribe(ctx, recorder.handle)
+
+	// The subscriber first receives the current state.
+	if diff := cmp.Diff([]string{"PodAdded/pod1"}, recorder.next(t, 1)); diff != "" {
+		t.Errorf("Unexpected initial events (-want +got): %s", diff)
+	}
+
+	ds.PodUpdateOrAddIfNotExist(pod2)
+	ds.PodUpdateOrAddIfNotExist(pod2) // unchanged, no event
+	ds.PodUpdateOrAddIfNotExist(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod2"},
+		Status:     corev1.PodStatus{PodIP: "10.0.0.2"},
+	})
+	ds.PodDelete(pod1NamespacedName)
+	model := testutil.MakeInferenceModel("model1").ModelName("chat").ObjRef()
+	ds.ModelSetIfOlder(model)
+	ds.ModelDelete(types.NamespacedName{Name: model.Name, Namespace: model.Namespace})
+	want := []string{
+		"PodAdded/pod2",
+		"PodUpdated/pod2",
+		"PodRemoved/pod1",
+		"ModelChanged/chat",
+		"ModelChanged/chat",
+	}
+	if diff := cmp.Diff(want, recorder.next(t, len(want))); diff != "" {
+		t.Errorf("Unexpected events (-want +got): %s", diff)
+	}
+
+	if err := ds.PoolSet(ctx, nil, nil); err != nil { // clears the store
+		t.Fatalf("Failed to clear the pool: %v", err)
+	}
+	if diff := cmp.Diff([]string{"PoolChanged", "PodRemoved/pod2"}, recorder.next(t, 2)); diff != "" {
+		t.Errorf("Unexpected events (-want +got): %s", diff)
+	}
+
+	// Once unsubscribed, no more events are received.
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	ds.PodUpdateOrAddIfNotExist(pod1)
+	select {
+	case event := <-recorder:
+		t.Errorf("Unexpected event after unsubscribing: %s", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
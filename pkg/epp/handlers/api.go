@@ -0,0 +1,216 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	schedulingtypes "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// apiPaths are the path suffixes of the OpenAI compatible endpoints by API type, "/chat/completions"
+// being matched before "/completions".
+var apiPaths = []struct {
+	suffix  string
+	apiType string
+}{
+	{"/chat/completions", schedulingtypes.ChatCompletionsAPI},
+	{"/completions", schedulingtypes.CompletionsAPI},
+	{"/embeddings", schedulingtypes.EmbeddingsAPI},
+	{"/rerank", schedulingtypes.RerankAPI},
+	{"/responses", schedulingtypes.ResponsesAPI},
+	{"/audio/transcriptions", schedulingtypes.TranscriptionsAPI},
+}
+
+// pathAPIType returns the API type of a request from its path, empty if unknown.
+func pathAPIType(path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	path = strings.TrimSuffix(path, "/")
+	for _, api := range apiPaths {
+		if strings.HasSuffix(path, api.suffix) {
+			return api.apiType
+		}
+	}
+	return ""
+}
+
+// parseAPIRequestBody parses the body of a request of the given API type, a multipart form for
+// audio transcriptions and a JSON object otherwise.
+func parseAPIRequestBody(apiType string, raw []byte, contentType string) (*requestBody, error) {
+	if apiType == schedulingtypes.TranscriptionsAPI {
+		return parseFormRequestBody(raw, contentType)
+	}
+	return parseRequestBody(raw)
+}
+
+// apiType returns the API type of a request sent to an unknown path from its body: chat
+// completions if it has messages, completions otherwise.
+func (b *requestBody) apiType() string {
+	if b.messages != nil {
+		return schedulingtypes.ChatCompletionsAPI
+	}
+	return schedulingtypes.CompletionsAPI
+}
+
+// fillInputs sets the prompt, batch size and prompt tokens of the given request from the inputs of
+// the body, as per the API type of the request. Inputs of unexpected types are returned as errors.
+func (b *requestBody) fillInputs(req *schedulingtypes.LLMRequest) error {
+	var err error
+	switch req.APIType {
+	case schedulingtypes.ChatCompletionsAPI:
+		if b.messages != nil {
+			req.ChatCompletionRequest, err = schedulingtypes.NewKVCacheChatCompletionRequestFromJSON(b.messages, b.tools, b.toolChoices)
+		}
+	case schedulingtypes.CompletionsAPI, schedulingtypes.TranscriptionsAPI:
+		err = fillTextInputs(req, b.prompt)
+	case schedulingtypes.EmbeddingsAPI:
+		err = fillTextInputs(req, b.input)
+	case schedulingtypes.RerankAPI:
+		err = fillRerankInputs(req, b.query, b.documents)
+	case schedulingtypes.ResponsesAPI:
+		if b.instructions != nil || b.input != nil {
+			req.ChatCompletionRequest, err = schedulingtypes.NewKVCacheChatCompletionRequestFromResponsesJSON(b.instructions, b.input)
+		}
+	}
+	return err
+}
+
+// fillTextInputs sets the prompt of the given request from the raw value of an input of completions
+// or embeddings: a string, an array of token IDs, or a batch of strings or token ID arrays. As the
+// inputs of a batch are separate sequences, the prompt is the longest of them.
+func fillTextInputs(req *schedulingtypes.LLMRequest, raw []byte) error {
+	if raw == nil || string(raw) == "null" {
+		return nil
+	}
+	if raw[0] == '"' {
+		var err error
+		req.Prompt, err = decodeString(raw)
+		return err
+	}
+
+	var inputs []json.RawMessage
+	if err := json.Unmarshal(raw, &inputs); err != nil {
+		return err
+	}
+	if len(inputs) == 0 {
+		return nil
+	}
+	if first := inputs[0][0]; first != '"' && first != '[' {
+		var tokenIDs []int
+		err := json.Unmarshal(raw, &tokenIDs)
+		req.PromptTokens = len(tokenIDs)
+		return err
+	}
+	longest := longestInput{}
+	for _, input := range inputs {
+		switch input[0] {
+		case '"':
+			text, err := decodeString(input)
+			if err != nil {
+				return err
+			}
+			longest.addText(text)
+		case '[':
+			var tokenIDs []int
+			if err := json.Unmarshal(input, &tokenIDs); err != nil {
+				return err
+			}
+			longest.addTokens(len(tokenIDs))
+		default:
+			return fmt.Errorf("unexpected batched input %s", input)
+		}
+	}
+	req.Prompt, req.PromptTokens = longest.text, longest.tokens
+	req.BatchSize = len(inputs)
+	return nil
+}
+
+// longestInput is the longest of the inputs of a batch, either a text or a number of token IDs, as
+// per the estimated number of tokens of the texts.
+type longestInput struct {
+	text   string
+	tokens int
+}
+
+func (l *longestInput) addText(text string) {
+	if l.tokens > 0 && schedulingtypes.EstimatedTokens(len(text)) > l.tokens || l.tokens == 0 && len(text) > len(l.text) {
+		*l = longestInput{text: text}
+	}
+}
+
+func (l *longestInput) addTokens(tokens int) {
+	if tokens > schedulingtypes.EstimatedTokens(len(l.text))+l.tokens {
+		*l = longestInput{tokens: tokens}
+	}
+}
+
+// rerankDocument is a document of a rerank request given as an object instead of a string.
+type rerankDocument struct {
+	Text string `json:"text"`
+}
+
+// fillRerankInputs sets the prompt of the given request from the raw values of the query and
+// documents of a rerank request. As each document is scored along with the query in a separate
+// sequence, the prompt is the query followed by the longest document.
+func fillRerankInputs(req *schedulingtypes.LLMRequest, query, documents []byte) error {
+	var texts []string
+	if query != nil {
+		text, _, err := stringValue(query)
+		if err != nil {
+			return err
+		}
+		texts = append(texts, text)
+	}
+	longest := ""
+	if documents != nil && string(documents) != "null" {
+		var docs []json.RawMessage
+		if err := json.Unmarshal(documents, &docs); err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			var text string
+			switch doc[0] {
+			case '"':
+				var err error
+				if text, err = decodeString(doc); err != nil {
+					return err
+				}
+			case '{':
+				var d rerankDocument
+				if err := json.Unmarshal(doc, &d); err != nil {
+					return err
+				}
+				text = d.Text
+			default:
+				return fmt.Errorf("unexpected document %s", doc)
+			}
+			if len(text) > len(longest) {
+				longest = text
+			}
+		}
+		if len(docs) > 0 {
+			texts = append(texts, longest)
+		}
+		req.BatchSize = len(docs)
+	}
+	req.Prompt = strings.Join(texts, "\n")
+	return nil
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sashabaranov/go-openai"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/filter"
+	schedulingtypes "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestPathAPIType(t *testing.T) {
+	tests := map[string]string{
+		"/v1/completions":              schedulingtypes.CompletionsAPI,
+		"/v1/chat/completions":         schedulingtypes.ChatCompletionsAPI,
+		"/openai/v1/chat/completions/": schedulingtypes.ChatCompletionsAPI,
+		"/v1/embeddings?user=u1":       schedulingtypes.EmbeddingsAPI,
+		"/v1/rerank":                   schedulingtypes.RerankAPI,
+		"/rerank":                      schedulingtypes.RerankAPI,
+		"/v1/responses":                schedulingtypes.ResponsesAPI,
+		"/v1/audio/transcriptions":     schedulingtypes.TranscriptionsAPI,
+		"/v1/models":                   "",
+		"":                             "",
+	}
+	for path, want := range tests {
+		if got := pathAPIType(path); got != want {
+			t.Errorf("Unexpected API type of %q, want %q, got %q", path, want, got)
+		}
+	}
+}
+
+func TestFillInputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		apiType string
+		body    string
+		want    *schedulingtypes.LLMRequest
+		wantErr bool
+	}{
+		{
+			name:    "completions prompt",
+			apiType: schedulingtypes.CompletionsAPI,
+			body:    `{"model": "m", "prompt": "hello"}`,
+			want:    &schedulingtypes.LLMRequest{Prompt: "hello"},
+		},
+		{
+			name:    "completions batch",
+			apiType: schedulingtypes.CompletionsAPI,
+			body:    `{"model": "m", "prompt": ["hello", "hello!"]}`,
+			want:    &schedulingtypes.LLMRequest{Prompt: "hello!", BatchSize: 2},
+		},
+		{
+			name:    "embeddings input",
+			apiType: schedulingtypes.EmbeddingsAPI,
+			body:    `{"model": "m", "input": "hello"}`,
+			want:    &schedulingtypes.LLMRequest{Prompt: "hello"},
+		},
+		{
+			name:    "embeddings token IDs",
+			apiType: schedulingtypes.EmbeddingsAPI,
+			body:    `{"model": "m", "input": [1, 2, 3]}`,
+			want:    &schedulingtypes.LLMRequest{PromptTokens: 3},
+		},
+		{
+			name:    "embeddings batch of token IDs",
+			apiType: schedulingtypes.EmbeddingsAPI,
+			body:    `{"model": "m", "input": [[1, 2], [3], [4, 5, 6]]}`,
+			want:    &schedulingtypes.LLMRequest{PromptTokens: 3, BatchSize: 3},
+		},
+		{
+			name:    "embeddings batch of texts and token IDs",
+			apiType: schedulingtypes.EmbeddingsAPI,
+			body:    `{"model": "m", "input": ["hello", [1, 2, 3], "hi"]}`,
+			want:    &schedulingtypes.LLMRequest{PromptTokens: 3, BatchSize: 3},
+		},
+		{
+			name:    "embeddings of unexpected input",
+			apiType: schedulingtypes.EmbeddingsAPI,
+			body:    `{"model": "m", "input": ["hello", {"text": "world"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "rerank",
+			apiType: schedulingtypes.RerankAPI,
+			body:    `{"model": "m", "query": "what?", "documents": ["a", {"text": "bb"}]}`,
+			want:    &schedulingtypes.LLMRequest{Prompt: "what?\nbb", BatchSize: 2},
+		},
+		{
+			name:    "transcriptions prompt",
+			apiType: schedulingtypes.TranscriptionsAPI,
+			body:    `{"model": "m", "prompt": "names: Ada"}`,
+			want:    &schedulingtypes.LLMRequest{Prompt: "names: Ada"},
+		},
+		{
+			name:    "chat completions",
+			apiType: schedulingtypes.ChatCompletionsAPI,
+			body:    `{"model": "m", "messages": [{"role": "user", "content": "hi"}]}`,
+			want: &schedulingtypes.LLMRequest{ChatCompletionRequest: &schedulingtypes.KVCacheChatCompletionRequest{
+				Messages: []openai.ChatCompletionMessage{{Role: "user", Content: "hi"}},
+			}},
+		},
+		{
+			name:    "responses string input",
+			apiType: schedulingtypes.ResponsesAPI,
+			body:    `{"model": "m", "instructions": "be brief", "input": "hi"}`,
+			want: &schedulingtypes.LLMRequest{ChatCompletionRequest: &schedulingtypes.KVCacheChatCompletionRequest{
+				Messages: []openai.ChatCompletionMessage{{Role: "system", Content: "be brief"}, {Role: "user", Content: "hi"}},
+			}},
+		},
+		{
+			name:    "responses items",
+			apiType: schedulingtypes.ResponsesAPI,
+			body: `{"model": "m", "input": [{"role": "user", "content": [{"type": "input_text", "text": "what is this?"},` +
+				` {"type": "input_image", "image_url": "https://example.com/a.png"}]},` +
+				` {"type": "function_call_output", "call_id": "c1", "output": "42"}]}`,
+			want: &schedulingtypes.LLMRequest{ChatCompletionRequest: &schedulingtypes.KVCacheChatCompletionRequest{
+				Messages: []openai.ChatCompletionMessage{
+					{Role: "user", MultiContent: []openai.ChatMessagePart{
+						{Type: openai.ChatMessagePartTypeText, Text: "what is this?"},
+						{Type: "input_image"},
+					}},
+					{Role: "function_call_output", Content: `{"type": "function_call_output", "call_id": "c1", "output": "42"}`},
+				},
+			}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, err := parseRequestBody([]byte(test.body))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := &schedulingtypes.LLMRequest{APIType: test.apiType}
+			err = body.fillInputs(got)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Unexpected error, want %v, got %v", test.wantErr, err)
+			}
+			if test.wantErr {
+				return
+			}
+			test.want.APIType = test.apiType
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Unexpected request (-want +got): %v", diff)
+			}
+		})
+	}
+
+	// The responses request with an image is multimodal.
+	body, err := parseRequestBody([]byte(tests[len(tests)-1].body))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req := &schedulingtypes.LLMRequest{APIType: schedulingtypes.ResponsesAPI}
+	if err := body.fillInputs(req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !req.HasMultimodalInput() {
+		t.Errorf("Expected the responses request with an image to have multimodal input")
+	}
+}
+
+func TestFillInputsLargeBatch(t *testing.T) {
+	// 256 inputs of 2000 characters, estimated to 500 tokens each, 128000 tokens in total.
+	inputs := make([]string, 256)
+	for i := range inputs {
+		inputs[i] = strings.Repeat("a", 2000)
+	}
+	raw, err := json.Marshal(map[string]any{"model": "m", "input": inputs})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	body, err := parseRequestBody(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req := &schedulingtypes.LLMRequest{APIType: schedulingtypes.EmbeddingsAPI}
+	if err := body.fillInputs(req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := req.EstimatedPromptTokens(); got != 500 {
+		t.Errorf("Unexpected estimated prompt tokens, want 500, got %d", got)
+	}
+
+	// The batch fits the context of the pod, as each input is a separate sequence.
+	pods := []schedulingtypes.Pod{&schedulingtypes.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod"}, MaxModelLen: 8192},
+		Metrics: &backendmetrics.Metrics{},
+	}}
+	ctx := schedulingtypes.NewSchedulingContext(context.Background(), req, pods, 0)
+	if got := (&filter.ContextLengthFilter{}).Filter(ctx, pods); len(got) != 1 || ctx.Rejection != nil {
+		t.Errorf("Expected the batch to fit the pod, got %v, rejection: %v", got, ctx.Rejection)
+	}
+}
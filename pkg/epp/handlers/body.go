@@ -0,0 +1,476 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+var errUnexpectedEnd = errors.New("unexpected end of JSON input")
+
+// requestBody holds the fields of a request body the EPP needs, parsed by scanning the body instead
+// of decoding it: the values of the other fields, e.g., the prompt of chat completions, are only
+// skipped. The body is not otherwise validated, which is left to the model server.
+type requestBody struct {
+	// raw is the request body.
+	raw []byte
+
+	// model is the model of the request, and hasModel is true if it is set as a string.
+	model    string
+	hasModel bool
+	// modelStart and modelEnd delimit the raw value of the model in the body.
+	modelStart, modelEnd int
+
+	// form is true for multipart form bodies, whose values are not JSON.
+	form bool
+	// truncated is true if the body is a prefix of the request body, whose fields after the end of
+	// the prefix are missing.
+	truncated bool
+
+	// prompt, messages, tools, toolChoices, input, instructions, query and documents are the raw
+	// values of the inputs of the various APIs, nil if not set. The prompt of a multipart form body
+	// is encoded as a JSON string.
+	prompt, messages, tools, toolChoices []byte
+	input, instructions                  []byte
+	query, documents                     []byte
+	stream                               bool
+	// maxTokens, maxCompletionTokens and maxResponseTokens, of the "max_output_tokens" field, are
+	// zero if not set.
+	maxTokens           int
+	maxCompletionTokens int
+	maxResponseTokens   int
+	user                string
+}
+
+// parseRequestBody scans the top-level fields of the given JSON object.
+func parseRequestBody(raw []byte) (*requestBody, error) {
+	return scanRequestBody(raw, false)
+}
+
+// parseRequestBodyPrefix scans the top-level fields of the given prefix of a JSON object, e.g., of
+// a body truncated to the buffer of Envoy. The fields cut by the end of the prefix are ignored.
+func parseRequestBodyPrefix(raw []byte) (*requestBody, error) {
+	return scanRequestBody(raw, true)
+}
+
+func scanRequestBody(raw []byte, prefix bool) (*requestBody, error) {
+	b := &requestBody{raw: raw}
+	fail := func(err error) (*requestBody, error) {
+		if prefix && errors.Is(err, errUnexpectedEnd) {
+			b.truncated = true
+			return b, nil
+		}
+		return nil, err
+	}
+	i := skipSpace(raw, 0)
+	if i >= len(raw) || raw[i] != '{' {
+		return nil, errors.New("request body is not a JSON object")
+	}
+	i = skipSpace(raw, i+1)
+	if i < len(raw) && raw[i] == '}' {
+		return b, b.checkEnd(i + 1)
+	}
+	for {
+		keyEnd, err := skipString(raw, i)
+		if err != nil {
+			return fail(err)
+		}
+		key, err := decodeString(raw[i:keyEnd])
+		if err != nil {
+			return nil, err
+		}
+		i = skipSpace(raw, keyEnd)
+		if i >= len(raw) || raw[i] != ':' {
+			return fail(syntaxError(raw, i, "':' after object key"))
+		}
+		valueStart := skipSpace(raw, i+1)
+		valueEnd, err := skipValue(raw, valueStart)
+		if err != nil {
+			return fail(err)
+		}
+		if prefix && valueEnd == len(raw) && raw[valueStart] != '"' && raw[valueStart] != '{' && raw[valueStart] != '[' {
+			// A number or literal ending the prefix may be cut.
+			return fail(errUnexpectedEnd)
+		}
+		if err := b.setField(key, valueStart, valueEnd); err != nil {
+			return nil, fmt.Errorf("field %s: %w", key, err)
+		}
+
+		i = skipSpace(raw, valueEnd)
+		if i >= len(raw) {
+			return fail(errUnexpectedEnd)
+		}
+		switch raw[i] {
+		case ',':
+			i = skipSpace(raw, i+1)
+		case '}':
+			return b, b.checkEnd(i + 1)
+		default:
+			return nil, syntaxError(raw, i, "',' or '}' after object value")
+		}
+	}
+}
+
+// setField records the value of the given field if the EPP needs it. Values of unexpected types are
+// ignored, as when decoding the body into a map.
+func (b *requestBody) setField(key string, start, end int) error {
+	value := b.raw[start:end]
+	var err error
+	switch key {
+	case "model":
+		b.model, b.hasModel, err = stringValue(value)
+		b.modelStart, b.modelEnd = start, end
+	case "prompt":
+		b.prompt = value
+	case "messages":
+		b.messages = value
+	case "tools":
+		b.tools = value
+	case "tool_choices":
+		b.toolChoices = value
+	case "input":
+		b.input = value
+	case "instructions":
+		b.instructions = value
+	case "query":
+		b.query = value
+	case "documents":
+		b.documents = value
+	case "stream":
+		b.stream = string(value) == "true"
+	case "max_tokens":
+		b.maxTokens = intValue(value)
+	case "max_completion_tokens":
+		b.maxCompletionTokens = intValue(value)
+	case "max_output_tokens":
+		b.maxResponseTokens = intValue(value)
+	case "user":
+		b.user, _, err = stringValue(value)
+	}
+	return err
+}
+
+func (b *requestBody) checkEnd(i int) error {
+	if i = skipSpace(b.raw, i); i != len(b.raw) {
+		return syntaxError(b.raw, i, "end of input after top-level value")
+	}
+	return nil
+}
+
+// maxOutputTokens returns the maximum number of tokens to generate, from the "max_tokens" field or
+// else from the "max_completion_tokens" or "max_output_tokens" fields, zero if not set.
+func (b *requestBody) maxOutputTokens() int {
+	if b.maxTokens > 0 {
+		return b.maxTokens
+	}
+	if b.maxCompletionTokens > 0 {
+		return b.maxCompletionTokens
+	}
+	return b.maxResponseTokens
+}
+
+// withModel returns the body with the given model, patching the raw value of the model instead of
+// re-encoding the body. The body is returned as is if the model is unchanged.
+func (b *requestBody) withModel(model string) ([]byte, error) {
+	if b.hasModel && model == b.model {
+		return b.raw, nil
+	}
+	if !b.hasModel {
+		return nil, errors.New("no model to replace in request body")
+	}
+	value := []byte(model)
+	if !b.form {
+		var err error
+		if value, err = json.Marshal(model); err != nil {
+			return nil, err
+		}
+	}
+	patched := make([]byte, 0, len(b.raw)-(b.modelEnd-b.modelStart)+len(value))
+	patched = append(patched, b.raw[:b.modelStart]...)
+	patched = append(patched, value...)
+	return append(patched, b.raw[b.modelEnd:]...), nil
+}
+
+// parseFormRequestBody scans the parts of the given multipart form body, e.g., of audio
+// transcriptions requests, with the given content type.
+func parseFormRequestBody(raw []byte, contentType string) (*requestBody, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return nil, fmt.Errorf("request body is not a multipart form: content type %q", contentType)
+	}
+	b := &requestBody{raw: raw, form: true}
+	// Each part follows a delimiter line, the first of which may not be preceded by a line break.
+	delimiter := []byte("\r\n--" + params["boundary"])
+	i := bytes.Index(raw, delimiter[2:])
+	if i < 0 {
+		return nil, errors.New("no part in multipart form")
+	}
+	i += len(delimiter) - 2
+	for !bytes.HasPrefix(raw[i:], []byte("--")) {
+		headersEnd := bytes.Index(raw[i:], []byte("\r\n\r\n"))
+		if headersEnd < 0 {
+			return nil, errUnexpectedEnd
+		}
+		start := i + headersEnd + 4
+		end := bytes.Index(raw[start:], delimiter)
+		if end < 0 {
+			return nil, errUnexpectedEnd
+		}
+		end += start
+		if err := b.setFormField(formName(raw[i:i+headersEnd]), start, end); err != nil {
+			return nil, err
+		}
+		i = end + len(delimiter)
+	}
+	return b, nil
+}
+
+// setFormField records the value of the given form field if the EPP needs it.
+func (b *requestBody) setFormField(name string, start, end int) error {
+	value := b.raw[start:end]
+	var err error
+	switch name {
+	case "model":
+		b.model, b.hasModel = string(value), true
+		b.modelStart, b.modelEnd = start, end
+	case "prompt":
+		b.prompt, err = json.Marshal(string(value))
+	case "stream":
+		b.stream = string(value) == "true"
+	}
+	return err
+}
+
+// formName returns the name of the form field of the part with the given headers, empty if none.
+func formName(headers []byte) string {
+	for _, line := range strings.Split(string(headers), "\r\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "Content-Disposition") {
+			continue
+		}
+		if _, params, err := mime.ParseMediaType(value); err == nil {
+			return params["name"]
+		}
+	}
+	return ""
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func skipSpace(raw []byte, i int) int {
+	for i < len(raw) && isSpace(raw[i]) {
+		i++
+	}
+	return i
+}
+
+// skipString returns the end of the string starting at i.
+func skipString(raw []byte, i int) (int, error) {
+	if i >= len(raw) || raw[i] != '"' {
+		return 0, syntaxError(raw, i, "string")
+	}
+	for j := i + 1; j < len(raw); {
+		k := bytes.IndexAny(raw[j:], `"\`)
+		if k < 0 {
+			break
+		}
+		j += k
+		if raw[j] == '"' {
+			return j + 1, nil
+		}
+		j += 2 // Skips the escaped character.
+	}
+	return 0, errUnexpectedEnd
+}
+
+// skipValue returns the end of the value starting at i.
+func skipValue(raw []byte, i int) (int, error) {
+	if i >= len(raw) {
+		return 0, errUnexpectedEnd
+	}
+	switch raw[i] {
+	case '"':
+		return skipString(raw, i)
+	case '{', '[':
+		depth := 0
+		for j := i; j < len(raw); j++ {
+			switch raw[j] {
+			case '"':
+				end, err := skipString(raw, j)
+				if err != nil {
+					return 0, err
+				}
+				j = end - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return j + 1, nil
+				}
+			}
+		}
+		return 0, errUnexpectedEnd
+	default:
+		// A number, or true, false or null.
+		j := i
+		for j < len(raw) && raw[j] != ',' && raw[j] != '}' && raw[j] != ']' && !isSpace(raw[j]) {
+			j++
+		}
+		if j == i {
+			return 0, syntaxError(raw, i, "value")
+		}
+		return j, nil
+	}
+}
+
+// decodeString decodes the given raw JSON string, copying it as is unless it holds escapes.
+func decodeString(value []byte) (string, error) {
+	if bytes.IndexByte(value, '\\') < 0 {
+		return string(value[1 : len(value)-1]), nil
+	}
+	var s string
+	err := json.Unmarshal(value, &s)
+	return s, err
+}
+
+// stringValue decodes the given raw JSON value if it is a string.
+func stringValue(value []byte) (string, bool, error) {
+	if value[0] != '"' {
+		return "", false, nil
+	}
+	s, err := decodeString(value)
+	return s, err == nil, err
+}
+
+// intValue returns the given raw JSON value if it is a number, truncated, zero otherwise.
+func intValue(value []byte) int {
+	f, err := strconv.ParseFloat(string(value), 64)
+	if err != nil {
+		return 0
+	}
+	return int(f)
+}
+
+func syntaxError(raw []byte, i int, want string) error {
+	if i >= len(raw) {
+		return errUnexpectedEnd
+	}
+	return fmt.Errorf("invalid character %q at offset %d, want %s", raw[i], i, want)
+}
+
+// usageScanner extracts the top-level "usage" object of a JSON response body written to it in
+// chunks, keeping only the usage instead of buffering the body. Like json.Unmarshal, the usage
+// objects of a body with duplicate "usage" keys are decoded in order, so the last one wins.
+type usageScanner struct {
+	depth    int
+	inString bool
+	escaped  bool
+	// inKey is true while scanning a top-level key, kept in key, and expectKey is true when the next
+	// top-level string is a key.
+	inKey     bool
+	expectKey bool
+	key       []byte
+	// pendingUsage is true between the "usage" key and its value, captured while capturing and
+	// decoded into usage once complete.
+	pendingUsage bool
+	capturing    bool
+	captured     []byte
+	found        bool
+	usage        Usage
+	err          error
+}
+
+// scan scans the next chunk of the body.
+func (s *usageScanner) scan(chunk []byte) {
+	for _, c := range chunk {
+		if s.capturing {
+			s.captured = append(s.captured, c)
+		}
+		if s.inString {
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+				if s.inKey {
+					s.inKey = false
+					continue
+				}
+			}
+			if s.inKey {
+				s.key = append(s.key, c)
+			}
+			continue
+		}
+		if s.pendingUsage && !isSpace(c) {
+			// Only a usage object is captured, e.g., not a null usage.
+			s.pendingUsage = false
+			if c == '{' {
+				s.capturing = true
+				s.captured = append(s.captured[:0], c)
+			}
+		}
+		switch c {
+		case '"':
+			s.inString = true
+			if s.depth == 1 && s.expectKey {
+				s.inKey, s.expectKey = true, false
+				s.key = s.key[:0]
+			}
+		case '{', '[':
+			s.depth++
+			s.expectKey = s.depth == 1 && c == '{'
+		case '}', ']':
+			s.depth--
+			if s.capturing && s.depth == 1 {
+				s.capturing = false
+				s.decode()
+			}
+		case ',':
+			s.expectKey = s.depth == 1
+		case ':':
+			s.pendingUsage = s.depth == 1 && string(s.key) == "usage"
+		}
+	}
+}
+
+// decode decodes the captured usage object over the previous ones.
+func (s *usageScanner) decode() {
+	s.found = true
+	if err := json.Unmarshal(s.captured, &s.usage); err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+// result returns the usage of the body, and false if the body has no complete usage object.
+func (s *usageScanner) result() (Usage, bool, error) {
+	if !s.found || s.capturing {
+		return Usage{}, false, nil
+	}
+	return s.usage, s.err == nil, s.err
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRequestBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    *requestBody
+		wantErr bool
+	}{
+		{
+			name: "completions",
+			body: `{"model": "my-model", "prompt": "hello \"world\"", "max_tokens": 100, "stream": true, "user": "u1"}`,
+			want: &requestBody{model: "my-model", hasModel: true, modelStart: 10, modelEnd: 20, prompt: []byte(`"hello \"world\""`),
+				maxTokens: 100, stream: true, user: "u1"},
+		},
+		{
+			name: "chat completions",
+			body: `{"messages":[{"role":"user","content":"hi {]"}],"model":"my-model","max_completion_tokens":5.0,"stream":false}`,
+			want: &requestBody{model: "my-model", hasModel: true, modelStart: 56, modelEnd: 66,
+				messages: []byte(`[{"role":"user","content":"hi {]"}]`), maxCompletionTokens: 5},
+		},
+		{
+			name: "unexpected types ignored",
+			body: `{"model": 1, "prompt": ["a", "b"], "max_tokens": "100", "user": null, "stream": "true"}`,
+			want: &requestBody{modelStart: 10, modelEnd: 11, prompt: []byte(`["a", "b"]`)},
+		},
+		{
+			name: "responses",
+			body: `{"model": "my-model", "instructions": "be brief", "input": [{"role": "user", "content": "hi"}], "max_output_tokens": 20}`,
+			want: &requestBody{model: "my-model", hasModel: true, modelStart: 10, modelEnd: 20, instructions: []byte(`"be brief"`),
+				input: []byte(`[{"role": "user", "content": "hi"}]`), maxResponseTokens: 20},
+		},
+		{
+			name: "rerank",
+			body: `{"model": "my-model", "query": "q", "documents": ["a", {"text": "b"}]}`,
+			want: &requestBody{model: "my-model", hasModel: true, modelStart: 10, modelEnd: 20, query: []byte(`"q"`),
+				documents: []byte(`["a", {"text": "b"}]`)},
+		},
+		{
+			name: "empty object",
+			body: " {} ",
+			want: &requestBody{},
+		},
+		{
+			name:    "not an object",
+			body:    `["model"]`,
+			wantErr: true,
+		},
+		{
+			name:    "truncated",
+			body:    `{"model": "my-model", "prompt": "hel`,
+			wantErr: true,
+		},
+		{
+			name:    "missing colon",
+			body:    `{"model" "my-model"}`,
+			wantErr: true,
+		},
+		{
+			name:    "trailing data",
+			body:    `{"model": "my-model"} {}`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseRequestBody([]byte(test.body))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Unexpected error, want %v, got %v", test.wantErr, err)
+			}
+			if test.wantErr {
+				return
+			}
+			test.want.raw = []byte(test.body)
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(requestBody{})); diff != "" {
+				t.Errorf("Unexpected request body (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestParseRequestBodyPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    *requestBody
+		wantErr bool
+	}{
+		{
+			name: "complete",
+			body: `{"model": "my-model", "max_tokens": 10}`,
+			want: &requestBody{model: "my-model", hasModel: true, modelStart: 10, modelEnd: 20, maxTokens: 10},
+		},
+		{
+			name: "cut in a value",
+			body: `{"model": "my-model", "prompt": "hel`,
+			want: &requestBody{model: "my-model", hasModel: true, modelStart: 10, modelEnd: 20, truncated: true},
+		},
+		{
+			name: "cut after a number",
+			body: `{"model": "my-model", "max_tokens": 10`,
+			want: &requestBody{model: "my-model", hasModel: true, modelStart: 10, modelEnd: 20, truncated: true},
+		},
+		{
+			name: "cut after a value",
+			body: `{"prompt": "hi", "model": "my-model"`,
+			want: &requestBody{model: "my-model", hasModel: true, modelStart: 26, modelEnd: 36, prompt: []byte(`"hi"`), truncated: true},
+		},
+		{
+			name: "cut in a key",
+			body: `{"prompt": "hi", "mod`,
+			want: &requestBody{prompt: []byte(`"hi"`), truncated: true},
+		},
+		{
+			name:    "invalid",
+			body:    `{"model": "my-model" "prompt": "hel`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseRequestBodyPrefix([]byte(test.body))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Unexpected error, want %v, got %v", test.wantErr, err)
+			}
+			if test.wantErr {
+				return
+			}
+			test.want.raw = []byte(test.body)
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(requestBody{})); diff != "" {
+				t.Errorf("Unexpected request body (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestWithModel(t *testing.T) {
+	raw := []byte(`{"prompt": "hi", "model": "my-model", "max_tokens": 10}`)
+	body, err := parseRequestBody(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	unchanged, err := body.withModel("my-model")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if &unchanged[0] != &raw[0] {
+		t.Errorf("Expected the body to be passed through as is")
+	}
+
+	patched, err := body.withModel(`my-model-"v2"`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := `{"prompt": "hi", "model": "my-model-\"v2\"", "max_tokens": 10}`
+	if string(patched) != want {
+		t.Errorf("Unexpected patched body, want %s, got %s", want, patched)
+	}
+	if string(raw) != `{"prompt": "hi", "model": "my-model", "max_tokens": 10}` {
+		t.Errorf("Unexpected change of the original body: %s", raw)
+	}
+}
+
+func TestParseFormRequestBody(t *testing.T) {
+	body := "--xyz\r\n" +
+		"Content-Disposition: form-data; name=\"file\"; filename=\"audio.wav\"\r\n" +
+		"Content-Type: audio/wav\r\n\r\n" +
+		"RIFF\r\n--xy\x00\r\n" +
+		"--xyz\r\n" +
+		"Content-Disposition: form-data; name=\"model\"\r\n\r\n" +
+		"my-model\r\n" +
+		"--xyz\r\n" +
+		"content-disposition: form-data; name=\"prompt\"\r\n\r\n" +
+		"say \"hi\"\r\n" +
+		"--xyz--\r\n"
+	got, err := parseFormRequestBody([]byte(body), `multipart/form-data; boundary=xyz`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	modelStart := strings.Index(body, "my-model")
+	want := &requestBody{raw: []byte(body), form: true, model: "my-model", hasModel: true, modelStart: modelStart,
+		modelEnd: modelStart + len("my-model"), prompt: []byte(`"say \"hi\""`)}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(requestBody{})); diff != "" {
+		t.Errorf("Unexpected request body (-want +got): %v", diff)
+	}
+
+	patched, err := got.withModel("my-model-v2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := strings.Replace(body, "my-model", "my-model-v2", 1); string(patched) != want {
+		t.Errorf("Unexpected patched body, want %q, got %q", want, patched)
+	}
+
+	for _, test := range []struct {
+		name        string
+		body        string
+		contentType string
+	}{
+		{name: "not multipart", body: body, contentType: "application/json"},
+		{name: "no boundary", body: body, contentType: "multipart/form-data"},
+		{name: "no part", body: "hello", contentType: "multipart/form-data; boundary=xyz"},
+		{name: "truncated", body: body[:len(body)-20], contentType: "multipart/form-data; boundary=xyz"},
+	} {
+		if _, err := parseFormRequestBody([]byte(test.body), test.contentType); err == nil {
+			t.Errorf("Expected an error for %s body", test.name)
+		}
+	}
+}
+
+// largeChatCompletionsBody returns a chat completions request of about 100KB.
+func largeChatCompletionsBody(b *testing.B) []byte {
+	messages := []map[string]string{
+		{"role": "system", "content": strings.Repeat("You are a helpful assistant. ", 1000)},
+		{"role": "user", "content": strings.Repeat("Summarize the following text: lorem ipsum dolor sit amet. ", 1200)},
+	}
+	body, err := json.Marshal(map[string]any{"model": "my-model", "messages": messages, "max_tokens": 100, "stream": true})
+	if err != nil {
+		b.Fatalf("Unexpected error: %v", err)
+	}
+	return body
+}
+
+// BenchmarkRequestBodyDecode is the baseline of decoding the body into a map and re-encoding it.
+func BenchmarkRequestBodyDecode(b *testing.B) {
+	body := largeChatCompletionsBody(b)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for b.Loop() {
+		var m map[string]any
+		if err := json.Unmarshal(body, &m); err != nil {
+			b.Fatal(err)
+		}
+		m["model"] = "my-model-v2"
+		if _, err := json.Marshal(m); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRequestBodyScan(b *testing.B) {
+	body := largeChatCompletionsBody(b)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for b.Loop() {
+		parsed, err := parseRequestBody(body)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := parsed.withModel("my-model-v2"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	configPb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extProcPb "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	envoyTypePb "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	errutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/error"
+)
+
+// The policies routing the requests without a body other than the /v1/models ones, e.g., GET
+// requests to other endpoints of the model servers.
+const (
+	// BodylessRandomPolicy routes the requests to a random pod.
+	BodylessRandomPolicy = "random"
+	// BodylessLeastLoadedPolicy routes the requests to the pod with the shortest queue, and then the
+	// lowest KV-cache utilization.
+	BodylessLeastLoadedPolicy = "least-loaded"
+	// BodylessRejectPolicy rejects the requests.
+	BodylessRejectPolicy = "reject"
+)
+
+// BodylessPolicies are the valid policies routing the requests without a body.
+var BodylessPolicies = []string{BodylessRandomPolicy, BodylessLeastLoadedPolicy, BodylessRejectPolicy}
+
+// modelsPath is the path of the OpenAI compatible endpoint listing the models, answered by the EPP
+// with the models of the InferenceModels instead of the models of a single pod.
+const modelsPath = "/v1/models"
+
+// modelList is the body of the response to /v1/models requests.
+type modelList struct {
+	Object string      `json:"object"`
+	Data   []modelCard `json:"data"`
+}
+
+// modelCard describes a model accepted by the gateway, i.e., an InferenceModel, extending the
+// OpenAI model object with the criticality and target models of the InferenceModel.
+type modelCard struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	// OwnedBy is the InferencePool serving the model.
+	OwnedBy      string        `json:"owned_by"`
+	Criticality  string        `json:"criticality,omitempty"`
+	TargetModels []targetModel `json:"target_models,omitempty"`
+}
+
+type targetModel struct {
+	Name   string `json:"name"`
+	Weight *int32 `json:"weight,omitempty"`
+}
+
+// isModelsRequest returns true if the request with the given headers lists the models.
+func isModelsRequest(headers map[string]string) bool {
+	path, _, _ := strings.Cut(headers[":path"], "?")
+	return headers[":method"] == "GET" && strings.TrimSuffix(path, "/") == modelsPath
+}
+
+// modelsResponse returns the immediate response to a /v1/models request, listing the models of the
+// InferenceModels of the datastore sorted by name.
+func modelsResponse(ds datastore.Datastore) (*extProcPb.ProcessingResponse, error) {
+	pool, err := ds.PoolGet()
+	if err != nil {
+		return nil, errutil.Error{Code: errutil.Internal, Msg: fmt.Sprintf("error listing the models: %v", err)}
+	}
+	list := modelList{Object: "list", Data: []modelCard{}}
+	for _, model := range ds.ModelGetAll() {
+		card := modelCard{
+			ID:      model.Spec.ModelName,
+			Object:  "model",
+			Created: model.CreationTimestamp.Unix(),
+			OwnedBy: pool.Name,
+		}
+		if model.Spec.Criticality != nil {
+			card.Criticality = string(*model.Spec.Criticality)
+		}
+		for _, target := range model.Spec.TargetModels {
+			card.TargetModels = append(card.TargetModels, targetModel{Name: target.Name, Weight: target.Weight})
+		}
+		list.Data = append(list.Data, card)
+	}
+	slices.SortFunc(list.Data, func(a, b modelCard) int { return strings.Compare(a.ID, b.ID) })
+
+	body, err := json.Marshal(list)
+	if err != nil {
+		return nil, errutil.Error{Code: errutil.Internal, Msg: fmt.Sprintf("error encoding the models: %v", err)}
+	}
+	return &extProcPb.ProcessingResponse{
+		Response: &extProcPb.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extProcPb.ImmediateResponse{
+				Status: &envoyTypePb.HttpStatus{Code: envoyTypePb.StatusCode_OK},
+				Headers: &extProcPb.HeaderMutation{
+					SetHeaders: []*configPb.HeaderValueOption{
+						{Header: &configPb.HeaderValue{Key: "content-type", RawValue: []byte("application/json")}},
+					},
+				},
+				Body: body,
+			},
+		},
+	}, nil
+}
+
+// leastLoadedPod returns the pod with the shortest queue, and then the lowest KV-cache utilization,
+// nil if none.
+func leastLoadedPod(pods []backendmetrics.PodMetrics) *backendmetrics.Pod {
+	var least backendmetrics.PodMetrics
+	for _, pod := range pods {
+		if least == nil {
+			least = pod
+			continue
+		}
+		m, leastMetrics := pod.GetMetrics(), least.GetMetrics()
+		if m.WaitingQueueSize < leastMetrics.WaitingQueueSize ||
+			(m.WaitingQueueSize == leastMetrics.WaitingQueueSize && m.KVCacheUsagePercent < leastMetrics.KVCacheUsagePercent) {
+			least = pod
+		}
+	}
+	if least == nil {
+		return nil
+	}
+	return least.GetPod()
+}
@@ -18,14 +18,16 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
 	extProcPb "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/google/uuid"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
 	schedulingtypes "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
 	errutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/error"
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
@@ -38,16 +40,40 @@ func (s *StreamingServer) HandleRequestBody(
 	ctx context.Context,
 	reqCtx *RequestContext,
 	req *extProcPb.ProcessingRequest,
-	requestBodyMap map[string]interface{},
+	rawBody []byte,
 ) (*RequestContext, error) {
-	var requestBodyBytes []byte
 	logger := log.FromContext(ctx)
 
+	apiType := pathAPIType(reqCtx.RequestHeaders[":path"])
+	// In the BUFFERED_PARTIAL mode, Envoy only sends the prefix of a body exceeding its buffer, which
+	// is routed if it holds the model.
+	truncated := !req.GetRequestBody().GetEndOfStream()
+	var body *requestBody
+	var err error
+	switch {
+	case !truncated:
+		body, err = parseAPIRequestBody(apiType, rawBody, reqCtx.RequestHeaders["content-type"])
+	case apiType != schedulingtypes.TranscriptionsAPI:
+		body, err = parseRequestBodyPrefix(rawBody)
+	default:
+		return reqCtx, errutil.Error{Code: errutil.BadRequest, Msg: "request body exceeds the buffer of the gateway"}
+	}
+	if err != nil {
+		return reqCtx, errutil.Error{Code: errutil.BadRequest, Msg: fmt.Sprintf("invalid request body: %v", err)}
+	}
+	if apiType == "" {
+		apiType = body.apiType()
+	}
+	reqCtx.APIType = apiType
+
 	// Resolve target models.
-	model, ok := requestBodyMap["model"].(string)
-	if !ok {
+	if !body.hasModel && body.truncated {
+		return reqCtx, errutil.Error{Code: errutil.BadRequest, Msg: "model not found in the part of the request body within the buffer of the gateway"}
+	}
+	if !body.hasModel {
 		return reqCtx, errutil.Error{Code: errutil.BadRequest, Msg: "model not found in request"}
 	}
+	model := body.model
 
 	modelName := model
 
@@ -71,33 +97,49 @@ func (s *StreamingServer) HandleRequestBody(
 		ResolvedTargetModel: modelName,
 		Critical:            modelObj.Spec.Criticality != nil && *modelObj.Spec.Criticality == v1alpha2.Critical,
 		Prompt:              emptyPrompt,
+		SessionID:           reqCtx.RequestHeaders[schedulingtypes.SessionTokenHeader],
+		RequestID:           reqCtx.RequestID,
+		Sheddable:           modelObj.Spec.Criticality != nil && *modelObj.Spec.Criticality == v1alpha2.Sheddable,
+		GatewayZone:         s.gatewayZone,
+		User:                body.user,
+		MaxTokens:           body.maxOutputTokens(),
+		Stream:              body.stream,
+		APIType:             apiType,
+	}
+	if zone := reqCtx.RequestHeaders[schedulingtypes.GatewayZoneHeader]; zone != "" {
+		llmReq.GatewayZone = zone
+	}
+	if objectives := modelObj.Spec.Objectives; objectives != nil {
+		if objectives.TTFTMilliseconds != nil {
+			llmReq.TTFTObjective = time.Duration(*objectives.TTFTMilliseconds) * time.Millisecond
+		}
+		if objectives.TPOTMilliseconds != nil {
+			llmReq.TPOTObjective = time.Duration(*objectives.TPOTMilliseconds) * time.Millisecond
+		}
 	}
 	logger.V(logutil.DEBUG).Info("LLM request assembled", "request", llmReq)
 
-	var err error
-	// Update target models in the body.
-	if llmReq.Model != llmReq.ResolvedTargetModel {
-		requestBodyMap["model"] = llmReq.ResolvedTargetModel
-	}
-	// Extract prompt/messages from the request body.
-	if prompt, ok := requestBodyMap["prompt"].(string); ok {
-		llmReq.Prompt = prompt
-	} else if _, ok := requestBodyMap["messages"]; ok { // check for chat completion request
-		if chatRequest, err := schedulingtypes.NewKVCacheChatCompletionRequest(requestBodyMap); err == nil {
-			llmReq.ChatCompletionRequest = chatRequest
-		} else {
-			logger.Error(err, "Error creating chat completion request")
-		}
+	// Extract the prompt, messages or other inputs from the request body.
+	if err := body.fillInputs(llmReq); err != nil {
+		return reqCtx, errutil.Error{Code: errutil.BadRequest, Msg: fmt.Sprintf("invalid %s request inputs: %v", apiType, err)}
 	}
+	reqCtx.BatchSize = llmReq.BatchSize
 
-	requestBodyBytes, err = json.Marshal(requestBodyMap)
+	// Update target models in the body, which is otherwise passed through as is.
+	requestBodyBytes, err := body.withModel(llmReq.ResolvedTargetModel)
 	if err != nil {
-		logger.V(logutil.DEFAULT).Error(err, "Error marshaling request body")
-		return reqCtx, errutil.Error{Code: errutil.Internal, Msg: fmt.Sprintf("error marshaling request body: %v", err)}
+		logger.V(logutil.DEFAULT).Error(err, "Error patching request body")
+		return reqCtx, errutil.Error{Code: errutil.Internal, Msg: fmt.Sprintf("error patching request body: %v", err)}
 	}
 
 	res, err := s.scheduler.Schedule(ctx, llmReq)
+	reqCtx.ExperimentArm = llmReq.ExperimentArm
 	if err != nil {
+		// Errors of the scheduler, e.g., rejections of requests no pod can fit, are passed through.
+		var schedulingErr errutil.Error
+		if errors.As(err, &schedulingErr) {
+			return reqCtx, schedulingErr
+		}
 		return reqCtx, errutil.Error{Code: errutil.InferencePoolResourceExhausted, Msg: fmt.Errorf("failed to find target pod: %w", err).Error()}
 	}
 	targetPod := res.TargetPod.GetPod()
@@ -119,54 +161,92 @@ func (s *StreamingServer) HandleRequestBody(
 	reqCtx.TargetPod = targetPod.NamespacedName.String()
 	reqCtx.TargetEndpoint = endpoint
 
-	s.populateRequestHeaderResponse(reqCtx, endpoint, len(requestBodyBytes), res.MutatedHeaders)
-
-	reqCtx.reqBodyResp = &extProcPb.ProcessingResponse{
-		// The Endpoint Picker supports two approaches to communicating the target endpoint, as a request header
-		// and as an unstructure ext-proc response metadata key/value pair. This enables different integration
-		// options for gateway providers.
-		Response: &extProcPb.ProcessingResponse_RequestBody{
-			RequestBody: &extProcPb.BodyResponse{
-				Response: &extProcPb.CommonResponse{
-					BodyMutation: &extProcPb.BodyMutation{
-						Mutation: &extProcPb.BodyMutation_StreamedResponse{
-							StreamedResponse: &extProcPb.StreamedBodyResponse{
-								Body:        requestBodyBytes,
-								EndOfStream: true,
-							},
-						},
-					},
-				},
-			},
-		},
+	bodyLength := len(requestBodyBytes)
+	if truncated {
+		// The rest of the body follows the prefix, so its length is only known from the header, if
+		// set, and left to Envoy otherwise.
+		bodyLength = 0
+		if length, err := strconv.Atoi(reqCtx.RequestHeaders["content-length"]); err == nil {
+			bodyLength = length + len(requestBodyBytes) - len(rawBody)
+			reqCtx.RequestSize = bodyLength
+		}
 	}
+	s.populateRequestHeaderResponse(reqCtx, endpoint, bodyLength, res.MutatedHeaders)
+	reqCtx.reqBodyResp = reqCtx.requestBodyResponse(requestBodyBytes)
 	return reqCtx, nil
 }
 
 func (s *StreamingServer) HandleRequestHeaders(ctx context.Context, reqCtx *RequestContext, req *extProcPb.ProcessingRequest_RequestHeaders) error {
 	reqCtx.RequestReceivedTimestamp = time.Now()
 
+	for _, header := range req.RequestHeaders.Headers.Headers {
+		reqCtx.RequestHeaders[header.Key] = string(header.RawValue)
+	}
+	reqCtx.RequestID = reqCtx.RequestHeaders[schedulingtypes.RequestIDHeader]
+	if reqCtx.RequestID == "" {
+		reqCtx.RequestID = uuid.NewString()
+	}
+
 	// an EoS in the request headers means this request has no body or trailers.
 	if req.RequestHeaders.EndOfStream {
-		// We will route this request to a random pod as this is assumed to just be a GET
-		// More context: https://github.com/kubernetes-sigs/gateway-api-inference-extension/pull/526
-		// The above PR will address endpoint admission, but currently any request without a body will be
-		// routed to a random upstream pod.
-		pod := GetRandomPod(s.datastore)
-		if pod == nil {
-			return errutil.Error{Code: errutil.Internal, Msg: "no pods available in datastore"}
-		}
-		pool, err := s.datastore.PoolGet()
+		return s.handleBodylessRequest(reqCtx)
+	}
+	if !s.streaming {
+		reqCtx.setBuffered()
+	}
+	return nil
+}
+
+// setBuffered marks the request body as buffered, in which case Envoy waits for the response to the
+// request headers before sending the body, so the headers are answered right away.
+func (r *RequestContext) setBuffered() {
+	r.buffered = true
+	r.reqHeaderResp = &extProcPb.ProcessingResponse{
+		Response: &extProcPb.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extProcPb.HeadersResponse{},
+		},
+	}
+}
+
+// handleBodylessRequest answers the /v1/models requests with the models of the InferenceModels, and
+// routes the other requests without a body, e.g., GET requests, as per the bodyless policy. More
+// context: https://github.com/kubernetes-sigs/gateway-api-inference-extension/pull/526
+func (s *StreamingServer) handleBodylessRequest(reqCtx *RequestContext) error {
+	if isModelsRequest(reqCtx.RequestHeaders) {
+		resp, err := modelsResponse(s.datastore)
 		if err != nil {
 			return err
 		}
-		endpoint := pod.Address + ":" + strconv.Itoa(int(pool.Spec.TargetPortNumber))
-		s.populateRequestHeaderResponse(reqCtx, endpoint, 0, nil)
+		reqCtx.reqHeaderResp = resp
+		return nil
 	}
 
-	for _, header := range req.RequestHeaders.Headers.Headers {
-		reqCtx.RequestHeaders[header.Key] = string(header.RawValue)
+	var pod *backendmetrics.Pod
+	switch s.bodylessPolicy {
+	case BodylessRejectPolicy:
+		return errutil.Error{Code: errutil.BadRequest, Msg: "requests without a body are not supported"}
+	case BodylessLeastLoadedPolicy:
+		pod = leastLoadedPod(s.datastore.PodGetAll())
+	default:
+		pod = GetRandomPod(s.datastore)
 	}
-
+	if pod == nil {
+		return errutil.Error{Code: errutil.Internal, Msg: "no pods available in datastore"}
+	}
+	pool, err := s.datastore.PoolGet()
+	if err != nil {
+		return err
+	}
+	endpoint := pod.Address + ":" + strconv.Itoa(int(pool.Spec.TargetPortNumber))
+	s.populateRequestHeaderResponse(reqCtx, endpoint, 0, nil)
 	return nil
 }
+
+// handleSkippedRequestHeaders prepares the request context of a request whose headers are not sent
+// by Envoy, in which case the body is buffered and answered without a prior header response.
+func (s *StreamingServer) handleSkippedRequestHeaders(reqCtx *RequestContext) {
+	reqCtx.RequestReceivedTimestamp = time.Now()
+	reqCtx.RequestID = uuid.NewString()
+	reqCtx.buffered = true
+	reqCtx.RequestState = HeaderRequestResponseComplete
+}
@@ -19,9 +19,9 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
 
-	extProcPb "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
@@ -32,55 +32,37 @@ const (
 	streamingEndMsg     = "data: [DONE]"
 )
 
-// HandleResponseBody always returns the requestContext even in the error case, as the request context is used in error handling.
+// HandleResponseBody handles a chunk of a response body that is not streamed by the model server.
+// The chunk is passed through untouched, while the usage of the response is extracted. It always
+// returns the requestContext even in the error case, as the request context is used in error
+// handling.
 func (s *StreamingServer) HandleResponseBody(
 	ctx context.Context,
 	reqCtx *RequestContext,
-	response map[string]interface{},
+	chunk []byte,
+	endOfStream bool,
 ) (*RequestContext, error) {
-	logger := log.FromContext(ctx)
-	responseBytes, err := json.Marshal(response)
+	reqCtx.usageScanner.scan(chunk)
+	reqCtx.ResponseSize += len(chunk)
+	reqCtx.respBodyResp = reqCtx.responseBodyResponse(chunk, endOfStream)
+	if !endOfStream {
+		return reqCtx, nil
+	}
+
+	usage, ok, err := reqCtx.usageScanner.result()
 	if err != nil {
-		logger.V(logutil.DEFAULT).Error(err, "error marshalling responseBody")
-		return reqCtx, err
+		return reqCtx, fmt.Errorf("invalid usage in response body: %w", err)
 	}
-	if response["usage"] != nil {
-		usg := response["usage"].(map[string]interface{})
-		usage := Usage{
-			PromptTokens:     int(usg["prompt_tokens"].(float64)),
-			CompletionTokens: int(usg["completion_tokens"].(float64)),
-			TotalTokens:      int(usg["total_tokens"].(float64)),
-		}
+	if ok {
 		reqCtx.Usage = usage
-		logger.V(logutil.VERBOSE).Info("Response generated", "usage", reqCtx.Usage)
+		log.FromContext(ctx).V(logutil.VERBOSE).Info("Response generated", "usage", reqCtx.Usage)
 	}
-	reqCtx.ResponseSize = len(responseBytes)
 	// ResponseComplete is to indicate the response is complete. In non-streaming
 	// case, it will be set to be true once the response is processed; in
 	// streaming case, it will be set to be true once the last chunk is processed.
 	// TODO(https://github.com/kubernetes-sigs/gateway-api-inference-extension/issues/178)
 	// will add the processing for streaming case.
 	reqCtx.ResponseComplete = true
-
-	reqCtx.respBodyResp = &extProcPb.ProcessingResponse{
-		// The Endpoint Picker supports two approaches to communicating the target endpoint, as a request header
-		// and as an unstructure ext-proc response metadata key/value pair. This enables different integration
-		// options for gateway providers.
-		Response: &extProcPb.ProcessingResponse_ResponseBody{
-			ResponseBody: &extProcPb.BodyResponse{
-				Response: &extProcPb.CommonResponse{
-					BodyMutation: &extProcPb.BodyMutation{
-						Mutation: &extProcPb.BodyMutation_StreamedResponse{
-							StreamedResponse: &extProcPb.StreamedBodyResponse{
-								Body:        responseBytes,
-								EndOfStream: true,
-							},
-						},
-					},
-				},
-			},
-		},
-	}
 	return reqCtx, nil
 }
 
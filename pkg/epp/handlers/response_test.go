@@ -18,7 +18,6 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -62,11 +61,12 @@ func TestHandleResponseBody(t *testing.T) {
 	ctx := logutil.NewTestLoggerIntoContext(context.Background())
 
 	tests := []struct {
-		name    string
-		body    []byte
-		reqCtx  *RequestContext
-		want    Usage
-		wantErr bool
+		name string
+		body []byte
+		// chunkSize splits the body in chunks, the whole body if zero.
+		chunkSize int
+		want      Usage
+		wantErr   bool
 	}{
 		{
 			name: "success",
@@ -77,31 +77,69 @@ func TestHandleResponseBody(t *testing.T) {
 				CompletionTokens: 100,
 			},
 		},
+		{
+			name:      "usage split across chunks",
+			body:      []byte(body),
+			chunkSize: 7,
+			want: Usage{
+				PromptTokens:     11,
+				TotalTokens:      111,
+				CompletionTokens: 100,
+			},
+		},
+		{
+			name: "usage only in nested objects",
+			body: []byte(`{"choices": [{"text": "\"usage\": {", "usage": {"prompt_tokens": 1}}], "usage": null}`),
+		},
+		{
+			name: "duplicate usage keys",
+			body: []byte(`{"usage": {"prompt_tokens": 1, "total_tokens": 3}, "usage": null, "usage": {"prompt_tokens": 2}}`),
+			want: Usage{PromptTokens: 2, TotalTokens: 3},
+		},
+		{
+			name: "not JSON",
+			body: []byte("upstream connect error"),
+		},
+		{
+			name:    "invalid usage",
+			body:    []byte(`{"usage": {"prompt_tokens": "many"}}`),
+			wantErr: true,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			server := &StreamingServer{}
-			reqCtx := test.reqCtx
-			if reqCtx == nil {
-				reqCtx = &RequestContext{}
+			reqCtx := &RequestContext{}
+			chunkSize := test.chunkSize
+			if chunkSize == 0 {
+				chunkSize = len(test.body)
 			}
-			var responseMap map[string]interface{}
-			marshalErr := json.Unmarshal(test.body, &responseMap)
-			if marshalErr != nil {
-				t.Error(marshalErr, "Error unmarshaling request body")
+			var forwarded []byte
+			var err error
+			for start := 0; start < len(test.body); start += chunkSize {
+				end := min(start+chunkSize, len(test.body))
+				reqCtx, err = server.HandleResponseBody(ctx, reqCtx, test.body[start:end], end == len(test.body))
+				streamed := reqCtx.respBodyResp.GetResponseBody().GetResponse().GetBodyMutation().GetStreamedResponse()
+				forwarded = append(forwarded, streamed.GetBody()...)
 			}
-			_, err := server.HandleResponseBody(ctx, reqCtx, responseMap)
-			if err != nil {
-				if !test.wantErr {
-					t.Fatalf("HandleResponseBody returned unexpected error: %v, want %v", err, test.wantErr)
-				}
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("HandleResponseBody returned unexpected error: %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr {
 				return
 			}
 
 			if diff := cmp.Diff(test.want, reqCtx.Usage); diff != "" {
 				t.Errorf("HandleResponseBody returned unexpected response, diff(-want, +got): %v", diff)
 			}
+			if string(forwarded) != string(test.body) {
+				t.Errorf("Unexpected forwarded body, want %s, got %s", test.body, forwarded)
+			}
+			if !reqCtx.ResponseComplete || reqCtx.ResponseSize != len(test.body) {
+				t.Errorf("Unexpected response completion, want complete with size %d, got %v with size %d",
+					len(test.body), reqCtx.ResponseComplete, reqCtx.ResponseSize)
+			}
 		})
 	}
 }
@@ -18,7 +18,6 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
 	"io"
 	"math/rand"
 	"strconv"
@@ -26,6 +25,7 @@ import (
 	"time"
 
 	configPb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	filterPb "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	extProcPb "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	envoyTypePb "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/go-logr/logr"
@@ -43,12 +43,16 @@ import (
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
 )
 
-func NewStreamingServer(scheduler Scheduler, destinationEndpointHintMetadataNamespace, destinationEndpointHintKey string, datastore datastore.Datastore) *StreamingServer {
+func NewStreamingServer(scheduler Scheduler, destinationEndpointHintMetadataNamespace, destinationEndpointHintKey, gatewayZone string, datastore datastore.Datastore, streaming, skipResponseBody bool, bodylessPolicy string) *StreamingServer {
 	return &StreamingServer{
 		scheduler:                                scheduler,
 		destinationEndpointHintMetadataNamespace: destinationEndpointHintMetadataNamespace,
 		destinationEndpointHintKey:               destinationEndpointHintKey,
+		gatewayZone:                              gatewayZone,
 		datastore:                                datastore,
+		streaming:                                streaming,
+		skipResponseBody:                         skipResponseBody,
+		bodylessPolicy:                           bodylessPolicy,
 	}
 }
 
@@ -62,21 +66,45 @@ type StreamingServer struct {
 	// The key acting as the outer namespace struct in the metadata extproc response to communicate
 	// back the picked endpoints.
 	destinationEndpointHintMetadataNamespace string
-	datastore                                datastore.Datastore
+	// The topology zone of the gateway, used for requests without a zone header.
+	gatewayZone string
+	datastore   datastore.Datastore
+	// streaming is true if Envoy sends the request bodies in the FULL_DUPLEX_STREAMED mode, in which
+	// case the request headers are answered once the body is received, with the picked endpoint. If
+	// false, the request bodies are sent in the BUFFERED or BUFFERED_PARTIAL mode, for which Envoy
+	// waits for the response to the headers: the headers are then answered right away, and the
+	// picked endpoint is returned with the body.
+	streaming bool
+	// skipResponseBody is true if Envoy is asked not to send the response bodies no post-completion
+	// plugin or experiment needs, which requires its allow_mode_override option. The latencies,
+	// sizes and token counts of the skipped responses are not recorded.
+	skipResponseBody bool
+	// bodylessPolicy routes the requests without a body other than the /v1/models ones, e.g.,
+	// BodylessRandomPolicy.
+	bodylessPolicy string
 }
 
 type Scheduler interface {
 	Schedule(ctx context.Context, b *schedulingtypes.LLMRequest) (result *schedulingtypes.Result, err error)
 	RunPostResponsePlugins(ctx context.Context, req *types.LLMRequest, tragetPodName string) (*schedulingtypes.Result, error)
+	RunPostCompletionPlugins(ctx context.Context, req *types.LLMRequest, targetPodName string, latency *schedulingtypes.ResponseLatency) error
 }
 
 // RequestContext stores context information during the life time of an HTTP request.
 type RequestContext struct {
-	TargetPod                 string
-	TargetEndpoint            string
+	TargetPod      string
+	TargetEndpoint string
+	// ExperimentArm is the experiment arm the request was assigned to, empty if none.
+	ExperimentArm string
+	// APIType is the API of the request, e.g., chat completions, and BatchSize the number of inputs
+	// of batched requests, zero otherwise.
+	APIType                   string
+	BatchSize                 int
 	Model                     string
 	ResolvedTargetModel       string
+	RequestID                 string
 	RequestReceivedTimestamp  time.Time
+	FirstTokenTimestamp       time.Time // Time of the first streamed response chunk.
 	ResponseCompleteTimestamp time.Time
 	RequestSize               int
 	Usage                     Usage
@@ -87,6 +115,17 @@ type RequestContext struct {
 
 	RequestState         StreamRequestState
 	modelServerStreaming bool
+	// usageScanner extracts the usage of non-streamed response bodies.
+	usageScanner usageScanner
+	// buffered is true if Envoy sends the request body in the BUFFERED or BUFFERED_PARTIAL mode, in
+	// which case it is sent and answered in a single message. The response body is answered in the
+	// same mode, as both are either streamed in full duplex or not.
+	buffered bool
+	// responseChunks is the number of response body messages received, and responseEndOfStream is
+	// true once the last one is received. The response body mode is independent from the request
+	// one: e.g., a buffered request may get a streamed response.
+	responseChunks      int
+	responseEndOfStream bool
 
 	RequestHeaders map[string]string
 
@@ -126,7 +165,6 @@ func (s *StreamingServer) Process(srv extProcPb.ExternalProcessor_ProcessServer)
 	}
 
 	var body []byte
-	var requestBody, responseBody map[string]interface{}
 
 	// Create error handling var as each request should only report once for
 	// error metrics. This doesn't cover the error "Cannot receive stream request" because
@@ -151,7 +189,11 @@ func (s *StreamingServer) Process(srv extProcPb.ExternalProcessor_ProcessServer)
 		}
 
 		req, recvErr := srv.Recv()
-		if recvErr == io.EOF || status.Code(recvErr) == codes.Canceled {
+		if recvErr == io.EOF {
+			s.handleTruncatedResponse(ctx, reqCtx)
+			return nil
+		}
+		if status.Code(recvErr) == codes.Canceled {
 			return nil
 		}
 		if recvErr != nil {
@@ -166,16 +208,18 @@ func (s *StreamingServer) Process(srv extProcPb.ExternalProcessor_ProcessServer)
 			err = s.HandleRequestHeaders(ctx, reqCtx, v)
 		case *extProcPb.ProcessingRequest_RequestBody:
 			loggerTrace.Info("Incoming body chunk", "EoS", v.RequestBody.EndOfStream)
+			if reqCtx.RequestReceivedTimestamp.IsZero() {
+				// Envoy skipped the request headers, which only works with a buffered body.
+				s.handleSkippedRequestHeaders(reqCtx)
+			}
 			// In the stream case, we can receive multiple request bodies.
 			body = append(body, v.RequestBody.Body...)
 
-			// Message is buffered, we can read and decode.
-			if v.RequestBody.EndOfStream {
+			// Message is buffered, we can read and decode. In the buffered modes, Envoy sends a single
+			// body message, truncated in the BUFFERED_PARTIAL mode if the body exceeds its buffer.
+			if v.RequestBody.EndOfStream || reqCtx.buffered {
 				loggerTrace.Info("decoding")
-				err = json.Unmarshal(body, &requestBody)
-				if err != nil {
-					logger.V(logutil.DEFAULT).Error(err, "Error unmarshaling request body")
-				}
+				requestBody := body
 
 				// Body stream complete. Allocate empty slice for response to use.
 				body = []byte{}
@@ -185,6 +229,7 @@ func (s *StreamingServer) Process(srv extProcPb.ExternalProcessor_ProcessServer)
 					logger.V(logutil.DEFAULT).Error(err, "Error handling body")
 				} else {
 					metrics.RecordRequestCounter(reqCtx.Model, reqCtx.ResolvedTargetModel)
+					metrics.RecordAPIRequest(reqCtx.Model, reqCtx.APIType, reqCtx.BatchSize)
 					metrics.RecordRequestSizes(reqCtx.Model, reqCtx.ResolvedTargetModel, reqCtx.RequestSize)
 				}
 			}
@@ -209,6 +254,8 @@ func (s *StreamingServer) Process(srv extProcPb.ExternalProcessor_ProcessServer)
 				Model:               reqCtx.Model,
 				Headers:             responseHeaders,
 				ResolvedTargetModel: reqCtx.ResolvedTargetModel,
+				SessionID:           reqCtx.RequestHeaders[schedulingtypes.SessionTokenHeader],
+				ExperimentArm:       reqCtx.ExperimentArm,
 			}
 
 			var result *types.Result
@@ -248,64 +295,40 @@ func (s *StreamingServer) Process(srv extProcPb.ExternalProcessor_ProcessServer)
 							},
 						},
 					},
+					ModeOverride: s.responseModeOverride(reqCtx, result),
 				}
 			}
 
 		case *extProcPb.ProcessingRequest_ResponseBody:
+			if reqCtx.RequestState == BodyRequestResponsesComplete {
+				// Envoy skipped the response headers.
+				reqCtx.RequestState = HeaderResponseResponseComplete
+			}
+			reqCtx.responseChunks++
+			reqCtx.responseEndOfStream = v.ResponseBody.EndOfStream
 			if reqCtx.modelServerStreaming {
 				// Currently we punt on response parsing if the modelServer is streaming, and we just passthrough.
-
+				if reqCtx.FirstTokenTimestamp.IsZero() {
+					reqCtx.FirstTokenTimestamp = time.Now()
+				}
 				responseText := string(v.ResponseBody.Body)
 				s.HandleResponseBodyModelStreaming(ctx, reqCtx, responseText)
 				if v.ResponseBody.EndOfStream {
 					loggerTrace.Info("stream completed")
-
-					reqCtx.ResponseCompleteTimestamp = time.Now()
-					metrics.RecordRequestLatencies(ctx, reqCtx.Model, reqCtx.ResolvedTargetModel, reqCtx.RequestReceivedTimestamp, reqCtx.ResponseCompleteTimestamp)
-					metrics.RecordResponseSizes(reqCtx.Model, reqCtx.ResolvedTargetModel, reqCtx.ResponseSize)
+					s.completeResponse(ctx, reqCtx)
 				}
 
-				reqCtx.respBodyResp = &extProcPb.ProcessingResponse{
-					Response: &extProcPb.ProcessingResponse_ResponseBody{
-						ResponseBody: &extProcPb.BodyResponse{
-							Response: &extProcPb.CommonResponse{
-								BodyMutation: &extProcPb.BodyMutation{
-									Mutation: &extProcPb.BodyMutation_StreamedResponse{
-										StreamedResponse: &extProcPb.StreamedBodyResponse{
-											Body:        v.ResponseBody.Body,
-											EndOfStream: v.ResponseBody.EndOfStream,
-										},
-									},
-								},
-							},
-						},
-					},
-				}
+				reqCtx.respBodyResp = reqCtx.responseBodyResponse(v.ResponseBody.Body, v.ResponseBody.EndOfStream)
 			} else {
-				body = append(body, v.ResponseBody.Body...)
-
-				// Message is buffered, we can read and decode.
-				if v.ResponseBody.EndOfStream {
+				// Don't send a 500 on a response error. Just let the message passthrough and log our error for debugging purposes.
+				// Using the standard 'err' var would send an immediate error response back to the caller.
+				var responseErr error
+				reqCtx, responseErr = s.HandleResponseBody(ctx, reqCtx, v.ResponseBody.Body, v.ResponseBody.EndOfStream)
+				if responseErr != nil {
+					logger.V(logutil.DEFAULT).Error(responseErr, "Failed to process response body", "request", req)
+				} else if reqCtx.ResponseComplete {
 					loggerTrace.Info("stream completed")
-					// Don't send a 500 on a response error. Just let the message passthrough and log our error for debugging purposes.
-					// We assume the body is valid JSON, err messages are not guaranteed to be json, and so capturing and sending a 500 obfuscates the response message.
-					// using the standard 'err' var will send an immediate error response back to the caller.
-					var responseErr error
-					responseErr = json.Unmarshal(body, &responseBody)
-					if responseErr != nil {
-						logger.V(logutil.DEFAULT).Error(responseErr, "Error unmarshaling request body")
-					}
-
-					reqCtx, responseErr = s.HandleResponseBody(ctx, reqCtx, responseBody)
-					if responseErr != nil {
-						logger.V(logutil.DEFAULT).Error(responseErr, "Failed to process response body", "request", req)
-					} else if reqCtx.ResponseComplete {
-						reqCtx.ResponseCompleteTimestamp = time.Now()
-						metrics.RecordRequestLatencies(ctx, reqCtx.Model, reqCtx.ResolvedTargetModel, reqCtx.RequestReceivedTimestamp, reqCtx.ResponseCompleteTimestamp)
-						metrics.RecordResponseSizes(reqCtx.Model, reqCtx.ResolvedTargetModel, reqCtx.ResponseSize)
-						metrics.RecordInputTokens(reqCtx.Model, reqCtx.ResolvedTargetModel, reqCtx.Usage.PromptTokens)
-						metrics.RecordOutputTokens(reqCtx.Model, reqCtx.ResolvedTargetModel, reqCtx.Usage.CompletionTokens)
-					}
+					s.completeResponse(ctx, reqCtx)
 				}
 			}
 		case *extProcPb.ProcessingRequest_ResponseTrailers:
@@ -332,6 +355,81 @@ func (s *StreamingServer) Process(srv extProcPb.ExternalProcessor_ProcessServer)
 	}
 }
 
+// completeResponse records the metrics of a completed response and passes its latencies to the
+// scheduler post-completion plugins.
+func (s *StreamingServer) completeResponse(ctx context.Context, reqCtx *RequestContext) {
+	reqCtx.ResponseCompleteTimestamp = time.Now()
+	if reqCtx.responseChunks <= 1 {
+		// A body received in a single message, e.g., buffered, gives no time to first token.
+		reqCtx.FirstTokenTimestamp = time.Time{}
+	}
+	metrics.RecordRequestLatencies(ctx, reqCtx.Model, reqCtx.ResolvedTargetModel, reqCtx.RequestReceivedTimestamp, reqCtx.ResponseCompleteTimestamp)
+	metrics.RecordResponseSizes(reqCtx.Model, reqCtx.ResolvedTargetModel, reqCtx.ResponseSize)
+	if !reqCtx.modelServerStreaming {
+		metrics.RecordInputTokens(reqCtx.Model, reqCtx.ResolvedTargetModel, reqCtx.Usage.PromptTokens)
+		metrics.RecordOutputTokens(reqCtx.Model, reqCtx.ResolvedTargetModel, reqCtx.Usage.CompletionTokens)
+	}
+	s.reportResponseLatency(ctx, reqCtx)
+}
+
+// handleTruncatedResponse records the latency of a response whose body exceeded the buffer of Envoy
+// in the BUFFERED_PARTIAL mode, in which case Envoy sends its prefix only and the rest of the body
+// is sent to the client before the stream ends. The size and usage of such a response are unknown,
+// so it is not passed to the post-completion plugins.
+func (s *StreamingServer) handleTruncatedResponse(ctx context.Context, reqCtx *RequestContext) {
+	if reqCtx.responseChunks == 0 || reqCtx.responseEndOfStream || reqCtx.ResponseStatusCode != "" {
+		return
+	}
+	reqCtx.ResponseCompleteTimestamp = time.Now()
+	metrics.RecordRequestLatencies(ctx, reqCtx.Model, reqCtx.ResolvedTargetModel, reqCtx.RequestReceivedTimestamp, reqCtx.ResponseCompleteTimestamp)
+}
+
+// responseModeOverride returns the processing mode asking Envoy not to send the response body and
+// trailers if they are skipped, nil otherwise. The body of a response is not skipped if it is
+// needed by post-completion plugins or by the metrics of the experiment arm of the request.
+func (s *StreamingServer) responseModeOverride(reqCtx *RequestContext, result *types.Result) *filterPb.ProcessingMode {
+	if !s.skipResponseBody || result.NeedsCompletion || reqCtx.ExperimentArm != "" {
+		return nil
+	}
+	return &filterPb.ProcessingMode{
+		ResponseHeaderMode:  filterPb.ProcessingMode_SEND,
+		ResponseBodyMode:    filterPb.ProcessingMode_NONE,
+		ResponseTrailerMode: filterPb.ProcessingMode_SKIP,
+	}
+}
+
+// reportResponseLatency passes the latencies observed for a successfully completed response to the
+// scheduler post-completion plugins.
+func (s *StreamingServer) reportResponseLatency(ctx context.Context, reqCtx *RequestContext) {
+	if reqCtx.ResponseStatusCode != "" || reqCtx.TargetPod == "" {
+		return
+	}
+
+	latency := &schedulingtypes.ResponseLatency{
+		E2E:              reqCtx.ResponseCompleteTimestamp.Sub(reqCtx.RequestReceivedTimestamp),
+		PromptTokens:     reqCtx.Usage.PromptTokens,
+		CompletionTokens: reqCtx.Usage.CompletionTokens,
+	}
+	if !reqCtx.FirstTokenTimestamp.IsZero() {
+		latency.TTFT = reqCtx.FirstTokenTimestamp.Sub(reqCtx.RequestReceivedTimestamp)
+	}
+	metrics.RecordAPIResponse(reqCtx.Model, reqCtx.APIType, latency.E2E)
+	if reqCtx.ExperimentArm != "" {
+		metrics.RecordExperimentResponse(reqCtx.Model, reqCtx.ExperimentArm, latency.E2E, latency.TTFT, latency.TPOT(),
+			latency.PromptTokens, latency.CompletionTokens)
+	}
+
+	llmReq := &schedulingtypes.LLMRequest{
+		Model:               reqCtx.Model,
+		ResolvedTargetModel: reqCtx.ResolvedTargetModel,
+		RequestID:           reqCtx.RequestID,
+		ExperimentArm:       reqCtx.ExperimentArm,
+	}
+	if err := s.scheduler.RunPostCompletionPlugins(ctx, llmReq, reqCtx.TargetPod, latency); err != nil {
+		log.FromContext(ctx).V(logutil.DEFAULT).Error(err, "Error handling response completion")
+	}
+}
+
 // updateStateAndSendIfNeeded checks state and can send mutiple responses in a single pass, but only if ordered properly.
 // Order of requests matter in FULL_DUPLEX_STREAMING. For both request and response, the order of response sent back MUST be: Header->Body->Trailer, with trailer being optional.
 func (r *RequestContext) updateStateAndSendIfNeeded(srv extProcPb.ExternalProcessor_ProcessServer, logger logr.Logger) error {
@@ -375,8 +473,7 @@ func (r *RequestContext) updateStateAndSendIfNeeded(srv extProcPb.ExternalProces
 			return status.Errorf(codes.Unknown, "failed to send response back to Envoy: %v", err)
 		}
 
-		body := r.respBodyResp.Response.(*extProcPb.ProcessingResponse_ResponseBody)
-		if body.ResponseBody.Response.GetBodyMutation().GetStreamedResponse().GetEndOfStream() {
+		if r.responseEndOfStream {
 			r.RequestState = BodyResponseResponsesComplete
 		}
 		// Dump the response so a new stream message can begin
@@ -391,6 +488,73 @@ func (r *RequestContext) updateStateAndSendIfNeeded(srv extProcPb.ExternalProces
 	return nil
 }
 
+// requestBodyResponse returns the response to the request body, replaced by the given body.
+func (r *RequestContext) requestBodyResponse(body []byte) *extProcPb.ProcessingResponse {
+	if r.buffered {
+		// The request headers were already answered, so the picked endpoint is returned along with the
+		// body, by the headers and metadata of the request header response populated for it.
+		common := r.reqHeaderResp.GetRequestHeaders().GetResponse()
+		common.BodyMutation = &extProcPb.BodyMutation{
+			Mutation: &extProcPb.BodyMutation_Body{Body: body},
+		}
+		return &extProcPb.ProcessingResponse{
+			Response: &extProcPb.ProcessingResponse_RequestBody{
+				RequestBody: &extProcPb.BodyResponse{Response: common},
+			},
+			DynamicMetadata: r.reqHeaderResp.DynamicMetadata,
+		}
+	}
+	return &extProcPb.ProcessingResponse{
+		// The Endpoint Picker supports two approaches to communicating the target endpoint, as a request header
+		// and as an unstructure ext-proc response metadata key/value pair. This enables different integration
+		// options for gateway providers.
+		Response: &extProcPb.ProcessingResponse_RequestBody{
+			RequestBody: &extProcPb.BodyResponse{
+				Response: &extProcPb.CommonResponse{
+					BodyMutation: &extProcPb.BodyMutation{
+						Mutation: &extProcPb.BodyMutation_StreamedResponse{
+							StreamedResponse: &extProcPb.StreamedBodyResponse{
+								Body:        body,
+								EndOfStream: true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// responseBodyResponse returns the response to a chunk of the response body, streaming back the
+// given body in full duplex. Otherwise the body is left unchanged.
+func (r *RequestContext) responseBodyResponse(body []byte, endOfStream bool) *extProcPb.ProcessingResponse {
+	if r.buffered {
+		// The response body is passed through unchanged, which spares sending it back, and is valid
+		// whether it is buffered or streamed.
+		return &extProcPb.ProcessingResponse{
+			Response: &extProcPb.ProcessingResponse_ResponseBody{
+				ResponseBody: &extProcPb.BodyResponse{},
+			},
+		}
+	}
+	return &extProcPb.ProcessingResponse{
+		Response: &extProcPb.ProcessingResponse_ResponseBody{
+			ResponseBody: &extProcPb.BodyResponse{
+				Response: &extProcPb.CommonResponse{
+					BodyMutation: &extProcPb.BodyMutation{
+						Mutation: &extProcPb.BodyMutation_StreamedResponse{
+							StreamedResponse: &extProcPb.StreamedBodyResponse{
+								Body:        body,
+								EndOfStream: endOfStream,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func (s *StreamingServer) populateRequestHeaderResponse(reqCtx *RequestContext, endpoint string, requestBodyLength int, mutatedHeaders map[string]string) {
 	headers := []*configPb.HeaderValueOption{
 		{
@@ -537,6 +701,19 @@ func BuildErrResponse(err error) (*extProcPb.ProcessingResponse, error) {
 				},
 			},
 		}
+	// This code can be returned by the scheduler when no pod can fit the request. The body tells the
+	// user why.
+	case errutil.ContextLengthExceeded:
+		resp = &extProcPb.ProcessingResponse{
+			Response: &extProcPb.ProcessingResponse_ImmediateResponse{
+				ImmediateResponse: &extProcPb.ImmediateResponse{
+					Status: &envoyTypePb.HttpStatus{
+						Code: envoyTypePb.StatusCode_BadRequest,
+					},
+					Body: []byte(err.Error()),
+				},
+			},
+		}
 	case errutil.BadConfiguration:
 		resp = &extProcPb.ProcessingResponse{
 			Response: &extProcPb.ProcessingResponse_ImmediateResponse{
@@ -17,16 +17,34 @@ limitations under the License.
 package handlers
 
 import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
+	configPb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	filterPb "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extProcPb "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	envoyTypePb "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/structpb"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	schedulingtypes "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	errutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/error"
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+	testutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/testing"
 )
 
 func TestRandomWeightedDraw(t *testing.T) {
@@ -184,3 +202,617 @@ func TestGetRandomPod(t *testing.T) {
 func pointer(v int32) *int32 {
 	return &v
 }
+
+// fakeProcessServer replays the given requests and records the responses. A request with an entry
+// in delays is sent after the given delay, as for a slow client.
+type fakeProcessServer struct {
+	grpc.ServerStream
+	ctx       context.Context
+	requests  []*extProcPb.ProcessingRequest
+	delays    map[int]time.Duration
+	received  int
+	mu        sync.Mutex
+	responses []*extProcPb.ProcessingResponse
+}
+
+func (s *fakeProcessServer) Context() context.Context { return s.ctx }
+
+func (s *fakeProcessServer) Recv() (*extProcPb.ProcessingRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	time.Sleep(s.delays[s.received])
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	s.received++
+	return req, nil
+}
+
+func (s *fakeProcessServer) Send(resp *extProcPb.ProcessingResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses = append(s.responses, resp)
+	return nil
+}
+
+func (s *fakeProcessServer) sent() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.responses)
+}
+
+// fakeScheduler always picks the same pod, or fails with the given error if any, and records the
+// latencies of the completed responses.
+type fakeScheduler struct {
+	pod       *metrics.Pod
+	err       error
+	requests  []*schedulingtypes.LLMRequest
+	latencies []*schedulingtypes.ResponseLatency
+	// needsCompletion is true if the scheduler has post-completion plugins.
+	needsCompletion bool
+}
+
+func (s *fakeScheduler) Schedule(_ context.Context, req *schedulingtypes.LLMRequest) (*schedulingtypes.Result, error) {
+	s.requests = append(s.requests, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &schedulingtypes.Result{TargetPod: &schedulingtypes.PodMetrics{Pod: s.pod}}, nil
+}
+
+func (s *fakeScheduler) RunPostResponsePlugins(_ context.Context, _ *schedulingtypes.LLMRequest, _ string) (*schedulingtypes.Result, error) {
+	return &schedulingtypes.Result{NeedsCompletion: s.needsCompletion}, nil
+}
+
+func (s *fakeScheduler) RunPostCompletionPlugins(_ context.Context, _ *schedulingtypes.LLMRequest, _ string, latency *schedulingtypes.ResponseLatency) error {
+	s.latencies = append(s.latencies, latency)
+	return nil
+}
+
+func TestProcessBodyModes(t *testing.T) {
+	ctx := logutil.NewTestLoggerIntoContext(context.Background())
+	pmf := metrics.NewPodMetricsFactory(&metrics.FakePodMetricsClient{}, time.Second)
+	ds := datastore.NewDatastore(ctx, pmf)
+	pool := &v1alpha2.InferencePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "default"},
+		Spec:       v1alpha2.InferencePoolSpec{TargetPortNumber: 8000},
+	}
+	if err := ds.PoolSet(ctx, fake.NewClientBuilder().Build(), pool); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ds.ModelSetIfOlder(testutil.MakeInferenceModel("my-model").Namespace("default").ModelName("my-model").Objectives(500, 50).ObjRef())
+	pod := &metrics.Pod{
+		NamespacedName: k8stypes.NamespacedName{Name: "pod1", Namespace: "default"},
+		Address:        "1.2.3.4",
+	}
+
+	requestBody := []byte(`{"model":"my-model","prompt":"hi"}`)
+	responseBody := []byte(`{"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}`)
+	requestHeaders := &extProcPb.ProcessingRequest{
+		Request: &extProcPb.ProcessingRequest_RequestHeaders{
+			RequestHeaders: &extProcPb.HttpHeaders{Headers: &configPb.HeaderMap{}},
+		},
+	}
+	// The request body is truncated to its prefix in the BUFFERED_PARTIAL mode.
+	truncatedBody := []byte(`{"model":"my-model","prompt":"hel`)
+	truncatedHeaders := &extProcPb.ProcessingRequest{
+		Request: &extProcPb.ProcessingRequest_RequestHeaders{
+			RequestHeaders: &extProcPb.HttpHeaders{Headers: &configPb.HeaderMap{
+				Headers: []*configPb.HeaderValue{{Key: "content-length", RawValue: []byte("4096")}},
+			}},
+		},
+	}
+	bufferedBody := func(body []byte, endOfStream bool) *extProcPb.ProcessingRequest {
+		return &extProcPb.ProcessingRequest{
+			Request: &extProcPb.ProcessingRequest_RequestBody{
+				RequestBody: &extProcPb.HttpBody{Body: body, EndOfStream: endOfStream},
+			},
+		}
+	}
+	responseBodyChunk := func(body []byte, endOfStream bool) *extProcPb.ProcessingRequest {
+		return &extProcPb.ProcessingRequest{
+			Request: &extProcPb.ProcessingRequest_ResponseBody{
+				ResponseBody: &extProcPb.HttpBody{Body: body, EndOfStream: endOfStream},
+			},
+		}
+	}
+	responseRequests := []*extProcPb.ProcessingRequest{
+		{
+			Request: &extProcPb.ProcessingRequest_ResponseHeaders{
+				ResponseHeaders: &extProcPb.HttpHeaders{Headers: &configPb.HeaderMap{}},
+			},
+		},
+		responseBodyChunk(responseBody, true),
+	}
+	streamedResponseRequests := []*extProcPb.ProcessingRequest{
+		{
+			Request: &extProcPb.ProcessingRequest_ResponseHeaders{
+				ResponseHeaders: &extProcPb.HttpHeaders{Headers: &configPb.HeaderMap{
+					Headers: []*configPb.HeaderValue{{Key: "content-type", RawValue: []byte("text/event-stream")}},
+				}},
+			},
+		},
+		responseBodyChunk([]byte("data: {\"choices\":[{\"text\":\"hello\"}]}\n\n"), false),
+		responseBodyChunk([]byte("data: {\"choices\":[],\"usage\":{\"prompt_tokens\":1,\"completion_tokens\":2}}\n\ndata: [DONE]\n\n"), true),
+	}
+
+	headersResponse := &extProcPb.ProcessingResponse{
+		Response: &extProcPb.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extProcPb.HeadersResponse{},
+		},
+	}
+	bodyResponseWithLength := func(body []byte, length int) *extProcPb.ProcessingResponse {
+		return &extProcPb.ProcessingResponse{
+			Response: &extProcPb.ProcessingResponse_RequestBody{
+				RequestBody: &extProcPb.BodyResponse{
+					Response: &extProcPb.CommonResponse{
+						ClearRouteCache: true,
+						HeaderMutation: &extProcPb.HeaderMutation{
+							SetHeaders: []*configPb.HeaderValueOption{
+								{Header: &configPb.HeaderValue{Key: "x-gateway-destination-endpoint", RawValue: []byte("1.2.3.4:8000")}},
+								{Header: &configPb.HeaderValue{Key: "Content-Length", RawValue: []byte(strconv.Itoa(length))}},
+							},
+						},
+						BodyMutation: &extProcPb.BodyMutation{
+							Mutation: &extProcPb.BodyMutation_Body{Body: body},
+						},
+					},
+				},
+			},
+			DynamicMetadata: &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"envoy.lb": structpb.NewStructValue(&structpb.Struct{
+						Fields: map[string]*structpb.Value{
+							"x-gateway-destination-endpoint": structpb.NewStringValue("1.2.3.4:8000"),
+						},
+					}),
+				},
+			},
+		}
+	}
+	bodyResponse := bodyResponseWithLength(requestBody, len(requestBody))
+	responseHeadersResponse := &extProcPb.ProcessingResponse{
+		Response: &extProcPb.ProcessingResponse_ResponseHeaders{
+			ResponseHeaders: &extProcPb.HeadersResponse{
+				Response: &extProcPb.CommonResponse{
+					HeaderMutation: &extProcPb.HeaderMutation{
+						SetHeaders: []*configPb.HeaderValueOption{
+							{Header: &configPb.HeaderValue{Key: "x-went-into-resp-headers", RawValue: []byte("true")}},
+						},
+					},
+				},
+			},
+		},
+	}
+	responseBodyResponse := &extProcPb.ProcessingResponse{
+		Response: &extProcPb.ProcessingResponse_ResponseBody{
+			ResponseBody: &extProcPb.BodyResponse{},
+		},
+	}
+	// In full duplex, the picked endpoint is returned with the request headers, and the bodies are
+	// streamed back.
+	streamedHeadersResponse := &extProcPb.ProcessingResponse{
+		Response: &extProcPb.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extProcPb.HeadersResponse{
+				Response: &extProcPb.CommonResponse{
+					ClearRouteCache: true,
+					HeaderMutation:  bodyResponse.GetRequestBody().GetResponse().GetHeaderMutation(),
+				},
+			},
+		},
+		DynamicMetadata: bodyResponse.DynamicMetadata,
+	}
+	streamedMutation := func(body []byte, endOfStream bool) *extProcPb.CommonResponse {
+		return &extProcPb.CommonResponse{
+			BodyMutation: &extProcPb.BodyMutation{
+				Mutation: &extProcPb.BodyMutation_StreamedResponse{
+					StreamedResponse: &extProcPb.StreamedBodyResponse{Body: body, EndOfStream: endOfStream},
+				},
+			},
+		}
+	}
+	streamedBodyResponse := func(body []byte, endOfStream bool) *extProcPb.ProcessingResponse {
+		return &extProcPb.ProcessingResponse{
+			Response: &extProcPb.ProcessingResponse_RequestBody{
+				RequestBody: &extProcPb.BodyResponse{Response: streamedMutation(body, endOfStream)},
+			},
+		}
+	}
+	streamedResponseBodyResponse := func(body []byte, endOfStream bool) *extProcPb.ProcessingResponse {
+		return &extProcPb.ProcessingResponse{
+			Response: &extProcPb.ProcessingResponse_ResponseBody{
+				ResponseBody: &extProcPb.BodyResponse{Response: streamedMutation(body, endOfStream)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		streaming        bool
+		skipResponseBody bool
+		needsCompletion  bool
+		requests         []*extProcPb.ProcessingRequest
+		delays           map[int]time.Duration
+		want             []*extProcPb.ProcessingResponse
+		// wantCompletions is the number of completed responses, and wantTTFT is true if their time to
+		// first token is known.
+		wantCompletions int
+		wantTTFT        bool
+	}{
+		{
+			name:            "buffered",
+			requests:        append([]*extProcPb.ProcessingRequest{requestHeaders, bufferedBody(requestBody, true)}, responseRequests...),
+			want:            []*extProcPb.ProcessingResponse{headersResponse, bodyResponse, responseHeadersResponse, responseBodyResponse},
+			wantCompletions: 1,
+		},
+		{
+			name:      "streamed body arriving late in chunks",
+			streaming: true,
+			requests: append([]*extProcPb.ProcessingRequest{
+				requestHeaders, bufferedBody(requestBody[:30], false), bufferedBody(requestBody[30:], true),
+			}, responseRequests...),
+			delays: map[int]time.Duration{1: 100 * time.Millisecond, 2: 100 * time.Millisecond},
+			want: []*extProcPb.ProcessingResponse{
+				streamedHeadersResponse, streamedBodyResponse(requestBody, true),
+				responseHeadersResponse, streamedResponseBodyResponse(responseBody, true),
+			},
+			wantCompletions: 1,
+		},
+		{
+			name:            "buffered with request headers skipped",
+			streaming:       true,
+			requests:        append([]*extProcPb.ProcessingRequest{bufferedBody(requestBody, true)}, responseRequests...),
+			want:            []*extProcPb.ProcessingResponse{bodyResponse, responseHeadersResponse, responseBodyResponse},
+			wantCompletions: 1,
+		},
+		{
+			name:     "buffered with streamed response",
+			requests: append([]*extProcPb.ProcessingRequest{requestHeaders, bufferedBody(requestBody, true)}, streamedResponseRequests...),
+			want: []*extProcPb.ProcessingResponse{
+				headersResponse, bodyResponse, responseHeadersResponse, responseBodyResponse, responseBodyResponse,
+			},
+			wantCompletions: 1,
+			wantTTFT:        true,
+		},
+		{
+			name:     "buffered with buffered streamed response",
+			requests: []*extProcPb.ProcessingRequest{requestHeaders, bufferedBody(requestBody, true), streamedResponseRequests[0], streamedResponseRequests[2]},
+			want: []*extProcPb.ProcessingResponse{
+				headersResponse, bodyResponse, responseHeadersResponse, responseBodyResponse,
+			},
+			wantCompletions: 1,
+		},
+		{
+			name:     "buffered partial response exceeding the buffer",
+			requests: []*extProcPb.ProcessingRequest{requestHeaders, bufferedBody(requestBody, true), responseRequests[0], responseBodyChunk(responseBody[:10], false)},
+			want:     []*extProcPb.ProcessingResponse{headersResponse, bodyResponse, responseHeadersResponse, responseBodyResponse},
+		},
+		{
+			name:             "buffered with response body skipped",
+			skipResponseBody: true,
+			requests:         []*extProcPb.ProcessingRequest{requestHeaders, bufferedBody(requestBody, true), responseRequests[0]},
+			want: []*extProcPb.ProcessingResponse{headersResponse, bodyResponse, {
+				Response: responseHeadersResponse.Response,
+				ModeOverride: &filterPb.ProcessingMode{
+					ResponseHeaderMode:  filterPb.ProcessingMode_SEND,
+					ResponseBodyMode:    filterPb.ProcessingMode_NONE,
+					ResponseTrailerMode: filterPb.ProcessingMode_SKIP,
+				},
+			}},
+		},
+		{
+			name:             "buffered with response body needed by post-completion plugins",
+			skipResponseBody: true,
+			needsCompletion:  true,
+			requests:         append([]*extProcPb.ProcessingRequest{requestHeaders, bufferedBody(requestBody, true)}, responseRequests...),
+			want:             []*extProcPb.ProcessingResponse{headersResponse, bodyResponse, responseHeadersResponse, responseBodyResponse},
+			wantCompletions:  1,
+		},
+		{
+			name:     "buffered partial body exceeding the buffer",
+			requests: append([]*extProcPb.ProcessingRequest{truncatedHeaders, bufferedBody(truncatedBody, false)}, responseRequests...),
+			want: []*extProcPb.ProcessingResponse{
+				headersResponse, bodyResponseWithLength(truncatedBody, 4096), responseHeadersResponse, responseBodyResponse,
+			},
+			wantCompletions: 1,
+		},
+		{
+			name:     "invalid inputs",
+			requests: []*extProcPb.ProcessingRequest{requestHeaders, bufferedBody([]byte(`{"model":"my-model","prompt":[{"text":"hi"}]}`), true)},
+			want: []*extProcPb.ProcessingResponse{
+				headersResponse,
+				{
+					Response: &extProcPb.ProcessingResponse_ImmediateResponse{
+						ImmediateResponse: &extProcPb.ImmediateResponse{
+							Status: &envoyTypePb.HttpStatus{Code: envoyTypePb.StatusCode_BadRequest},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:     "buffered partial body exceeding the buffer before the model",
+			requests: []*extProcPb.ProcessingRequest{requestHeaders, bufferedBody([]byte(`{"prompt":"hello","model":"my-mo`), false)},
+			want: []*extProcPb.ProcessingResponse{
+				headersResponse,
+				{
+					Response: &extProcPb.ProcessingResponse_ImmediateResponse{
+						ImmediateResponse: &extProcPb.ImmediateResponse{
+							Status: &envoyTypePb.HttpStatus{Code: envoyTypePb.StatusCode_BadRequest},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler := &fakeScheduler{pod: pod, needsCompletion: test.needsCompletion}
+			server := NewStreamingServer(scheduler, "envoy.lb", "x-gateway-destination-endpoint", "", ds, test.streaming, test.skipResponseBody, BodylessRandomPolicy)
+			srv := &fakeProcessServer{ctx: ctx, requests: test.requests, delays: test.delays}
+			if err := server.Process(srv); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.want, srv.responses, protocmp.Transform()); diff != "" {
+				t.Errorf("Unexpected responses (-want +got): %v", diff)
+			}
+			if len(scheduler.latencies) != test.wantCompletions {
+				t.Fatalf("Got %d completed responses, want %d", len(scheduler.latencies), test.wantCompletions)
+			}
+			for _, latency := range scheduler.latencies {
+				if gotTTFT := latency.TTFT > 0; gotTTFT != test.wantTTFT {
+					t.Errorf("Got a time to first token of %v, want one: %v", latency.TTFT, test.wantTTFT)
+				}
+			}
+			// The latency objectives of the model are passed to the scheduler.
+			for _, req := range scheduler.requests {
+				if req.TTFTObjective != 500*time.Millisecond || req.TPOTObjective != 50*time.Millisecond {
+					t.Errorf("Got latency objectives %v and %v, want 500ms and 50ms", req.TTFTObjective, req.TPOTObjective)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessScheduleError(t *testing.T) {
+	ctx := logutil.NewTestLoggerIntoContext(context.Background())
+	pmf := metrics.NewPodMetricsFactory(&metrics.FakePodMetricsClient{}, time.Second)
+	ds := datastore.NewDatastore(ctx, pmf)
+	pool := &v1alpha2.InferencePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "default"},
+		Spec:       v1alpha2.InferencePoolSpec{TargetPortNumber: 8000},
+	}
+	if err := ds.PoolSet(ctx, fake.NewClientBuilder().Build(), pool); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ds.ModelSetIfOlder(&v1alpha2.InferenceModel{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-model", Namespace: "default"},
+		Spec:       v1alpha2.InferenceModelSpec{ModelName: "my-model"},
+	})
+	requests := []*extProcPb.ProcessingRequest{
+		{
+			Request: &extProcPb.ProcessingRequest_RequestHeaders{
+				RequestHeaders: &extProcPb.HttpHeaders{Headers: &configPb.HeaderMap{}},
+			},
+		},
+		{
+			Request: &extProcPb.ProcessingRequest_RequestBody{
+				RequestBody: &extProcPb.HttpBody{Body: []byte(`{"model":"my-model","prompt":"hi"}`), EndOfStream: true},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want *extProcPb.ImmediateResponse
+	}{
+		{
+			name: "context length exceeded",
+			err:  errutil.Error{Code: errutil.ContextLengthExceeded, Msg: "request exceeds the maximum context length"},
+			want: &extProcPb.ImmediateResponse{
+				Status: &envoyTypePb.HttpStatus{Code: envoyTypePb.StatusCode_BadRequest},
+				Body:   []byte("inference gateway: ContextLengthExceeded - request exceeds the maximum context length"),
+			},
+		},
+		{
+			name: "internal error",
+			err:  errutil.Error{Code: errutil.Internal, Msg: "failed to find a target pod"},
+			want: &extProcPb.ImmediateResponse{
+				Status: &envoyTypePb.HttpStatus{Code: envoyTypePb.StatusCode_InternalServerError},
+			},
+		},
+		{
+			name: "other error",
+			err:  errors.New("no pod"),
+			want: &extProcPb.ImmediateResponse{
+				Status: &envoyTypePb.HttpStatus{Code: envoyTypePb.StatusCode_TooManyRequests},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := NewStreamingServer(&fakeScheduler{err: test.err}, "envoy.lb", "x-gateway-destination-endpoint", "", ds, false, false, BodylessRandomPolicy)
+			srv := &fakeProcessServer{ctx: ctx, requests: requests}
+			if err := server.Process(srv); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			want := []*extProcPb.ProcessingResponse{
+				{
+					Response: &extProcPb.ProcessingResponse_RequestHeaders{
+						RequestHeaders: &extProcPb.HeadersResponse{},
+					},
+				},
+				{
+					Response: &extProcPb.ProcessingResponse_ImmediateResponse{ImmediateResponse: test.want},
+				},
+			}
+			if diff := cmp.Diff(want, srv.responses, protocmp.Transform()); diff != "" {
+				t.Errorf("Unexpected responses (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestProcessBodyless(t *testing.T) {
+	ctx := logutil.NewTestLoggerIntoContext(context.Background())
+	pmf := metrics.NewPodMetricsFactory(&metrics.FakePodMetricsClient{}, time.Second)
+	ds := datastore.NewDatastore(ctx, pmf)
+	pool := &v1alpha2.InferencePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "default"},
+		Spec:       v1alpha2.InferencePoolSpec{TargetPortNumber: 8000},
+	}
+	if err := ds.PoolSet(ctx, fake.NewClientBuilder().Build(), pool); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	critical := v1alpha2.Critical
+	ds.ModelSetIfOlder(&v1alpha2.InferenceModel{
+		ObjectMeta: metav1.ObjectMeta{Name: "model-b", Namespace: "default", CreationTimestamp: metav1.Unix(1700000000, 0)},
+		Spec: v1alpha2.InferenceModelSpec{ModelName: "model-b", Criticality: &critical, TargetModels: []v1alpha2.TargetModel{
+			{Name: "model-b-v1", Weight: pointer(90)},
+			{Name: "model-b-v2", Weight: pointer(10)},
+		}},
+	})
+	ds.ModelSetIfOlder(&v1alpha2.InferenceModel{
+		ObjectMeta: metav1.ObjectMeta{Name: "model-a", Namespace: "default", CreationTimestamp: metav1.Unix(1700000000, 0)},
+		Spec:       v1alpha2.InferenceModelSpec{ModelName: "model-a"},
+	})
+	ds.PodUpdateOrAddIfNotExist(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default"},
+		Status:     corev1.PodStatus{PodIP: "1.2.3.4"},
+	})
+
+	bodylessRequest := func(path string) []*extProcPb.ProcessingRequest {
+		return []*extProcPb.ProcessingRequest{{
+			Request: &extProcPb.ProcessingRequest_RequestHeaders{
+				RequestHeaders: &extProcPb.HttpHeaders{
+					Headers: &configPb.HeaderMap{Headers: []*configPb.HeaderValue{
+						{Key: ":method", RawValue: []byte("GET")},
+						{Key: ":path", RawValue: []byte(path)},
+					}},
+					EndOfStream: true,
+				},
+			},
+		}}
+	}
+	modelsResponse := &extProcPb.ProcessingResponse{
+		Response: &extProcPb.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extProcPb.ImmediateResponse{
+				Status: &envoyTypePb.HttpStatus{Code: envoyTypePb.StatusCode_OK},
+				Headers: &extProcPb.HeaderMutation{
+					SetHeaders: []*configPb.HeaderValueOption{
+						{Header: &configPb.HeaderValue{Key: "content-type", RawValue: []byte("application/json")}},
+					},
+				},
+				Body: []byte(`{"object":"list","data":[` +
+					`{"id":"model-a","object":"model","created":1700000000,"owned_by":"pool"},` +
+					`{"id":"model-b","object":"model","created":1700000000,"owned_by":"pool","criticality":"Critical",` +
+					`"target_models":[{"name":"model-b-v1","weight":90},{"name":"model-b-v2","weight":10}]}]}`),
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		policy   string
+		requests []*extProcPb.ProcessingRequest
+		want     *extProcPb.ProcessingResponse
+		// wantEndpoint is the endpoint the request is routed to, if not answered by the EPP.
+		wantEndpoint string
+	}{
+		{
+			name:     "models",
+			policy:   BodylessRandomPolicy,
+			requests: bodylessRequest("/v1/models"),
+			want:     modelsResponse,
+		},
+		{
+			name:     "models with query",
+			policy:   BodylessRejectPolicy,
+			requests: bodylessRequest("/v1/models/?limit=10"),
+			want:     modelsResponse,
+		},
+		{
+			name:         "random",
+			policy:       BodylessRandomPolicy,
+			requests:     bodylessRequest("/metrics"),
+			wantEndpoint: "1.2.3.4:8000",
+		},
+		{
+			name:         "least loaded",
+			policy:       BodylessLeastLoadedPolicy,
+			requests:     bodylessRequest("/v1/models/model-a"),
+			wantEndpoint: "1.2.3.4:8000",
+		},
+		{
+			name:     "reject",
+			policy:   BodylessRejectPolicy,
+			requests: bodylessRequest("/metrics"),
+			want: &extProcPb.ProcessingResponse{
+				Response: &extProcPb.ProcessingResponse_ImmediateResponse{
+					ImmediateResponse: &extProcPb.ImmediateResponse{
+						Status: &envoyTypePb.HttpStatus{Code: envoyTypePb.StatusCode_BadRequest},
+					},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := NewStreamingServer(&fakeScheduler{}, "envoy.lb", "x-gateway-destination-endpoint", "", ds, true, false, test.policy)
+			srv := &fakeProcessServer{ctx: ctx, requests: test.requests}
+			if err := server.Process(srv); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(srv.responses) != 1 {
+				t.Fatalf("Unexpected responses, want 1, got %v", srv.responses)
+			}
+			if test.wantEndpoint != "" {
+				endpoint := srv.responses[0].GetDynamicMetadata().GetFields()["envoy.lb"].GetStructValue().
+					GetFields()["x-gateway-destination-endpoint"].GetStringValue()
+				if endpoint != test.wantEndpoint {
+					t.Errorf("Unexpected endpoint, want %s, got %s", test.wantEndpoint, endpoint)
+				}
+				return
+			}
+			if diff := cmp.Diff(test.want, srv.responses[0], protocmp.Transform()); diff != "" {
+				t.Errorf("Unexpected response (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestLeastLoadedPod(t *testing.T) {
+	pod := func(name string, queue int, kvCache float64) *metrics.FakePodMetrics {
+		return &metrics.FakePodMetrics{
+			Pod:     &metrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}},
+			Metrics: &metrics.Metrics{WaitingQueueSize: queue, KVCacheUsagePercent: kvCache},
+		}
+	}
+	tests := []struct {
+		name string
+		pods []metrics.PodMetrics
+		want string
+	}{
+		{
+			name: "shortest queue",
+			pods: []metrics.PodMetrics{pod("pod1", 3, 0.1), pod("pod2", 1, 0.9), pod("pod3", 2, 0)},
+			want: "pod2",
+		},
+		{
+			name: "lowest KV-cache utilization on ties",
+			pods: []metrics.PodMetrics{pod("pod1", 1, 0.5), pod("pod2", 1, 0.2), pod("pod3", 1, 0.3)},
+			want: "pod2",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := leastLoadedPod(test.pods).NamespacedName.Name; got != test.want {
+				t.Errorf("Unexpected pod, want %s, got %s", test.want, got)
+			}
+		})
+	}
+	if got := leastLoadedPod(nil); got != nil {
+		t.Errorf("Unexpected pod without pods, want nil, got %v", got)
+	}
+}
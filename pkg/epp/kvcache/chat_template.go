@@ -0,0 +1,80 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvcache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+const chatTemplateFileName = "chat_template.tmpl"
+
+// ErrNoChatTemplate is returned when rendering messages with a tokenizer without a chat template.
+var ErrNoChatTemplate = errors.New("no chat template")
+
+// ChatMessage is a message of a chat completions request.
+type ChatMessage struct {
+	Role    string
+	Content string
+}
+
+// chatTemplateData is the data the chat templates are executed with. The generation prompt, e.g.,
+// the header of the assistant message, is always added, as by the model server for chat
+// completions requests.
+type chatTemplateData struct {
+	Messages            []ChatMessage
+	AddGenerationPrompt bool
+}
+
+// chatTemplateFuncs are the functions available to the chat templates, besides the builtin ones.
+var chatTemplateFuncs = template.FuncMap{
+	"trim": strings.TrimSpace,
+}
+
+// loadChatTemplate loads the Go template of the chat template of a model, the equivalent of the
+// Jinja chat template of the model, nil if the file does not exist.
+func loadChatTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat template file: %w", err)
+	}
+	tmpl, err := template.New(chatTemplateFileName).Funcs(chatTemplateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse chat template file %s: %w", path, err)
+	}
+	return tmpl, nil
+}
+
+// ApplyChatTemplate renders the given messages into the prompt of the model, which is then encoded
+// without adding the special tokens of the tokenizer, as the template holds them. ErrNoChatTemplate
+// is returned if the tokenizer has no chat template.
+func (t *Tokenizer) ApplyChatTemplate(messages []ChatMessage) (string, error) {
+	if t.chatTemplate == nil {
+		return "", ErrNoChatTemplate
+	}
+	var b strings.Builder
+	if err := t.chatTemplate.Execute(&b, chatTemplateData{Messages: messages, AddGenerationPrompt: true}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
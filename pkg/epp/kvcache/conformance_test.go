@@ -0,0 +1,121 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvcache
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// conformanceDirEnvVar is the tokenizers directory holding the expectations generated by
+// hack/kvcache-conformance.py from the Hugging Face tokenizers and chat templates of real models.
+const conformanceDirEnvVar = "KVCACHE_CONFORMANCE_DIR"
+
+const conformanceFileName = "conformance.json"
+
+type conformanceExpectations struct {
+	Prompts []struct {
+		Text string   `json:"text"`
+		IDs  []uint32 `json:"ids"`
+	} `json:"prompts"`
+	Chats []struct {
+		Messages []ChatMessage `json:"messages"`
+		Prompt   string        `json:"prompt"`
+		IDs      []uint32      `json:"ids"`
+	} `json:"chats"`
+}
+
+// TestConformance checks the tokenizers and the chat templates against the ones of real models,
+// for the models of the directory set by KVCACHE_CONFORMANCE_DIR, e.g.:
+//
+//	python3 hack/kvcache-conformance.py /tmp/tokenizers meta-llama/Llama-3.1-8B-Instruct Qwen/Qwen2.5-7B-Instruct
+//	KVCACHE_CONFORMANCE_DIR=/tmp/tokenizers go test ./pkg/epp/kvcache/ -run TestConformance
+//
+// The chats are only checked for the models with a chat_template.tmpl file.
+func TestConformance(t *testing.T) {
+	dir := os.Getenv(conformanceDirEnvVar)
+	if dir == "" {
+		t.Skipf("%s is not set", conformanceDirEnvVar)
+	}
+
+	pool := NewTokenizerPool(dir)
+	models := 0
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || entry.Name() != conformanceFileName {
+			return err
+		}
+		model, err := filepath.Rel(dir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		models++
+		t.Run(filepath.ToSlash(model), func(t *testing.T) {
+			testModelConformance(t, pool, filepath.ToSlash(model), path)
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if models == 0 {
+		t.Fatalf("No %s file in %s", conformanceFileName, dir)
+	}
+}
+
+func testModelConformance(t *testing.T, pool *TokenizerPool, model, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectations conformanceExpectations
+	if err := json.Unmarshal(data, &expectations); err != nil {
+		t.Fatalf("Invalid %s: %v", path, err)
+	}
+	tokenizer, err := pool.Get(model)
+	if err != nil {
+		t.Fatalf("Failed to load tokenizer: %v", err)
+	}
+
+	for _, prompt := range expectations.Prompts {
+		if diff := cmp.Diff(prompt.IDs, tokenizer.Encode(prompt.Text, true)); diff != "" {
+			t.Errorf("Unexpected token IDs of %q (-want +got): %v", prompt.Text, diff)
+		}
+	}
+
+	for i, chat := range expectations.Chats {
+		prompt, err := tokenizer.ApplyChatTemplate(chat.Messages)
+		if errors.Is(err, ErrNoChatTemplate) {
+			t.Logf("Skipping the chats, the model has no %s", chatTemplateFileName)
+			return
+		}
+		if err != nil {
+			t.Fatalf("Failed to apply the chat template: %v", err)
+		}
+		if diff := cmp.Diff(chat.Prompt, prompt); diff != "" {
+			t.Errorf("Unexpected prompt of chat %d (-want +got): %v", i, diff)
+		}
+		if diff := cmp.Diff(chat.IDs, tokenizer.Encode(chat.Prompt, false)); diff != "" {
+			t.Errorf("Unexpected token IDs of chat %d (-want +got): %v", i, diff)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvcache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+const (
+	// EventsPath is the HTTP path on which model servers push their KV-cache events.
+	EventsPath = "/v1/kv-events"
+
+	BlockStoredEventType      = "BlockStored"
+	BlockRemovedEventType     = "BlockRemoved"
+	AllBlocksClearedEventType = "AllBlocksCleared"
+
+	// maxEventBatchBytes bounds the size of a single pushed event batch.
+	maxEventBatchBytes = 16 << 20
+)
+
+// EventBatch mirrors, in JSON, the KV-cache event batch published by vLLM in msgpack over ZMQ.
+type EventBatch struct {
+	Timestamp float64 `json:"ts"`
+	Events    []Event `json:"events"`
+}
+
+// Event is a single KV-cache event. Only the fields relevant to the event type are set.
+type Event struct {
+	Type            string      `json:"type"`
+	BlockHashes     []BlockHash `json:"block_hashes,omitempty"`
+	ParentBlockHash *BlockHash  `json:"parent_block_hash,omitempty"`
+	TokenIDs        []uint32    `json:"token_ids,omitempty"`
+	BlockSize       int         `json:"block_size,omitempty"`
+}
+
+// BlockHash is an engine block hash. Engines report hashes either as integers or as strings, both
+// are accepted and kept in their textual form.
+type BlockHash string
+
+func (h *BlockHash) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*h = BlockHash(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid block hash %s: %w", data, err)
+	}
+	*h = BlockHash(n.String())
+	return nil
+}
+
+// NewEventsHandler returns an HTTP handler that applies pushed event batches to the given index.
+// The events are applied to the pod of the address of the pushing client, so that a client cannot
+// write the blocks of another pod: they must be pushed from the network namespace of the model
+// server, e.g., by a sidecar.
+func NewEventsHandler(index *Index, logger logr.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var batch EventBatch
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEventBatchBytes)).Decode(&batch); err != nil {
+			http.Error(w, fmt.Sprintf("invalid event batch: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		pod, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			http.Error(w, "cannot determine the reporting pod", http.StatusBadRequest)
+			return
+		}
+
+		if err := index.Apply(pod, &batch); err != nil {
+			// Partial failures are expected when events were missed, the rest of the batch is applied.
+			logger.V(logutil.DEBUG).Info("Failed to apply some KV-cache events", "pod", pod, "err", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
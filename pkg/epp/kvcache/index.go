@@ -0,0 +1,233 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kvcache implements an in-process index of the KV-cache blocks held by model servers.
+// The index is fed by the KV-cache events published by the model servers (block stored, block
+// removed, all blocks cleared) and answers which pods hold the longest cached prefix of a
+// tokenized prompt.
+package kvcache
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	// DefaultBlockSize is the default number of tokens in a KV-cache block, matching vLLM's default.
+	DefaultBlockSize = 16
+)
+
+// Index is an in-memory mapping of KV-cache blocks to the pods that hold them.
+//
+// Model servers identify blocks by engine-specific hashes that cannot be reproduced outside the
+// engine. The index therefore re-keys every stored block by a chained hash of its token IDs, which
+// can be computed both from the events and from a locally tokenized prompt. The engine hashes are
+// only kept per pod to resolve parent blocks and removals.
+type Index struct {
+	mu        sync.RWMutex
+	blockSize int
+	// blocks maps a chained block key to the set of pods holding the block.
+	blocks map[uint64]map[string]struct{}
+	// pods maps a pod to the engine block hashes it reported and the block keys they resolve to.
+	pods map[string]map[BlockHash]uint64
+}
+
+// NewIndex creates an empty Index for the given block size. If the block size is not positive,
+// DefaultBlockSize is used.
+func NewIndex(blockSize int) *Index {
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+	return &Index{
+		blockSize: blockSize,
+		blocks:    make(map[uint64]map[string]struct{}),
+		pods:      make(map[string]map[BlockHash]uint64),
+	}
+}
+
+// BlockSize returns the number of tokens per block used by the index.
+func (idx *Index) BlockSize() int {
+	return idx.blockSize
+}
+
+// Apply applies a batch of events reported by the given pod to the index.
+// Events that cannot be applied are skipped, and the last error encountered is returned.
+func (idx *Index) Apply(pod string, batch *EventBatch) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	var lastErr error
+	for i := range batch.Events {
+		if err := idx.applyLocked(pod, &batch.Events[i]); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (idx *Index) applyLocked(pod string, ev *Event) error {
+	switch ev.Type {
+	case BlockStoredEventType:
+		return idx.storeLocked(pod, ev)
+	case BlockRemovedEventType:
+		idx.removeLocked(pod, ev.BlockHashes)
+		return nil
+	case AllBlocksClearedEventType:
+		idx.removePodLocked(pod)
+		return nil
+	default:
+		return fmt.Errorf("unknown event type %q", ev.Type)
+	}
+}
+
+func (idx *Index) storeLocked(pod string, ev *Event) error {
+	if ev.BlockSize != 0 && ev.BlockSize != idx.blockSize {
+		return fmt.Errorf("block size %d reported by pod %s does not match the index block size %d", ev.BlockSize, pod, idx.blockSize)
+	}
+	if len(ev.TokenIDs) < len(ev.BlockHashes)*idx.blockSize {
+		return fmt.Errorf("got %d tokens for %d blocks from pod %s", len(ev.TokenIDs), len(ev.BlockHashes), pod)
+	}
+
+	podBlocks, ok := idx.pods[pod]
+	if !ok {
+		podBlocks = make(map[BlockHash]uint64)
+		idx.pods[pod] = podBlocks
+	}
+
+	parentKey := uint64(0)
+	if ev.ParentBlockHash != nil {
+		if parentKey, ok = podBlocks[*ev.ParentBlockHash]; !ok {
+			// The parent was stored before we started listening or its event was lost, the chain
+			// cannot be reconstructed.
+			return fmt.Errorf("unknown parent block %s for pod %s", *ev.ParentBlockHash, pod)
+		}
+	}
+
+	for i, hash := range ev.BlockHashes {
+		key := blockKey(parentKey, ev.TokenIDs[i*idx.blockSize:(i+1)*idx.blockSize])
+		podBlocks[hash] = key
+
+		holders, ok := idx.blocks[key]
+		if !ok {
+			holders = make(map[string]struct{})
+			idx.blocks[key] = holders
+		}
+		holders[pod] = struct{}{}
+		parentKey = key
+	}
+	return nil
+}
+
+func (idx *Index) removeLocked(pod string, hashes []BlockHash) {
+	podBlocks, ok := idx.pods[pod]
+	if !ok {
+		return
+	}
+	for _, hash := range hashes {
+		key, ok := podBlocks[hash]
+		if !ok {
+			continue
+		}
+		delete(podBlocks, hash)
+		idx.unlinkLocked(pod, key)
+	}
+}
+
+func (idx *Index) removePodLocked(pod string) {
+	for _, key := range idx.pods[pod] {
+		idx.unlinkLocked(pod, key)
+	}
+	delete(idx.pods, pod)
+}
+
+func (idx *Index) unlinkLocked(pod string, key uint64) {
+	holders, ok := idx.blocks[key]
+	if !ok {
+		return
+	}
+	delete(holders, pod)
+	if len(holders) == 0 {
+		delete(idx.blocks, key)
+	}
+}
+
+// RemovePod drops all blocks held by the given pod.
+func (idx *Index) RemovePod(pod string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removePodLocked(pod)
+}
+
+// LongestPrefix returns, for each pod holding at least the first block of the given tokens, the
+// number of consecutive blocks of the token sequence that are cached on the pod.
+// Trailing tokens that do not fill a whole block are ignored.
+func (idx *Index) LongestPrefix(tokens []uint32) map[string]int {
+	keys := BlockKeys(tokens, idx.blockSize)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	matched := make(map[string]int)
+	for pod := range idx.blocks[keys[0]] {
+		matched[pod] = 1
+	}
+	active := len(matched)
+	for i := 1; i < len(keys) && active > 0; i++ {
+		holders := idx.blocks[keys[i]]
+		active = 0
+		for pod, count := range matched {
+			if count != i {
+				continue // the pod already missed a previous block
+			}
+			if _, ok := holders[pod]; ok {
+				matched[pod] = i + 1
+				active++
+			}
+		}
+	}
+	return matched
+}
+
+// BlockKeys splits the tokens into full blocks of the given size and returns the chained key of
+// each block.
+func BlockKeys(tokens []uint32, blockSize int) []uint64 {
+	if blockSize <= 0 {
+		return nil
+	}
+	keys := make([]uint64, 0, len(tokens)/blockSize)
+	parent := uint64(0)
+	for start := 0; start+blockSize <= len(tokens); start += blockSize {
+		parent = blockKey(parent, tokens[start:start+blockSize])
+		keys = append(keys, parent)
+	}
+	return keys
+}
+
+// blockKey hashes the parent block key together with the block tokens.
+func blockKey(parent uint64, tokens []uint32) uint64 {
+	buf := make([]byte, 8+4*len(tokens))
+	binary.LittleEndian.PutUint64(buf, parent)
+	for i, token := range tokens {
+		binary.LittleEndian.PutUint32(buf[8+4*i:], token)
+	}
+	return xxhash.Sum64(buf)
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvcache
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+)
+
+func tokens(n int, offset uint32) []uint32 {
+	res := make([]uint32, n)
+	for i := range res {
+		res[i] = offset + uint32(i)
+	}
+	return res
+}
+
+func hashPtr(h BlockHash) *BlockHash {
+	return &h
+}
+
+func TestIndexLongestPrefix(t *testing.T) {
+	const blockSize = 4
+	prompt := tokens(4*blockSize+2, 0)
+
+	tests := []struct {
+		name   string
+		events map[string][]Event
+		want   map[string]int
+	}{
+		{
+			name: "no blocks",
+			want: map[string]int{},
+		},
+		{
+			name: "full and partial prefixes",
+			events: map[string][]Event{
+				"10.0.0.1": {{Type: BlockStoredEventType, BlockHashes: []BlockHash{"a", "b", "c", "d"}, TokenIDs: prompt[:4*blockSize]}},
+				"10.0.0.2": {{Type: BlockStoredEventType, BlockHashes: []BlockHash{"a", "b"}, TokenIDs: prompt[:2*blockSize]}},
+			},
+			want: map[string]int{"10.0.0.1": 4, "10.0.0.2": 2},
+		},
+		{
+			name: "chained through parent block",
+			events: map[string][]Event{
+				"10.0.0.1": {
+					{Type: BlockStoredEventType, BlockHashes: []BlockHash{"a"}, TokenIDs: prompt[:blockSize]},
+					{Type: BlockStoredEventType, BlockHashes: []BlockHash{"b", "c"}, ParentBlockHash: hashPtr("a"), TokenIDs: prompt[blockSize : 3*blockSize]},
+				},
+			},
+			want: map[string]int{"10.0.0.1": 3},
+		},
+		{
+			name: "removed block breaks the prefix",
+			events: map[string][]Event{
+				"10.0.0.1": {
+					{Type: BlockStoredEventType, BlockHashes: []BlockHash{"a", "b", "c"}, TokenIDs: prompt[:3*blockSize]},
+					{Type: BlockRemovedEventType, BlockHashes: []BlockHash{"b"}},
+				},
+			},
+			want: map[string]int{"10.0.0.1": 1},
+		},
+		{
+			name: "cleared pod",
+			events: map[string][]Event{
+				"10.0.0.1": {
+					{Type: BlockStoredEventType, BlockHashes: []BlockHash{"a", "b"}, TokenIDs: prompt[:2*blockSize]},
+					{Type: AllBlocksClearedEventType},
+				},
+				"10.0.0.2": {{Type: BlockStoredEventType, BlockHashes: []BlockHash{"a"}, TokenIDs: prompt[:blockSize]}},
+			},
+			want: map[string]int{"10.0.0.2": 1},
+		},
+		{
+			name: "different first block does not match",
+			events: map[string][]Event{
+				"10.0.0.1": {{Type: BlockStoredEventType, BlockHashes: []BlockHash{"x", "b"}, TokenIDs: append(tokens(blockSize, 100), prompt[blockSize:2*blockSize]...)}},
+			},
+			want: map[string]int{},
+		},
+		{
+			name: "unknown parent is skipped",
+			events: map[string][]Event{
+				"10.0.0.1": {{Type: BlockStoredEventType, BlockHashes: []BlockHash{"b"}, ParentBlockHash: hashPtr("a"), TokenIDs: prompt[blockSize : 2*blockSize]}},
+			},
+			want: map[string]int{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			idx := NewIndex(blockSize)
+			for pod, events := range test.events {
+				_ = idx.Apply(pod, &EventBatch{Events: events})
+			}
+			if diff := cmp.Diff(test.want, idx.LongestPrefix(prompt)); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestIndexApplyErrors(t *testing.T) {
+	idx := NewIndex(4)
+	tests := []struct {
+		name  string
+		event Event
+	}{
+		{name: "block size mismatch", event: Event{Type: BlockStoredEventType, BlockHashes: []BlockHash{"a"}, TokenIDs: tokens(8, 0), BlockSize: 8}},
+		{name: "missing tokens", event: Event{Type: BlockStoredEventType, BlockHashes: []BlockHash{"a", "b"}, TokenIDs: tokens(4, 0)}},
+		{name: "unknown type", event: Event{Type: "Unknown"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := idx.Apply("pod", &EventBatch{Events: []Event{test.event}}); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	idx := NewIndex(2)
+	handler := NewEventsHandler(idx, logr.Discard())
+
+	// The client address identifies the pod, regardless of any pod given in the body.
+	body := `{"ts": 1.5, "pod": "10.0.0.2", "events": [
+		{"type": "BlockStored", "block_hashes": [123, "456"], "parent_block_hash": null, "token_ids": [1, 2, 3, 4], "block_size": 2}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, EventsPath, bytes.NewBufferString(body))
+	req.RemoteAddr = "10.0.0.1:34567"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Unexpected status code %d: %s", rec.Code, rec.Body.String())
+	}
+	if diff := cmp.Diff(map[string]int{"10.0.0.1": 2}, idx.LongestPrefix([]uint32{1, 2, 3, 4, 5})); diff != "" {
+		t.Errorf("Unexpected output (-want +got): %v", diff)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, EventsPath, bytes.NewBufferString(`{"events": [{"type": "BlockRemoved", "block_hashes": ["456"]}]}`))
+	req.RemoteAddr = "10.0.0.1:34568"
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Unexpected status code %d: %s", rec.Code, rec.Body.String())
+	}
+	if diff := cmp.Diff(map[string]int{"10.0.0.1": 1}, idx.LongestPrefix([]uint32{1, 2, 3, 4, 5})); diff != "" {
+		t.Errorf("Unexpected output (-want +got): %v", diff)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, EventsPath, bytes.NewBufferString("not json")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Unexpected status code %d for an invalid body", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, EventsPath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Unexpected status code %d for a GET request", rec.Code)
+	}
+}
@@ -0,0 +1,407 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// gpt2SplitPattern is the split pattern of the ByteLevel pre-tokenizer.
+const gpt2SplitPattern = `'s|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+(?!\S)|\s+`
+
+// component is a normalizer, pre-tokenizer or post-processor of a tokenizer.json file. Only the
+// components of byte-level BPE tokenizers, e.g., of the GPT-2, Llama 3 or Qwen models, are
+// supported.
+type component struct {
+	Type string `json:"type"`
+
+	// Normalizers, Pretokenizers and Processors are the components of a Sequence.
+	Normalizers   []component `json:"normalizers"`
+	Pretokenizers []component `json:"pretokenizers"`
+	Processors    []component `json:"processors"`
+
+	// AddPrefixSpace and UseRegex configure the ByteLevel pre-tokenizer, UseRegex defaulting to true.
+	AddPrefixSpace bool  `json:"add_prefix_space"`
+	UseRegex       *bool `json:"use_regex"`
+
+	// Pattern, Behavior and Invert configure the Split pre-tokenizer.
+	Pattern struct {
+		Regex  *string `json:"Regex"`
+		String *string `json:"String"`
+	} `json:"pattern"`
+	Behavior string `json:"behavior"`
+	Invert   bool   `json:"invert"`
+
+	// Single and SpecialTokens configure the TemplateProcessing post-processor.
+	Single        []templatePiece `json:"single"`
+	SpecialTokens map[string]struct {
+		IDs []uint32 `json:"ids"`
+	} `json:"special_tokens"`
+}
+
+// templatePiece is a piece of the template of a single sequence: a special token, or the sequence.
+type templatePiece struct {
+	SpecialToken *struct {
+		ID string `json:"id"`
+	} `json:"SpecialToken"`
+	Sequence *struct {
+		ID string `json:"id"`
+	} `json:"Sequence"`
+}
+
+// parseComponent parses the given raw component, nil if null.
+func parseComponent(raw json.RawMessage) (*component, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	c := &component{}
+	if err := json.Unmarshal(raw, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// normalizer normalizes the text of a tokenizer, e.g., to the NFC form.
+type normalizer func(text string) string
+
+func newNormalizer(c *component) (normalizer, error) {
+	if c == nil {
+		return func(text string) string { return text }, nil
+	}
+	switch c.Type {
+	case "NFC":
+		return norm.NFC.String, nil
+	case "NFKC":
+		return norm.NFKC.String, nil
+	case "Sequence":
+		normalizers := make([]normalizer, 0, len(c.Normalizers))
+		for i := range c.Normalizers {
+			n, err := newNormalizer(&c.Normalizers[i])
+			if err != nil {
+				return nil, err
+			}
+			normalizers = append(normalizers, n)
+		}
+		return func(text string) string {
+			for _, n := range normalizers {
+				text = n(text)
+			}
+			return text
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported normalizer %q", c.Type)
+	}
+}
+
+// preTokenizer splits the pieces of a text into smaller pieces, which are then encoded separately.
+type preTokenizer func(pieces []string) []string
+
+// newPreTokenizer returns the pre-tokenizer of the given component, which must hold a ByteLevel
+// pre-tokenizer as the byte-level BPE encoding follows.
+func newPreTokenizer(c *component) (preTokenizer, error) {
+	p, byteLevel, err := buildPreTokenizer(c)
+	if err != nil {
+		return nil, err
+	}
+	if !byteLevel {
+		return nil, fmt.Errorf("unsupported pre-tokenizer without ByteLevel")
+	}
+	return p, nil
+}
+
+func buildPreTokenizer(c *component) (preTokenizer, bool, error) {
+	if c == nil {
+		return nil, false, nil
+	}
+	switch c.Type {
+	case "ByteLevel":
+		var split *splitPattern
+		if c.UseRegex == nil || *c.UseRegex {
+			var err error
+			if split, err = compileSplitPattern(gpt2SplitPattern); err != nil {
+				return nil, false, err
+			}
+		}
+		addPrefixSpace := c.AddPrefixSpace
+		return func(pieces []string) []string {
+			if addPrefixSpace && len(pieces) > 0 && !strings.HasPrefix(pieces[0], " ") {
+				pieces[0] = " " + pieces[0]
+			}
+			if split == nil {
+				return pieces
+			}
+			return split.splitAll(pieces)
+		}, true, nil
+	case "Split":
+		if c.Invert || c.Behavior != "Isolated" {
+			return nil, false, fmt.Errorf("unsupported split behavior %q", c.Behavior)
+		}
+		pattern := ""
+		switch {
+		case c.Pattern.Regex != nil:
+			pattern = *c.Pattern.Regex
+		case c.Pattern.String != nil:
+			pattern = regexp.QuoteMeta(*c.Pattern.String)
+		}
+		split, err := compileSplitPattern(pattern)
+		if err != nil {
+			return nil, false, err
+		}
+		return split.splitAll, false, nil
+	case "Sequence":
+		preTokenizers := []preTokenizer{}
+		byteLevel := false
+		for i := range c.Pretokenizers {
+			p, isByteLevel, err := buildPreTokenizer(&c.Pretokenizers[i])
+			if err != nil {
+				return nil, false, err
+			}
+			preTokenizers = append(preTokenizers, p)
+			byteLevel = byteLevel || isByteLevel
+		}
+		return func(pieces []string) []string {
+			for _, p := range preTokenizers {
+				pieces = p(pieces)
+			}
+			return pieces
+		}, byteLevel, nil
+	default:
+		return nil, false, fmt.Errorf("unsupported pre-tokenizer %q", c.Type)
+	}
+}
+
+// specialTokens returns the IDs of the special tokens the post-processor of the given component
+// adds before and after a single sequence, e.g., the BOS token.
+func specialTokens(c *component) (prefix, suffix []uint32, err error) {
+	if c == nil {
+		return nil, nil, nil
+	}
+	switch c.Type {
+	case "ByteLevel":
+		return nil, nil, nil
+	case "TemplateProcessing":
+		sequence := false
+		for _, piece := range c.Single {
+			if piece.Sequence != nil {
+				sequence = true
+				continue
+			}
+			if piece.SpecialToken == nil {
+				continue
+			}
+			token, ok := c.SpecialTokens[piece.SpecialToken.ID]
+			if !ok {
+				return nil, nil, fmt.Errorf("unknown special token %q in the post-processor template", piece.SpecialToken.ID)
+			}
+			if sequence {
+				suffix = append(suffix, token.IDs...)
+			} else {
+				prefix = append(prefix, token.IDs...)
+			}
+		}
+		return prefix, suffix, nil
+	case "Sequence":
+		for i := range c.Processors {
+			p, s, err := specialTokens(&c.Processors[i])
+			if err != nil {
+				return nil, nil, err
+			}
+			prefix, suffix = append(prefix, p...), append(suffix, s...)
+		}
+		return prefix, suffix, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported post-processor %q", c.Type)
+	}
+}
+
+// splitPattern splits texts into the matches of a pattern and the text between them, as the Split
+// pre-tokenizer with the Isolated behavior. As Go regular expressions support no lookahead, the
+// \s+(?!\S) alternative of the usual patterns is matched separately, which requires the pattern to
+// be matched one top-level alternative at a time, in order.
+type splitPattern struct {
+	// alternatives are the top-level alternatives of the pattern, anchored, nil for \s+(?!\S).
+	alternatives []*regexp.Regexp
+}
+
+const trailingSpaceAlternative = `\s+(?!\S)`
+
+func compileSplitPattern(pattern string) (*splitPattern, error) {
+	alternatives, err := splitAlternatives(pattern)
+	if err != nil {
+		return nil, err
+	}
+	p := &splitPattern{}
+	for _, alternative := range alternatives {
+		if alternative == trailingSpaceAlternative {
+			p.alternatives = append(p.alternatives, nil)
+			continue
+		}
+		if strings.Contains(alternative, "(?=") || strings.Contains(alternative, "(?!") || strings.Contains(alternative, "(?<") {
+			return nil, fmt.Errorf("unsupported lookaround in split pattern %q", pattern)
+		}
+		expr, err := unicodeSpaces(alternative)
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(`^(?:` + expr + `)`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid split pattern %q: %w", pattern, err)
+		}
+		p.alternatives = append(p.alternatives, re)
+	}
+	return p, nil
+}
+
+// splitAlternatives splits the pattern into its top-level alternatives.
+func splitAlternatives(pattern string) ([]string, error) {
+	var alternatives []string
+	depth, start, inClass := 0, 0, false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+			if i+1 < len(pattern) && pattern[i+1] == ']' {
+				i++ // A leading ] is part of the class.
+			}
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == '|' && depth == 0:
+			alternatives = append(alternatives, pattern[start:i])
+			start = i + 1
+		}
+	}
+	if depth != 0 || inClass {
+		return nil, fmt.Errorf("unbalanced split pattern %q", pattern)
+	}
+	return append(alternatives, pattern[start:]), nil
+}
+
+// spaceClass is the Unicode white space, which \s matches in the patterns of tokenizer.json files
+// while it only matches ASCII spaces in Go regular expressions.
+const spaceClass = `\t\n\v\f\r\x{85}\p{Z}`
+
+// unicodeSpaces rewrites the \s and \S classes of the given expression to match Unicode spaces.
+func unicodeSpaces(expr string) (string, error) {
+	var b strings.Builder
+	inClass := false
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		if c == '\\' && i+1 < len(expr) {
+			switch next := expr[i+1]; {
+			case next == 's' && inClass:
+				b.WriteString(spaceClass)
+			case next == 's':
+				b.WriteString("[" + spaceClass + "]")
+			case next == 'S' && inClass:
+				return "", fmt.Errorf("unsupported \\S in a character class of split pattern %q", expr)
+			case next == 'S':
+				b.WriteString("[^" + spaceClass + "]")
+			default:
+				b.WriteByte(c)
+				b.WriteByte(next)
+			}
+			i++
+			continue
+		}
+		switch {
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), nil
+}
+
+func (p *splitPattern) splitAll(pieces []string) []string {
+	var split []string
+	for _, piece := range pieces {
+		split = p.split(piece, split)
+	}
+	return split
+}
+
+// split appends the pieces of the text to the given ones.
+func (p *splitPattern) split(text string, pieces []string) []string {
+	gap := 0
+	for i := 0; i < len(text); {
+		n := p.match(text[i:])
+		if n == 0 {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+			continue
+		}
+		if gap < i {
+			pieces = append(pieces, text[gap:i])
+		}
+		pieces = append(pieces, text[i:i+n])
+		i += n
+		gap = i
+	}
+	if gap < len(text) {
+		pieces = append(pieces, text[gap:])
+	}
+	return pieces
+}
+
+// match returns the length of the match of the first matching alternative at the start of the text.
+func (p *splitPattern) match(text string) int {
+	for _, re := range p.alternatives {
+		n := 0
+		if re == nil {
+			n = trailingSpaces(text)
+		} else if loc := re.FindStringIndex(text); loc != nil {
+			n = loc[1]
+		}
+		if n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// trailingSpaces returns the length of the match of \s+(?!\S) at the start of the text: the run of
+// spaces, less its last space if followed by a non-space.
+func trailingSpaces(text string) int {
+	end, last := 0, 0
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		last = end
+		end += size
+	}
+	if end == len(text) {
+		return end
+	}
+	return last
+}
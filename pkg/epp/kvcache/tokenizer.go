@@ -0,0 +1,364 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const tokenizerFileName = "tokenizer.json"
+
+// Tokenizer is a byte-level BPE tokenizer loaded from a Hugging Face tokenizer.json file, e.g., of
+// the GPT-2, Llama 3 or Qwen models. The normalizer, pre-tokenizer and post-processor of the file
+// are applied as by the model server, so that the token IDs, and hence the KV-cache blocks, match.
+// Files of other tokenizers, e.g., SentencePiece ones, fail to load.
+type Tokenizer struct {
+	vocab        map[string]uint32
+	mergeRanks   map[[2]string]int
+	ignoreMerges bool
+	addedTokens  []addedToken // sorted by decreasing length
+	byteToRune   [256]rune
+	normalize    normalizer
+	preTokenize  preTokenizer
+	// prefix and suffix are the special tokens added around the encoded text, e.g., the BOS token.
+	prefix, suffix []uint32
+	// chatTemplate renders the messages of chat completions requests, nil if not set.
+	chatTemplate *template.Template
+
+	cacheMu sync.RWMutex
+	cache   map[string][]uint32
+}
+
+type addedToken struct {
+	content string
+	id      uint32
+}
+
+type tokenizerFile struct {
+	AddedTokens []struct {
+		ID      uint32 `json:"id"`
+		Content string `json:"content"`
+	} `json:"added_tokens"`
+	Normalizer    json.RawMessage `json:"normalizer"`
+	PreTokenizer  json.RawMessage `json:"pre_tokenizer"`
+	PostProcessor json.RawMessage `json:"post_processor"`
+	Model         struct {
+		Type         string            `json:"type"`
+		Vocab        map[string]uint32 `json:"vocab"`
+		Merges       []json.RawMessage `json:"merges"`
+		IgnoreMerges bool              `json:"ignore_merges"`
+	} `json:"model"`
+}
+
+// maxCachedWords bounds the per-tokenizer cache of pre-token encodings.
+const maxCachedWords = 100000
+
+// LoadTokenizer loads a byte-level BPE tokenizer from a tokenizer.json file.
+func LoadTokenizer(path string) (*Tokenizer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokenizer file: %w", err)
+	}
+	var file tokenizerFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse tokenizer file %s: %w", path, err)
+	}
+	if file.Model.Type != "BPE" {
+		return nil, fmt.Errorf("unsupported tokenizer model type %q in %s", file.Model.Type, path)
+	}
+
+	t := &Tokenizer{
+		vocab:        file.Model.Vocab,
+		mergeRanks:   make(map[[2]string]int, len(file.Model.Merges)),
+		ignoreMerges: file.Model.IgnoreMerges,
+		byteToRune:   bytesToUnicode(),
+		cache:        make(map[string][]uint32),
+	}
+	normalizer, err := parseComponent(file.Normalizer)
+	if err == nil {
+		t.normalize, err = newNormalizer(normalizer)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid normalizer in %s: %w", path, err)
+	}
+	preTokenizer, err := parseComponent(file.PreTokenizer)
+	if err == nil {
+		t.preTokenize, err = newPreTokenizer(preTokenizer)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid pre-tokenizer in %s: %w", path, err)
+	}
+	postProcessor, err := parseComponent(file.PostProcessor)
+	if err == nil {
+		t.prefix, t.suffix, err = specialTokens(postProcessor)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid post-processor in %s: %w", path, err)
+	}
+	for rank, raw := range file.Model.Merges {
+		pair, err := parseMerge(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid merge %d in %s: %w", rank, path, err)
+		}
+		t.mergeRanks[pair] = rank
+	}
+	for _, added := range file.AddedTokens {
+		if added.Content != "" {
+			t.addedTokens = append(t.addedTokens, addedToken{content: added.Content, id: added.ID})
+		}
+	}
+	sort.Slice(t.addedTokens, func(i, j int) bool {
+		return len(t.addedTokens[i].content) > len(t.addedTokens[j].content)
+	})
+	return t, nil
+}
+
+// parseMerge accepts both the legacy "a b" and the newer ["a", "b"] merge encodings.
+func parseMerge(raw json.RawMessage) ([2]string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		left, right, ok := strings.Cut(s, " ")
+		if !ok {
+			return [2]string{}, fmt.Errorf("malformed merge %q", s)
+		}
+		return [2]string{left, right}, nil
+	}
+	var pair []string
+	if err := json.Unmarshal(raw, &pair); err != nil {
+		return [2]string{}, err
+	}
+	if len(pair) != 2 {
+		return [2]string{}, fmt.Errorf("malformed merge %v", pair)
+	}
+	return [2]string{pair[0], pair[1]}, nil
+}
+
+// Encode returns the token IDs of the given text, along with the special tokens of the tokenizer
+// post-processor, e.g., the BOS token, if addSpecialTokens is true. Special tokens present in the
+// text are encoded as their IDs.
+func (t *Tokenizer) Encode(text string, addSpecialTokens bool) []uint32 {
+	var ids []uint32
+	if addSpecialTokens {
+		ids = append(ids, t.prefix...)
+	}
+	for len(text) > 0 {
+		pos, special := t.nextAddedToken(text)
+		if special == nil {
+			ids = t.encodeOrdinary(text, ids)
+			break
+		}
+		ids = t.encodeOrdinary(text[:pos], ids)
+		ids = append(ids, special.id)
+		text = text[pos+len(special.content):]
+	}
+	if addSpecialTokens {
+		ids = append(ids, t.suffix...)
+	}
+	return ids
+}
+
+// nextAddedToken finds the earliest (and then longest) added token occurring in the text.
+func (t *Tokenizer) nextAddedToken(text string) (int, *addedToken) {
+	pos := -1
+	var found *addedToken
+	for i := range t.addedTokens {
+		at := strings.Index(text, t.addedTokens[i].content)
+		if at >= 0 && (pos < 0 || at < pos) {
+			pos = at
+			found = &t.addedTokens[i]
+		}
+	}
+	return pos, found
+}
+
+func (t *Tokenizer) encodeOrdinary(text string, ids []uint32) []uint32 {
+	if text == "" {
+		return ids
+	}
+	for _, word := range t.preTokenize([]string{t.normalize(text)}) {
+		ids = append(ids, t.encodeWord(word)...)
+	}
+	return ids
+}
+
+func (t *Tokenizer) encodeWord(word string) []uint32 {
+	t.cacheMu.RLock()
+	cached, ok := t.cache[word]
+	t.cacheMu.RUnlock()
+	if ok {
+		return cached
+	}
+
+	symbols := make([]string, 0, len(word))
+	for i := 0; i < len(word); i++ {
+		symbols = append(symbols, string(t.byteToRune[word[i]]))
+	}
+	if _, ok := t.vocab[strings.Join(symbols, "")]; ok && t.ignoreMerges {
+		// Words of the vocabulary are encoded as is, without applying the merges.
+		symbols = []string{strings.Join(symbols, "")}
+	} else {
+		symbols = t.merge(symbols)
+	}
+
+	ids := make([]uint32, 0, len(symbols))
+	for _, symbol := range symbols {
+		if id, ok := t.vocab[symbol]; ok {
+			ids = append(ids, id)
+		}
+	}
+
+	t.cacheMu.Lock()
+	if len(t.cache) >= maxCachedWords {
+		t.cache = make(map[string][]uint32)
+	}
+	t.cache[word] = ids
+	t.cacheMu.Unlock()
+	return ids
+}
+
+// merge repeatedly merges the adjacent pair of symbols with the lowest merge rank.
+func (t *Tokenizer) merge(symbols []string) []string {
+	for len(symbols) > 1 {
+		best, bestRank := -1, 0
+		for i := 0; i < len(symbols)-1; i++ {
+			rank, ok := t.mergeRanks[[2]string{symbols[i], symbols[i+1]}]
+			if ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		pair := [2]string{symbols[best], symbols[best+1]}
+		merged := symbols[:0:0]
+		for i := 0; i < len(symbols); i++ {
+			if i < len(symbols)-1 && symbols[i] == pair[0] && symbols[i+1] == pair[1] {
+				merged = append(merged, pair[0]+pair[1])
+				i++
+				continue
+			}
+			merged = append(merged, symbols[i])
+		}
+		symbols = merged
+	}
+	return symbols
+}
+
+// bytesToUnicode returns the GPT-2 mapping of bytes to printable unicode characters.
+func bytesToUnicode() [256]rune {
+	var table [256]rune
+	printable := func(b int) bool {
+		return (b >= '!' && b <= '~') || (b >= 0xA1 && b <= 0xAC) || (b >= 0xAE && b <= 0xFF)
+	}
+	n := 0
+	for b := 0; b < 256; b++ {
+		if printable(b) {
+			table[b] = rune(b)
+		} else {
+			table[b] = rune(256 + n)
+			n++
+		}
+	}
+	return table
+}
+
+// TokenizerPool lazily loads and caches tokenizers from a local directory.
+//
+// The tokenizer of a model is read from <dir>/<model>/tokenizer.json, falling back to
+// <dir>/tokenizer.json when no model specific file exists, along with the chat template of the same
+// directory, if any. Failures to load a tokenizer are cached for a while, sparing the reads of a
+// missing or invalid file on every request.
+type TokenizerPool struct {
+	dir string
+
+	mu         sync.Mutex
+	tokenizers map[string]*tokenizerEntry
+}
+
+// tokenizerRetryInterval is the time after which a tokenizer that failed to load is loaded again.
+const tokenizerRetryInterval = time.Minute
+
+// tokenizerEntry is a tokenizer of the pool, loaded once loaded is closed.
+type tokenizerEntry struct {
+	loaded    chan struct{}
+	tokenizer *Tokenizer
+	err       error
+	failedAt  time.Time
+}
+
+// NewTokenizerPool creates a TokenizerPool reading tokenizer files from the given directory.
+func NewTokenizerPool(dir string) *TokenizerPool {
+	return &TokenizerPool{
+		dir:        dir,
+		tokenizers: make(map[string]*tokenizerEntry),
+	}
+}
+
+// Get returns the tokenizer for the given model. Tokenizers are loaded outside the lock of the pool,
+// so that loading the tokenizer of a model does not block the requests of the others.
+func (p *TokenizerPool) Get(model string) (*Tokenizer, error) {
+	p.mu.Lock()
+	entry, ok := p.tokenizers[model]
+	if ok && entry.err != nil && time.Since(entry.failedAt) >= tokenizerRetryInterval {
+		ok = false
+	}
+	if ok {
+		p.mu.Unlock()
+		<-entry.loaded
+		return entry.tokenizer, entry.err
+	}
+	entry = &tokenizerEntry{loaded: make(chan struct{})}
+	p.tokenizers[model] = entry
+	p.mu.Unlock()
+
+	tokenizer, err := p.load(model)
+	p.mu.Lock()
+	entry.tokenizer, entry.err = tokenizer, err
+	if err != nil {
+		entry.failedAt = time.Now()
+	}
+	p.mu.Unlock()
+	close(entry.loaded)
+	return tokenizer, err
+}
+
+func (p *TokenizerPool) load(model string) (*Tokenizer, error) {
+	path := filepath.Join(p.dir, tokenizerFileName)
+	if model != "" && filepath.IsLocal(model) {
+		modelPath := filepath.Join(p.dir, model, tokenizerFileName)
+		if _, err := os.Stat(modelPath); err == nil {
+			path = modelPath
+		}
+	}
+
+	t, err := LoadTokenizer(path)
+	if err != nil {
+		return nil, err
+	}
+	if t.chatTemplate, err = loadChatTemplate(filepath.Join(filepath.Dir(path), chatTemplateFileName)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
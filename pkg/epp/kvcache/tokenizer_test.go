@@ -0,0 +1,259 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvcache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testTokenizerJSON = `{
+	"added_tokens": [
+		{"id": 100, "content": "<|endoftext|>", "special": true},
+		{"id": 101, "content": "<|begin_of_text|>", "special": true}
+	],
+	"normalizer": null,
+	"pre_tokenizer": {"type": "ByteLevel", "add_prefix_space": false, "trim_offsets": true, "use_regex": true},
+	"post_processor": null,
+	"model": {
+		"type": "BPE",
+		"vocab": {
+			"h": 0, "e": 1, "l": 2, "o": 3, "Ġ": 4, "w": 5, "r": 6, "d": 7, "!": 8,
+			"he": 10, "ll": 11, "hell": 12, "hello": 13,
+			"Ġw": 14, "or": 15, "Ġwor": 16, "Ġworl": 17, "Ġworld": 18, "lo": 19
+		},
+		"merges": ["h e", "l l", "he ll", ["hell", "o"], "Ġ w", "o r", "Ġw or", "Ġwor l", "Ġworl d"]
+	}
+}`
+
+// testLlama3TokenizerJSON has the pre-tokenizer and post-processor of the Llama 3 tokenizers, and
+// the vocabulary of testTokenizerJSON.
+const testLlama3TokenizerJSON = `{
+	"added_tokens": [
+		{"id": 100, "content": "<|endoftext|>", "special": true},
+		{"id": 101, "content": "<|begin_of_text|>", "special": true}
+	],
+	"normalizer": null,
+	"pre_tokenizer": {"type": "Sequence", "pretokenizers": [
+		{"type": "Split", "pattern": {"Regex": "(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\\r\\n\\p{L}\\p{N}]?\\p{L}+|\\p{N}{1,3}| ?[^\\s\\p{L}\\p{N}]+[\\r\\n]*|\\s*[\\r\\n]+|\\s+(?!\\S)|\\s+"},
+			"behavior": "Isolated", "invert": false},
+		{"type": "ByteLevel", "add_prefix_space": false, "trim_offsets": true, "use_regex": false}
+	]},
+	"post_processor": {"type": "Sequence", "processors": [
+		{"type": "ByteLevel", "add_prefix_space": true, "trim_offsets": false, "use_regex": true},
+		{"type": "TemplateProcessing",
+			"single": [{"SpecialToken": {"id": "<|begin_of_text|>", "type_id": 0}}, {"Sequence": {"id": "A", "type_id": 0}}],
+			"pair": [],
+			"special_tokens": {"<|begin_of_text|>": {"id": "<|begin_of_text|>", "ids": [101], "tokens": ["<|begin_of_text|>"]}}}
+	]},
+	"model": {
+		"type": "BPE",
+		"ignore_merges": true,
+		"vocab": {
+			"h": 0, "e": 1, "l": 2, "o": 3, "Ġ": 4, "w": 5, "r": 6, "d": 7, "!": 8,
+			"he": 10, "ll": 11, "hell": 12, "hello": 13,
+			"Ġw": 14, "or": 15, "Ġwor": 16, "Ġworl": 17, "Ġworld": 18, "lo": 19
+		},
+		"merges": ["h e", "l l", "he ll", ["hell", "o"], "Ġ w", "o r", "Ġw or", "Ġwor l", "Ġworl d"]
+	}
+}`
+
+func writeTokenizer(t *testing.T, dir string) {
+	t.Helper()
+	writeTokenizerFile(t, dir, testTokenizerJSON)
+}
+
+func writeTokenizerFile(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, tokenizerFileName), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTokenizerEncode(t *testing.T) {
+	dir := t.TempDir()
+	writeTokenizer(t, dir)
+	tokenizer, err := LoadTokenizer(filepath.Join(dir, tokenizerFileName))
+	if err != nil {
+		t.Fatalf("Failed to load tokenizer: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want []uint32
+	}{
+		{name: "empty", text: "", want: nil},
+		{name: "merged words", text: "hello world", want: []uint32{13, 18}},
+		{name: "partial merges", text: "hell word!", want: []uint32{12, 16, 7, 8}},
+		{name: "special token", text: "hello<|endoftext|>hello", want: []uint32{13, 100, 13}},
+		{name: "merges applied", text: "lo", want: []uint32{2, 3}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, tokenizer.Encode(test.text, true)); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestTokenizerEncodeLlama3(t *testing.T) {
+	dir := t.TempDir()
+	writeTokenizerFile(t, dir, testLlama3TokenizerJSON)
+	tokenizer, err := LoadTokenizer(filepath.Join(dir, tokenizerFileName))
+	if err != nil {
+		t.Fatalf("Failed to load tokenizer: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		text             string
+		addSpecialTokens bool
+		want             []uint32
+	}{
+		{name: "empty", text: "", want: nil},
+		{name: "BOS", text: "hello world", addSpecialTokens: true, want: []uint32{101, 13, 18}},
+		{name: "no BOS", text: "hello world", want: []uint32{13, 18}},
+		{name: "BOS in text", text: "<|begin_of_text|>hello", want: []uint32{101, 13}},
+		{name: "merges ignored", text: "lo", want: []uint32{19}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, tokenizer.Encode(test.text, test.addSpecialTokens)); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestLoadTokenizerUnsupported(t *testing.T) {
+	tests := map[string]string{
+		"SentencePiece":    `{"pre_tokenizer": {"type": "Metaspace", "replacement": "▁"}, "model": {"type": "BPE"}}`,
+		"no pre-tokenizer": `{"pre_tokenizer": null, "model": {"type": "BPE"}}`,
+		"normalizer":       `{"normalizer": {"type": "Lowercase"}, "pre_tokenizer": {"type": "ByteLevel"}, "model": {"type": "BPE"}}`,
+		"lookbehind": `{"pre_tokenizer": {"type": "Sequence", "pretokenizers": [{"type": "Split", "pattern": {"Regex": "(?<=a)b"},
+			"behavior": "Isolated"}, {"type": "ByteLevel"}]}, "model": {"type": "BPE"}}`,
+		"BERT post-processor": `{"pre_tokenizer": {"type": "ByteLevel"}, "post_processor": {"type": "BertProcessing"}, "model": {"type": "BPE"}}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTokenizerFile(t, dir, content)
+			if _, err := LoadTokenizer(filepath.Join(dir, tokenizerFileName)); err == nil {
+				t.Errorf("Expected an error loading an unsupported tokenizer")
+			}
+		})
+	}
+}
+
+func TestSplitPattern(t *testing.T) {
+	gpt2, err := compileSplitPattern(gpt2SplitPattern)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	llama3, err := compileSplitPattern(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		pattern *splitPattern
+		text    string
+		want    []string
+	}{
+		{pattern: gpt2, text: "Hello world", want: []string{"Hello", " world"}},
+		{pattern: gpt2, text: "it's 2025!", want: []string{"it", "'s", " 2025", "!"}},
+		{pattern: gpt2, text: "a  b", want: []string{"a", " ", " b"}},
+		{pattern: gpt2, text: "a\nb", want: []string{"a", "\n", "b"}},
+		{pattern: gpt2, text: "end  ", want: []string{"end", "  "}},
+		{pattern: gpt2, text: "x ?!y", want: []string{"x", " ?!", "y"}},
+		{pattern: gpt2, text: "héllo wörld", want: []string{"héllo", " wörld"}},
+		{pattern: gpt2, text: "a\u3000\u3000b", want: []string{"a", "\u3000", "\u3000", "b"}},
+		{pattern: llama3, text: "Hello world 12345!\n\n  x", want: []string{"Hello", " world", " ", "123", "45", "!\n\n", " ", " x"}},
+		{pattern: llama3, text: "IT'S", want: []string{"IT", "'S"}},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, test.pattern.split(test.text, nil)); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestTokenizerPool(t *testing.T) {
+	dir := t.TempDir()
+	pool := NewTokenizerPool(dir)
+	if _, err := pool.Get("model"); err == nil {
+		t.Error("Expected an error when no tokenizer file exists")
+	}
+
+	writeTokenizer(t, filepath.Join(dir, "org", "model"))
+	if _, err := pool.Get("org/model"); err != nil {
+		t.Errorf("Failed to load model specific tokenizer: %v", err)
+	}
+	if _, err := pool.Get("../model"); err == nil {
+		t.Error("Expected an error for a model name outside the tokenizers directory")
+	}
+
+	writeTokenizer(t, dir)
+	if _, err := pool.Get("other"); err != nil {
+		t.Errorf("Failed to load the default tokenizer: %v", err)
+	}
+	if _, err := pool.Get("model"); err == nil {
+		t.Error("Expected the failure to load the tokenizer to be cached")
+	}
+}
+
+func TestTokenizerPoolChatTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeTokenizerFile(t, dir, testLlama3TokenizerJSON)
+	chatTemplate := "<|begin_of_text|>{{range .Messages}}{{.Role}}: {{trim .Content}}<|endoftext|>{{end}}" +
+		"{{if .AddGenerationPrompt}}assistant: {{end}}"
+	if err := os.WriteFile(filepath.Join(dir, chatTemplateFileName), []byte(chatTemplate), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tokenizer, err := NewTokenizerPool(dir).Get("model")
+	if err != nil {
+		t.Fatalf("Failed to load tokenizer: %v", err)
+	}
+	got, err := tokenizer.ApplyChatTemplate([]ChatMessage{{Role: "system", Content: "be brief "}, {Role: "user", Content: "hello"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := "<|begin_of_text|>system: be brief<|endoftext|>user: hello<|endoftext|>assistant: "; got != want {
+		t.Errorf("Unexpected prompt, want %q, got %q", want, got)
+	}
+
+	writeTokenizer(t, filepath.Join(dir, "other"))
+	tokenizer, err = NewTokenizerPool(dir).Get("other")
+	if err != nil {
+		t.Fatalf("Failed to load tokenizer: %v", err)
+	}
+	if _, err := tokenizer.ApplyChatTemplate(nil); !errors.Is(err, ErrNoChatTemplate) {
+		t.Errorf("Expected ErrNoChatTemplate, got %v", err)
+	}
+}
@@ -147,6 +147,115 @@ var (
 		[]string{"model_name", "target_model_name"},
 	)
 
+	// Experiment Metrics
+	experimentRequestCounter = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      InferenceModelComponent,
+			Name:           "experiment_request_total",
+			Help:           "Counter of inference model requests broken out for each model and experiment arm.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"model_name", "experiment_arm"},
+	)
+
+	experimentRequestLatencies = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Subsystem: InferenceModelComponent,
+			Name:      "experiment_request_duration_seconds",
+			Help:      "Inference model response latency distribution in seconds for each model and experiment arm.",
+			Buckets: []float64{
+				0.005, 0.025, 0.05, 0.1, 0.2, 0.4, 0.6, 0.8, 1.0, 1.25, 1.5, 2, 3,
+				4, 5, 6, 8, 10, 15, 20, 30, 45, 60, 120, 180, 240, 300, 360, 480, 600, 900, 1200, 1800, 2700, 3600,
+			},
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"model_name", "experiment_arm"},
+	)
+
+	experimentTTFT = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Subsystem: InferenceModelComponent,
+			Name:      "experiment_time_to_first_token_seconds",
+			Help:      "Inference model time to first token distribution in seconds of streamed responses for each model and experiment arm.",
+			Buckets: []float64{
+				0.005, 0.01, 0.025, 0.05, 0.1, 0.2, 0.4, 0.6, 0.8, 1.0, 1.5, 2, 3, 5, 10, 20, 30, 60,
+			},
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"model_name", "experiment_arm"},
+	)
+
+	experimentTPOT = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Subsystem: InferenceModelComponent,
+			Name:      "experiment_time_per_output_token_seconds",
+			Help:      "Inference model time per output token after the first one distribution in seconds of streamed responses for each model and experiment arm.",
+			Buckets: []float64{
+				0.001, 0.002, 0.005, 0.01, 0.02, 0.05, 0.1, 0.2, 0.5, 1.0, 2.0, 5.0, 10.0,
+			},
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"model_name", "experiment_arm"},
+	)
+
+	experimentInputTokens = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Subsystem:      InferenceModelComponent,
+			Name:           "experiment_input_tokens",
+			Help:           "Inference model input token count distribution for each model and experiment arm.",
+			Buckets:        []float64{1, 8, 16, 32, 64, 128, 256, 512, 1024, 2048, 4096, 8192, 16384, 32778, 65536, 131072, 262144, 524288, 1048576},
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"model_name", "experiment_arm"},
+	)
+
+	experimentOutputTokens = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Subsystem:      InferenceModelComponent,
+			Name:           "experiment_output_tokens",
+			Help:           "Inference model output token count distribution for each model and experiment arm.",
+			Buckets:        []float64{1, 8, 16, 32, 64, 128, 256, 512, 1024, 2048, 4096, 8192},
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"model_name", "experiment_arm"},
+	)
+
+	// API Metrics
+	apiRequestCounter = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      InferenceModelComponent,
+			Name:           "api_request_total",
+			Help:           "Counter of inference model requests broken out for each model and API type.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"model_name", "api_type"},
+	)
+
+	apiRequestBatchSizes = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Subsystem:      InferenceModelComponent,
+			Name:           "api_request_batch_size",
+			Help:           "Inference model input count distribution of batched requests, e.g., of embeddings or rerank, for each model and API type.",
+			Buckets:        []float64{1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024, 2048},
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"model_name", "api_type"},
+	)
+
+	apiRequestLatencies = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Subsystem: InferenceModelComponent,
+			Name:      "api_request_duration_seconds",
+			Help:      "Inference model response latency distribution in seconds for each model and API type.",
+			Buckets: []float64{
+				0.005, 0.025, 0.05, 0.1, 0.2, 0.4, 0.6, 0.8, 1.0, 1.25, 1.5, 2, 3,
+				4, 5, 6, 8, 10, 15, 20, 30, 45, 60, 120, 180, 240, 300, 360, 480, 600, 900, 1200, 1800, 2700, 3600,
+			},
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"model_name", "api_type"},
+	)
+
 	// Inference Pool Metrics
 	inferencePoolAvgKVCache = compbasemetrics.NewGaugeVec(
 		&compbasemetrics.GaugeOpts{
@@ -178,6 +287,16 @@ var (
 		[]string{"name"},
 	)
 
+	inferencePoolRolePods = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Subsystem:      InferencePoolComponent,
+			Name:           "role_pods",
+			Help:           "The number of ready pods in the inference server pool for each role, including pods with an unrecognized role label (unknown), which are excluded from scheduling.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"name", "role"},
+	)
+
 	// Scheduler Plugin Metrics
 	SchedulerPluginProcessingLatencies = compbasemetrics.NewHistogramVec(
 		&compbasemetrics.HistogramOpts{
@@ -191,6 +310,76 @@ var (
 		},
 		[]string{"plugin_type", "plugin_name"},
 	)
+
+	latencyPredictorCoefficients = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Subsystem:      EPPComponent,
+			Name:           "latency_predictor_coefficient",
+			Help:           "Coefficients of the online latency regression of the latency predictive scorer for each pod, predicted latency and feature.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"pod_name", "latency", "feature"},
+	)
+
+	latencyPredictorSamples = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Subsystem:      EPPComponent,
+			Name:           "latency_predictor_samples",
+			Help:           "Number of observations the online latency regression of the latency predictive scorer was fitted on for each pod and predicted latency.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"pod_name", "latency"},
+	)
+
+	pdPairingCounter = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      EPPComponent,
+			Name:           "pd_pairing_total",
+			Help:           "Counter of prefill/decode pod pairs broken out by the closest topology domain (node, rack, zone or remote) the pods share.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"locality"},
+	)
+
+	zoneRoutingCounter = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      EPPComponent,
+			Name:           "zone_routing_total",
+			Help:           "Counter of requests broken out by the topology zone of the gateway and of the pod they were routed to.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"gateway_zone", "pod_zone"},
+	)
+
+	decisionRecordsCounter = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      EPPComponent,
+			Name:           "decision_records_total",
+			Help:           "Counter of sampled scheduling decision records broken out by outcome (written, dropped or failed).",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"outcome"},
+	)
+
+	shadowDecisionsCounter = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      EPPComponent,
+			Name:           "shadow_decisions_total",
+			Help:           "Counter of the scheduling cycles evaluated by the shadow scheduler broken out by the role of the pod picked by the scheduler and by whether the shadow scheduler picked the same pod.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"role", "agreement"},
+	)
+
+	shadowPodDecisionsCounter = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      EPPComponent,
+			Name:           "shadow_pod_decisions_total",
+			Help:           "Counter of the pods picked in the scheduling cycles evaluated by the shadow scheduler broken out by scheduler (primary or shadow), pod and role.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"scheduler", "pod", "role"},
+	)
 )
 
 var registerMetrics sync.Once
@@ -211,8 +400,26 @@ func Register() {
 		legacyregistry.MustRegister(inferencePoolAvgKVCache)
 		legacyregistry.MustRegister(inferencePoolAvgQueueSize)
 		legacyregistry.MustRegister(inferencePoolReadyPods)
+		legacyregistry.MustRegister(inferencePoolRolePods)
 
 		legacyregistry.MustRegister(SchedulerPluginProcessingLatencies)
+		legacyregistry.MustRegister(latencyPredictorCoefficients)
+		legacyregistry.MustRegister(latencyPredictorSamples)
+		legacyregistry.MustRegister(pdPairingCounter)
+		legacyregistry.MustRegister(zoneRoutingCounter)
+		legacyregistry.MustRegister(decisionRecordsCounter)
+		legacyregistry.MustRegister(shadowDecisionsCounter)
+		legacyregistry.MustRegister(shadowPodDecisionsCounter)
+		legacyregistry.MustRegister(experimentRequestCounter)
+		legacyregistry.MustRegister(experimentRequestLatencies)
+		legacyregistry.MustRegister(experimentTTFT)
+		legacyregistry.MustRegister(experimentTPOT)
+		legacyregistry.MustRegister(experimentInputTokens)
+		legacyregistry.MustRegister(experimentOutputTokens)
+
+		legacyregistry.MustRegister(apiRequestCounter)
+		legacyregistry.MustRegister(apiRequestBatchSizes)
+		legacyregistry.MustRegister(apiRequestLatencies)
 	})
 }
 
@@ -311,7 +518,98 @@ func RecordinferencePoolReadyPods(name string, runningPods float64) {
 	inferencePoolReadyPods.WithLabelValues(name).Set(runningPods)
 }
 
+// RecordInferencePoolRolePods records the number of ready pods of the pool with the given role.
+func RecordInferencePoolRolePods(name, role string, pods float64) {
+	inferencePoolRolePods.WithLabelValues(name, role).Set(pods)
+}
+
 // RecordSchedulerPluginProcessingLatency records the processing latency for a scheduler plugin.
 func RecordSchedulerPluginProcessingLatency(pluginType, pluginName string, duration time.Duration) {
 	SchedulerPluginProcessingLatencies.WithLabelValues(pluginType, pluginName).Observe(duration.Seconds())
 }
+
+// RecordLatencyPredictorModel records the coefficients of a latency regression model and the number of
+// observations it was fitted on.
+func RecordLatencyPredictorModel(podName, latency string, features []string, coefficients []float64, samples int) {
+	for i, feature := range features {
+		latencyPredictorCoefficients.WithLabelValues(podName, latency, feature).Set(coefficients[i])
+	}
+	latencyPredictorSamples.WithLabelValues(podName, latency).Set(float64(samples))
+}
+
+// DeleteLatencyPredictorModel deletes the metrics of a latency regression model, e.g., once its pod is gone.
+func DeleteLatencyPredictorModel(podName, latency string, features []string) {
+	for _, feature := range features {
+		latencyPredictorCoefficients.Delete(map[string]string{"pod_name": podName, "latency": latency, "feature": feature})
+	}
+	latencyPredictorSamples.Delete(map[string]string{"pod_name": podName, "latency": latency})
+}
+
+// RecordPDPairing counts a prefill/decode pod pair by the closest topology domain the pods share.
+func RecordPDPairing(locality string) {
+	pdPairingCounter.WithLabelValues(locality).Inc()
+}
+
+// RecordZoneRouting counts a request by the topology zone of the gateway and of the pod it was routed to.
+func RecordZoneRouting(gatewayZone, podZone string) {
+	zoneRoutingCounter.WithLabelValues(gatewayZone, podZone).Inc()
+}
+
+// RecordDecisionRecord counts a sampled scheduling decision record by its outcome.
+func RecordDecisionRecord(outcome string) {
+	decisionRecordsCounter.WithLabelValues(outcome).Inc()
+}
+
+// RecordShadowDecision counts a scheduling cycle evaluated by the shadow scheduler by the role of the
+// pod picked by the scheduler and by whether the shadow scheduler picked the same pod.
+func RecordShadowDecision(role string, agreed bool) {
+	agreement := "disagree"
+	if agreed {
+		agreement = "agree"
+	}
+	shadowDecisionsCounter.WithLabelValues(role, agreement).Inc()
+}
+
+// RecordShadowPodDecision counts a pod picked by the primary or shadow scheduler.
+func RecordShadowPodDecision(scheduler, pod, role string) {
+	shadowPodDecisionsCounter.WithLabelValues(scheduler, pod, role).Inc()
+}
+
+// RecordExperimentRequest counts a request assigned to an experiment arm.
+func RecordExperimentRequest(modelName, arm string) {
+	experimentRequestCounter.WithLabelValues(modelName, arm).Inc()
+}
+
+// RecordExperimentResponse records the latencies and token counts of a completed response of a
+// request assigned to an experiment arm. The TTFT and TPOT are only recorded if positive, i.e.,
+// for streamed responses.
+func RecordExperimentResponse(modelName, arm string, e2e, ttft, tpot time.Duration, inputTokens, outputTokens int) {
+	experimentRequestLatencies.WithLabelValues(modelName, arm).Observe(e2e.Seconds())
+	if ttft > 0 {
+		experimentTTFT.WithLabelValues(modelName, arm).Observe(ttft.Seconds())
+	}
+	if tpot > 0 {
+		experimentTPOT.WithLabelValues(modelName, arm).Observe(tpot.Seconds())
+	}
+	if inputTokens > 0 {
+		experimentInputTokens.WithLabelValues(modelName, arm).Observe(float64(inputTokens))
+	}
+	if outputTokens > 0 {
+		experimentOutputTokens.WithLabelValues(modelName, arm).Observe(float64(outputTokens))
+	}
+}
+
+// RecordAPIRequest records a request of the given API type, and the number of inputs of batched
+// requests, zero otherwise.
+func RecordAPIRequest(modelName, apiType string, batchSize int) {
+	apiRequestCounter.WithLabelValues(modelName, apiType).Inc()
+	if batchSize > 0 {
+		apiRequestBatchSizes.WithLabelValues(modelName, apiType).Observe(float64(batchSize))
+	}
+}
+
+// RecordAPIResponse records the end-to-end latency of a successfully completed response of the given
+// API type.
+func RecordAPIResponse(modelName, apiType string, e2e time.Duration) {
+	apiRequestLatencies.WithLabelValues(modelName, apiType).Observe(e2e.Seconds())
+}
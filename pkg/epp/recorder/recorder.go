@@ -0,0 +1,342 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package recorder records sampled scheduling decisions of the EPP to a local file, along with what
+// the scheduler saw, and replays them against another scheduler configuration.
+package recorder
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+const (
+	DefaultSampleRate  = 0.01
+	DefaultMaxFileSize = 100 << 20
+	DefaultMaxBackups  = 5
+	DefaultBlockSize   = 64
+
+	// recordQueueSize is the number of records waiting to be written beyond which records are
+	// dropped, rather than slowing down scheduling.
+	recordQueueSize = 1024
+)
+
+// Config contains the configuration of a Recorder.
+type Config struct {
+	// Path is the file the records are appended to, one JSON record per line.
+	Path string
+	// SampleRate is the fraction of the requests recorded, in (0, 1].
+	SampleRate float64
+	// MaxFileSize is the size in bytes beyond which the file is rotated, zero for no rotation.
+	MaxFileSize int64
+	// MaxBackups is the number of rotated files kept, named Path.1 (the newest) to Path.MaxBackups.
+	MaxBackups int
+	// Headers is the allow-list of request headers recorded, case insensitive. Other headers are
+	// never recorded.
+	Headers []string
+	// BlockSize is the number of characters of the hashed prompt blocks.
+	BlockSize int
+}
+
+// DefaultConfig returns a Config instance with default configuration, writing to the given path.
+func DefaultConfig(path string) *Config {
+	return &Config{
+		Path:        path,
+		SampleRate:  DefaultSampleRate,
+		MaxFileSize: DefaultMaxFileSize,
+		MaxBackups:  DefaultMaxBackups,
+		BlockSize:   DefaultBlockSize,
+	}
+}
+
+// Record is a sampled scheduling decision, with the request and pods the scheduler saw.
+type Record struct {
+	Time    time.Time      `json:"time"`
+	Request RequestSummary `json:"request"`
+	// TargetPort is the target port of the pool, used to resolve the urls of the pods.
+	TargetPort int32         `json:"targetPort"`
+	Pods       []PodSnapshot `json:"pods"`
+	Decision   Decision      `json:"decision"`
+}
+
+// RequestSummary summarizes a request without its prompt, which is only recorded as hashes.
+type RequestSummary struct {
+	RequestID           string `json:"requestID,omitempty"`
+	Model               string `json:"model"`
+	ResolvedTargetModel string `json:"resolvedTargetModel"`
+	Critical            bool   `json:"critical,omitempty"`
+	Sheddable           bool   `json:"sheddable,omitempty"`
+	MaxTokens           int    `json:"maxTokens,omitempty"`
+	// PromptLength is the length in characters of the prompt.
+	PromptLength int `json:"promptLength"`
+	// PromptBlocks are the chained hashes of the full blocks of PromptBlockSize characters of the
+	// prompt, so that requests sharing a prefix share the hashes of its blocks.
+	PromptBlockSize int      `json:"promptBlockSize"`
+	PromptBlocks    []uint64 `json:"promptBlocks,omitempty"`
+	// PromptHash is the hash of the whole prompt.
+	PromptHash    uint64            `json:"promptHash"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	TTFTObjective time.Duration     `json:"ttftObjective,omitempty"`
+	TPOTObjective time.Duration     `json:"tpotObjective,omitempty"`
+	GatewayZone   string            `json:"gatewayZone,omitempty"`
+	// ExperimentArm is the arm of the experiment the request was assigned to, if any.
+	ExperimentArm string `json:"experimentArm,omitempty"`
+}
+
+// PodSnapshot is a pod and its metrics, as seen by the scheduler.
+type PodSnapshot struct {
+	// Name is the namespaced name of the pod.
+	Name        string                  `json:"name"`
+	Address     string                  `json:"address"`
+	Role        string                  `json:"role"`
+	Capacity    float64                 `json:"capacity,omitempty"`
+	MaxModelLen int                     `json:"maxModelLen,omitempty"`
+	NodeName    string                  `json:"nodeName,omitempty"`
+	Zone        string                  `json:"zone,omitempty"`
+	Rack        string                  `json:"rack,omitempty"`
+	Metrics     *backendmetrics.Metrics `json:"metrics"`
+}
+
+// Decision is the outcome of the scheduling of a request, pods being named by their namespaced name.
+type Decision struct {
+	TargetPod  string `json:"targetPod,omitempty"`
+	PrefillPod string `json:"prefillPod,omitempty"`
+	EncodePod  string `json:"encodePod,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Scheduler is the scheduler of the EPP, e.g., scheduling.Scheduler or scheduling.PDScheduler.
+type Scheduler interface {
+	Schedule(ctx context.Context, req *types.LLMRequest) (*types.Result, error)
+	RunPostResponsePlugins(ctx context.Context, req *types.LLMRequest, targetPodName string) (*types.Result, error)
+	RunPostCompletionPlugins(ctx context.Context, req *types.LLMRequest, targetPodName string, latency *types.ResponseLatency) error
+}
+
+// Recorder writes sampled scheduling decisions to a rotating file. Records are written in the
+// background, and dropped if the writes fall behind.
+type Recorder struct {
+	config  *Config
+	headers map[string]bool
+	records chan *Record
+	// done is closed once the pending records were written and the file closed.
+	done chan struct{}
+}
+
+// NewRecorder creates a Recorder with the given configuration. The file is closed once the given
+// context is cancelled.
+func NewRecorder(ctx context.Context, config *Config) (*Recorder, error) {
+	if config.Path == "" {
+		return nil, errors.New("the path of the records is required")
+	}
+	if config.SampleRate <= 0 || config.SampleRate > 1 {
+		return nil, fmt.Errorf("sample rate %v is not in (0, 1]", config.SampleRate)
+	}
+	if config.BlockSize <= 0 {
+		return nil, errors.New("the prompt block size must be positive")
+	}
+	file, err := openRotatingFile(config.Path, config.MaxFileSize, config.MaxBackups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the records file - %w", err)
+	}
+
+	r := &Recorder{
+		config:  config,
+		headers: map[string]bool{},
+		records: make(chan *Record, recordQueueSize),
+		done:    make(chan struct{}),
+	}
+	for _, header := range config.Headers {
+		r.headers[strings.ToLower(header)] = true
+	}
+	go r.write(ctx, file)
+	return r, nil
+}
+
+func (r *Recorder) write(ctx context.Context, file *rotatingFile) {
+	logger := log.FromContext(ctx).WithName("recorder")
+	defer close(r.done)
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Error(err, "Failed to close the records file")
+		}
+	}()
+
+	encoder := json.NewEncoder(file)
+	write := func(record *Record) {
+		if err := encoder.Encode(record); err != nil {
+			logger.Error(err, "Failed to write a scheduling decision record")
+			metrics.RecordDecisionRecord("failed")
+			return
+		}
+		metrics.RecordDecisionRecord("written")
+	}
+	for {
+		select {
+		case record := <-r.records:
+			write(record)
+		case <-ctx.Done():
+			for {
+				select {
+				case record := <-r.records:
+					write(record)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Wrap returns a scheduler recording the sampled decisions of the given scheduler, along with the
+// snapshot of the pods the scheduler saw.
+func (r *Recorder) Wrap(scheduler Scheduler) Scheduler {
+	return &recordingScheduler{Scheduler: scheduler, recorder: r}
+}
+
+type recordingScheduler struct {
+	Scheduler
+	recorder *Recorder
+}
+
+func (s *recordingScheduler) Schedule(ctx context.Context, req *types.LLMRequest) (*types.Result, error) {
+	if rand.Float64() >= s.recorder.config.SampleRate {
+		return s.Scheduler.Schedule(ctx, req)
+	}
+
+	// The pods are empty if the scheduler failed before taking its snapshot, e.g., without a pool.
+	var pods []types.Pod
+	targetPort := int32(0)
+	ctx = scheduling.WithPodsSnapshotObserver(ctx, func(snapshot []types.Pod, port int32) {
+		pods, targetPort = snapshot, port
+	})
+	res, err := s.Scheduler.Schedule(ctx, req)
+
+	record := s.recorder.newRecord(req, pods, targetPort, res, err)
+	select {
+	case s.recorder.records <- record:
+	default:
+		metrics.RecordDecisionRecord("dropped")
+	}
+	return res, err
+}
+
+func (r *Recorder) newRecord(req *types.LLMRequest, pods []types.Pod, targetPort int32, res *types.Result, err error) *Record {
+	record := &Record{
+		Time:       time.Now(),
+		Request:    r.summarize(req),
+		TargetPort: targetPort,
+		Pods:       make([]PodSnapshot, 0, len(pods)),
+	}
+	podsByURL := map[string]string{}
+	for _, pm := range pods {
+		pod := pm.GetPod()
+		record.Pods = append(record.Pods, PodSnapshot{
+			Name:        pod.NamespacedName.String(),
+			Address:     pod.Address,
+			Role:        pod.Role.String(),
+			Capacity:    pod.Capacity,
+			MaxModelLen: pod.MaxModelLen,
+			NodeName:    pod.NodeName,
+			Zone:        pod.Zone,
+			Rack:        pod.Rack,
+			Metrics:     pm.GetMetrics(),
+		})
+		podsByURL[podURL(pod.Address, targetPort)] = pod.NamespacedName.String()
+	}
+	record.Decision = newDecision(res, err, podsByURL)
+	return record
+}
+
+func (r *Recorder) summarize(req *types.LLMRequest) RequestSummary {
+	prompt := req.PromptText()
+	summary := RequestSummary{
+		RequestID:           req.RequestID,
+		Model:               req.Model,
+		ResolvedTargetModel: req.ResolvedTargetModel,
+		Critical:            req.Critical,
+		Sheddable:           req.Sheddable,
+		MaxTokens:           req.MaxTokens,
+		PromptLength:        len(prompt),
+		PromptBlockSize:     r.config.BlockSize,
+		PromptBlocks:        promptBlocks(prompt, r.config.BlockSize),
+		PromptHash:          xxhash.Sum64String(prompt),
+		TTFTObjective:       req.TTFTObjective,
+		TPOTObjective:       req.TPOTObjective,
+		GatewayZone:         req.GatewayZone,
+		ExperimentArm:       req.ExperimentArm,
+	}
+	for name, value := range req.Headers {
+		if r.headers[strings.ToLower(name)] {
+			if summary.Headers == nil {
+				summary.Headers = map[string]string{}
+			}
+			summary.Headers[name] = value
+		}
+	}
+	return summary
+}
+
+// newDecision summarizes the result of a scheduling, resolving the urls of the prefill and encode
+// pods set in the headers to pod names.
+func newDecision(res *types.Result, err error, podsByURL map[string]string) Decision {
+	if err != nil {
+		return Decision{Error: err.Error()}
+	}
+	decision := Decision{}
+	if res == nil || res.TargetPod == nil {
+		return decision
+	}
+	decision.TargetPod = res.TargetPod.GetPod().NamespacedName.String()
+	if url, ok := res.MutatedHeaders[scheduling.PrefillPodHeader]; ok {
+		decision.PrefillPod = podsByURL[url]
+	}
+	if url, ok := res.MutatedHeaders[scheduling.EncodePodHeader]; ok {
+		decision.EncodePod = podsByURL[url]
+	}
+	return decision
+}
+
+func podURL(address string, targetPort int32) string {
+	return fmt.Sprintf("http://%s:%d", address, targetPort)
+}
+
+// promptBlocks returns the chained hashes of the full blocks of the prompt.
+func promptBlocks(prompt string, blockSize int) []uint64 {
+	blocks := []uint64{}
+	previous := uint64(0)
+	digest := xxhash.New()
+	for start := 0; start+blockSize <= len(prompt); start += blockSize {
+		digest.Reset()
+		_ = binary.Write(digest, binary.LittleEndian, previous)
+		_, _ = digest.WriteString(prompt[start : start+blockSize])
+		previous = digest.Sum64()
+		blocks = append(blocks, previous)
+	}
+	return blocks
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recorder
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// fakeDatastore serves fixed pods.
+type fakeDatastore struct {
+	pods []backendmetrics.PodMetrics
+}
+
+func (d *fakeDatastore) PoolGet() (*v1alpha2.InferencePool, error) {
+	return &v1alpha2.InferencePool{Spec: v1alpha2.InferencePoolSpec{TargetPortNumber: 8000}}, nil
+}
+
+func (d *fakeDatastore) PodGetAll() []backendmetrics.PodMetrics {
+	return d.pods
+}
+
+// leastQueueScheduler routes requests to the pod of the datastore with the shortest waiting queue,
+// and disaggregates them on the prefill pod, if any.
+type leastQueueScheduler struct {
+	datastore scheduling.Datastore
+	err       error
+	// errBeforeSnapshot fails the scheduling before the pods snapshot is taken.
+	errBeforeSnapshot bool
+}
+
+func (s *leastQueueScheduler) Schedule(ctx context.Context, req *types.LLMRequest) (*types.Result, error) {
+	req.ExperimentArm = "candidate"
+	if s.err != nil && s.errBeforeSnapshot {
+		return nil, s.err
+	}
+	pool, _ := s.datastore.PoolGet()
+	pods := types.ToSchedulerPodMetrics(s.datastore.PodGetAll())
+	scheduling.NotifyPodsSnapshot(ctx, pods, pool.Spec.TargetPortNumber)
+	if s.err != nil {
+		return nil, s.err
+	}
+	res := &types.Result{MutatedHeaders: map[string]string{}}
+	for _, pm := range pods {
+		if pm.GetPod().Role == backendmetrics.Prefill {
+			res.MutatedHeaders[scheduling.PrefillPodHeader] = podURL(pm.GetPod().Address, pool.Spec.TargetPortNumber)
+			continue
+		}
+		if res.TargetPod == nil || pm.GetMetrics().WaitingQueueSize < res.TargetPod.GetMetrics().WaitingQueueSize {
+			res.TargetPod = &types.PodMetrics{Pod: pm.GetPod(), Metrics: pm.GetMetrics()}
+		}
+	}
+	return res, nil
+}
+
+func (s *leastQueueScheduler) RunPostResponsePlugins(context.Context, *types.LLMRequest, string) (*types.Result, error) {
+	return &types.Result{}, nil
+}
+
+func (s *leastQueueScheduler) RunPostCompletionPlugins(context.Context, *types.LLMRequest, string, *types.ResponseLatency) error {
+	return nil
+}
+
+func (s *leastQueueScheduler) Plugins() []plugins.Plugin {
+	return nil
+}
+
+func newPod(name, address string, role backendmetrics.PodRole, waiting int) backendmetrics.PodMetrics {
+	return &backendmetrics.FakePodMetrics{
+		Pod: &backendmetrics.Pod{
+			NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: name},
+			Address:        address,
+			Role:           role,
+		},
+		Metrics: &backendmetrics.Metrics{WaitingQueueSize: waiting},
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	datastore := &fakeDatastore{pods: []backendmetrics.PodMetrics{
+		newPod("prefill", "1.1.1.1", backendmetrics.Prefill, 0),
+		newPod("decode", "2.2.2.2", backendmetrics.Decode, 3),
+	}}
+	prompt := strings.Repeat("a", 10) + "b"
+
+	tests := []struct {
+		name              string
+		schedulerErr      error
+		errBeforeSnapshot bool
+		want              Record
+	}{
+		{
+			name: "disaggregated request",
+			want: Record{
+				Request: RequestSummary{
+					RequestID:       "req-1",
+					Model:           "my-model",
+					Critical:        true,
+					PromptLength:    11,
+					PromptBlockSize: 4,
+					PromptBlocks:    promptBlocks(prompt, 4),
+					PromptHash:      xxhash.Sum64String(prompt),
+					Headers:         map[string]string{"X-Tenant": "team-a"},
+					ExperimentArm:   "candidate",
+				},
+				TargetPort: 8000,
+				Pods: []PodSnapshot{
+					{Name: "default/prefill", Address: "1.1.1.1", Role: "prefill", Metrics: &backendmetrics.Metrics{}},
+					{Name: "default/decode", Address: "2.2.2.2", Role: "decode", Metrics: &backendmetrics.Metrics{WaitingQueueSize: 3}},
+				},
+				Decision: Decision{TargetPod: "default/decode", PrefillPod: "default/prefill"},
+			},
+		},
+		{
+			name:         "scheduling error",
+			schedulerErr: errors.New("no pods available"),
+			want: Record{
+				Request: RequestSummary{
+					RequestID:       "req-1",
+					Model:           "my-model",
+					Critical:        true,
+					PromptLength:    11,
+					PromptBlockSize: 4,
+					PromptBlocks:    promptBlocks(prompt, 4),
+					PromptHash:      xxhash.Sum64String(prompt),
+					Headers:         map[string]string{"X-Tenant": "team-a"},
+					ExperimentArm:   "candidate",
+				},
+				TargetPort: 8000,
+				Pods: []PodSnapshot{
+					{Name: "default/prefill", Address: "1.1.1.1", Role: "prefill", Metrics: &backendmetrics.Metrics{}},
+					{Name: "default/decode", Address: "2.2.2.2", Role: "decode", Metrics: &backendmetrics.Metrics{WaitingQueueSize: 3}},
+				},
+				Decision: Decision{Error: "no pods available"},
+			},
+		},
+		{
+			name:              "scheduling error before the snapshot",
+			schedulerErr:      errors.New("no pool"),
+			errBeforeSnapshot: true,
+			want: Record{
+				Request: RequestSummary{
+					RequestID:       "req-1",
+					Model:           "my-model",
+					Critical:        true,
+					PromptLength:    11,
+					PromptBlockSize: 4,
+					PromptBlocks:    promptBlocks(prompt, 4),
+					PromptHash:      xxhash.Sum64String(prompt),
+					Headers:         map[string]string{"X-Tenant": "team-a"},
+					ExperimentArm:   "candidate",
+				},
+				Pods:     []PodSnapshot{},
+				Decision: Decision{Error: "no pool"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "records.jsonl")
+			config := DefaultConfig(path)
+			config.SampleRate = 1
+			config.BlockSize = 4
+			config.Headers = []string{"x-tenant"}
+			ctx, cancel := context.WithCancel(context.Background())
+			recorder, err := NewRecorder(ctx, config)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			scheduler := recorder.Wrap(&leastQueueScheduler{datastore: datastore, err: test.schedulerErr, errBeforeSnapshot: test.errBeforeSnapshot})
+			_, _ = scheduler.Schedule(ctx, &types.LLMRequest{
+				RequestID: "req-1",
+				Model:     "my-model",
+				Prompt:    prompt,
+				Critical:  true,
+				Headers:   map[string]string{"X-Tenant": "team-a", "Authorization": "Bearer secret"},
+			})
+			cancel()
+			<-recorder.done
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer func() { _ = file.Close() }()
+			records, err := ReadRecords(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff([]*Record{&test.want}, records, cmpopts.IgnoreFields(Record{}, "Time"), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Unexpected records (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.jsonl")
+	file, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got := map[string]string{}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, entry := range entries {
+		content, _ := os.ReadFile(filepath.Join(filepath.Dir(path), entry.Name()))
+		got[entry.Name()] = string(content)
+	}
+	want := map[string]string{
+		"records.jsonl":   "fourth\n",
+		"records.jsonl.1": "third\n",
+		"records.jsonl.2": "second\n",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected files (-want +got): %v", diff)
+	}
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// ReplayScheduler is the scheduler the records are replayed against.
+type ReplayScheduler interface {
+	Scheduler
+	Plugins() []plugins.Plugin
+}
+
+// ReadRecords reads the records written by a Recorder, one JSON record per line.
+func ReadRecords(r io.Reader) ([]*Record, error) {
+	records := []*Record{}
+	decoder := json.NewDecoder(r)
+	for {
+		record := &Record{}
+		if err := decoder.Decode(record); errors.Is(err, io.EOF) {
+			return records, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid record %d - %w", len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+}
+
+// ReplayReport compares the recorded decisions to the replayed ones.
+type ReplayReport struct {
+	Records   int `json:"records"`
+	Unchanged int `json:"unchanged"`
+	Changed   int `json:"changed"`
+	// Diffs are the records whose decision changed, in the order of the records.
+	Diffs []DecisionDiff `json:"diffs"`
+	// Pods counts the requests routed to every pod, as recorded and replayed.
+	Pods []PodDecisions `json:"pods"`
+}
+
+// DecisionDiff is a decision changed by the replay.
+type DecisionDiff struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"requestID,omitempty"`
+	Model     string    `json:"model"`
+	Recorded  Decision  `json:"recorded"`
+	Replayed  Decision  `json:"replayed"`
+}
+
+// PodDecisions counts the requests routed to a pod.
+type PodDecisions struct {
+	Name     string `json:"name"`
+	Recorded int    `json:"recorded"`
+	Replayed int    `json:"replayed"`
+}
+
+// Replay reruns the records, in the order of their time, through the scheduler created by the given
+// function for a datastore serving the pods of every record in turn, and diffs the decisions. If
+// the function is nil, the scheduler of the EPP is used, as configured by the environment.
+//
+// The prompts are replaced by synthetic prompts of the same length, sharing the same prefixes as the
+// recorded prompts, at the granularity of the recorded blocks. Session tokens are not recorded, so
+// session affinity is not replayed.
+func Replay(ctx context.Context, records []*Record, newScheduler func(datastore scheduling.Datastore) ReplayScheduler) (*ReplayReport, error) {
+	ds := &replayDatastore{}
+	var scheduler ReplayScheduler
+	if newScheduler != nil {
+		scheduler = newScheduler(ds)
+	} else if scheduling.PDEnabled {
+		scheduler = scheduling.NewPDScheduler(ds)
+	} else {
+		scheduler = scheduling.NewScheduler(ds)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := scheduling.StartPlugins(ctx, ds, scheduler.Plugins()); err != nil {
+		return nil, err
+	}
+
+	records = slices.Clone(records)
+	slices.SortStableFunc(records, func(a, b *Record) int { return a.Time.Compare(b.Time) })
+	report := &ReplayReport{Records: len(records), Diffs: []DecisionDiff{}, Pods: []PodDecisions{}}
+	pods := map[string]*PodDecisions{}
+	count := func(name string, replayed bool) {
+		if name == "" {
+			return
+		}
+		if pods[name] == nil {
+			pods[name] = &PodDecisions{Name: name}
+		}
+		if replayed {
+			pods[name].Replayed++
+		} else {
+			pods[name].Recorded++
+		}
+	}
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		podsByURL := ds.set(record)
+		res, err := scheduler.Schedule(ctx, record.Request.llmRequest())
+		replayed := newDecision(res, err, podsByURL)
+
+		count(record.Decision.TargetPod, false)
+		count(replayed.TargetPod, true)
+		if replayed == record.Decision {
+			report.Unchanged++
+			continue
+		}
+		report.Changed++
+		report.Diffs = append(report.Diffs, DecisionDiff{
+			Time:      record.Time,
+			RequestID: record.Request.RequestID,
+			Model:     record.Request.Model,
+			Recorded:  record.Decision,
+			Replayed:  replayed,
+		})
+	}
+	for _, name := range slices.Sorted(maps.Keys(pods)) {
+		report.Pods = append(report.Pods, *pods[name])
+	}
+	return report, nil
+}
+
+// llmRequest rebuilds the request, with a synthetic prompt.
+func (s *RequestSummary) llmRequest() *types.LLMRequest {
+	headers := maps.Clone(s.Headers)
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	return &types.LLMRequest{
+		Model:               s.Model,
+		Prompt:              s.syntheticPrompt(),
+		Headers:             headers,
+		ResolvedTargetModel: s.ResolvedTargetModel,
+		Critical:            s.Critical,
+		RequestID:           s.RequestID,
+		Sheddable:           s.Sheddable,
+		MaxTokens:           s.MaxTokens,
+		TTFTObjective:       s.TTFTObjective,
+		TPOTObjective:       s.TPOTObjective,
+		GatewayZone:         s.GatewayZone,
+	}
+}
+
+// syntheticPrompt generates a prompt of the recorded length, whose blocks are derived from the
+// recorded hashes. Prompts sharing recorded blocks share the same prefix, and the characters beyond
+// the last block are derived from the hash of the whole prompt.
+func (s *RequestSummary) syntheticPrompt() string {
+	var b strings.Builder
+	for _, block := range s.PromptBlocks {
+		b.WriteString(fill(block, s.PromptBlockSize))
+	}
+	if tail := s.PromptLength - b.Len(); tail > 0 {
+		b.WriteString(fill(s.PromptHash, tail))
+	}
+	return b.String()
+}
+
+func fill(hash uint64, length int) string {
+	word := fmt.Sprintf("%016x", hash)
+	return strings.Repeat(word, length/len(word)+1)[:length]
+}
+
+// replayDatastore serves the pods of the replayed record to the scheduler, and publishes the pods
+// added and removed between records to the plugins.
+type replayDatastore struct {
+	record   *Record
+	pods     []backendmetrics.PodMetrics
+	handlers []datastore.EventHandler
+}
+
+// set switches to the pods of the given record, returning the pod names by url.
+func (d *replayDatastore) set(record *Record) map[string]string {
+	previous := map[string]*backendmetrics.Pod{}
+	for _, pm := range d.pods {
+		previous[pm.GetPod().NamespacedName.String()] = pm.GetPod()
+	}
+
+	d.record = record
+	d.pods = make([]backendmetrics.PodMetrics, 0, len(record.Pods))
+	podsByURL := map[string]string{}
+	for _, snapshot := range record.Pods {
+		pod := snapshot.pod()
+		d.pods = append(d.pods, &backendmetrics.FakePodMetrics{Pod: pod, Metrics: snapshot.metrics()})
+		podsByURL[podURL(pod.Address, record.TargetPort)] = snapshot.Name
+		if _, ok := previous[snapshot.Name]; ok {
+			delete(previous, snapshot.Name)
+		} else {
+			d.publish(datastore.Event{Type: datastore.PodAdded, Pod: pod})
+		}
+	}
+	for _, pod := range previous {
+		d.publish(datastore.Event{Type: datastore.PodRemoved, Pod: pod})
+	}
+	return podsByURL
+}
+
+func (d *replayDatastore) publish(event datastore.Event) {
+	for _, handler := range d.handlers {
+		handler(event)
+	}
+}
+
+// PoolGet implements the scheduling.Datastore interface.
+func (d *replayDatastore) PoolGet() (*v1alpha2.InferencePool, error) {
+	targetPort := int32(0)
+	if d.record != nil {
+		targetPort = d.record.TargetPort
+	}
+	return &v1alpha2.InferencePool{Spec: v1alpha2.InferencePoolSpec{TargetPortNumber: targetPort}}, nil
+}
+
+// PodGetAll implements the scheduling.Datastore interface.
+func (d *replayDatastore) PodGetAll() []backendmetrics.PodMetrics {
+	return d.pods
+}
+
+// Subscribe implements the scheduling.EventSource interface. Events are delivered synchronously,
+// as the records are replayed.
+func (d *replayDatastore) Subscribe(_ context.Context, handler datastore.EventHandler) func() {
+	d.handlers = append(d.handlers, handler)
+	return func() {}
+}
+
+func (s *PodSnapshot) pod() *backendmetrics.Pod {
+	name := k8stypes.NamespacedName{Name: s.Name}
+	if namespace, podName, ok := strings.Cut(s.Name, "/"); ok {
+		name = k8stypes.NamespacedName{Namespace: namespace, Name: podName}
+	}
+	role := backendmetrics.Unknown
+	for _, r := range backendmetrics.PodRoles {
+		if r.String() == s.Role {
+			role = r
+		}
+	}
+	return &backendmetrics.Pod{
+		NamespacedName: name,
+		Address:        s.Address,
+		Role:           role,
+		Capacity:       s.Capacity,
+		MaxModelLen:    s.MaxModelLen,
+		NodeName:       s.NodeName,
+		Zone:           s.Zone,
+		Rack:           s.Rack,
+	}
+}
+
+func (s *PodSnapshot) metrics() *backendmetrics.Metrics {
+	if s.Metrics == nil {
+		return &backendmetrics.Metrics{ActiveModels: map[string]int{}, WaitingModels: map[string]int{}}
+	}
+	return s.Metrics
+}
+
+// WriteText writes the report as human readable text, listing at most maxDiffs changed decisions.
+func (r *ReplayReport) WriteText(w io.Writer, maxDiffs int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Records:\t%d (unchanged %d, changed %d)\n", r.Records, r.Unchanged, r.Changed)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "Pod\trecorded\treplayed")
+	for _, pod := range r.Pods {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", pod.Name, pod.Recorded, pod.Replayed)
+	}
+	if len(r.Diffs) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "Time\trequest\tmodel\trecorded\treplayed")
+		for _, diff := range r.Diffs[:min(len(r.Diffs), maxDiffs)] {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", diff.Time.Format(time.RFC3339Nano), diff.RequestID, diff.Model, diff.Recorded, diff.Replayed)
+		}
+		if len(r.Diffs) > maxDiffs {
+			fmt.Fprintf(tw, "... %d more\n", len(r.Diffs)-maxDiffs)
+		}
+	}
+	return tw.Flush()
+}
+
+// String describes the decision, e.g., "prefill-0 -> decode-1" for a disaggregated request.
+func (d Decision) String() string {
+	if d.Error != "" {
+		return "error: " + d.Error
+	}
+	if d.TargetPod == "" {
+		return "none"
+	}
+	s := d.TargetPod
+	if d.PrefillPod != "" && d.PrefillPod != d.TargetPod {
+		s = d.PrefillPod + " -> " + s
+	}
+	if d.EncodePod != "" {
+		s = d.EncodePod + " -> " + s
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recorder
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestReplay(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []*Record{
+		{
+			Time:       start.Add(time.Second),
+			Request:    RequestSummary{RequestID: "req-2", Model: "my-model"},
+			TargetPort: 8000,
+			Pods: []PodSnapshot{
+				{Name: "default/pod-a", Address: "1.1.1.1", Role: "both", Metrics: &backendmetrics.Metrics{WaitingQueueSize: 5}},
+				{Name: "default/pod-b", Address: "2.2.2.2", Role: "both", Metrics: &backendmetrics.Metrics{WaitingQueueSize: 1}},
+			},
+			Decision: Decision{TargetPod: "default/pod-a"},
+		},
+		{
+			Time:       start,
+			Request:    RequestSummary{RequestID: "req-1", Model: "my-model"},
+			TargetPort: 8000,
+			Pods: []PodSnapshot{
+				{Name: "default/pod-a", Address: "1.1.1.1", Role: "both", Metrics: &backendmetrics.Metrics{WaitingQueueSize: 0}},
+				{Name: "default/pod-b", Address: "2.2.2.2", Role: "both", Metrics: &backendmetrics.Metrics{WaitingQueueSize: 1}},
+			},
+			Decision: Decision{TargetPod: "default/pod-a"},
+		},
+	}
+
+	report, err := Replay(context.Background(), records, func(datastore scheduling.Datastore) ReplayScheduler {
+		return &leastQueueScheduler{datastore: datastore}
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := &ReplayReport{
+		Records:   2,
+		Unchanged: 1,
+		Changed:   1,
+		Diffs: []DecisionDiff{{
+			Time:      start.Add(time.Second),
+			RequestID: "req-2",
+			Model:     "my-model",
+			Recorded:  Decision{TargetPod: "default/pod-a"},
+			Replayed:  Decision{TargetPod: "default/pod-b"},
+		}},
+		Pods: []PodDecisions{
+			{Name: "default/pod-a", Recorded: 2, Replayed: 1},
+			{Name: "default/pod-b", Recorded: 0, Replayed: 1},
+		},
+	}
+	if diff := cmp.Diff(want, report); diff != "" {
+		t.Errorf("Unexpected report (-want +got): %v", diff)
+	}
+}
+
+func TestSyntheticPrompt(t *testing.T) {
+	recorder := &Recorder{config: &Config{BlockSize: 16}}
+	shared := strings.Repeat("You are a helpful assistant. ", 2)
+	first := recorder.summarize(&types.LLMRequest{Prompt: shared + "What is the capital of France?"})
+	second := recorder.summarize(&types.LLMRequest{Prompt: shared + "Who wrote Hamlet?"})
+
+	firstPrompt, secondPrompt := first.syntheticPrompt(), second.syntheticPrompt()
+	if len(firstPrompt) != first.PromptLength || len(secondPrompt) != second.PromptLength {
+		t.Errorf("Unexpected synthetic prompt lengths, want %d and %d, got %d and %d",
+			first.PromptLength, second.PromptLength, len(firstPrompt), len(secondPrompt))
+	}
+	sharedLength := len(shared) / 16 * 16
+	if firstPrompt[:sharedLength] != secondPrompt[:sharedLength] {
+		t.Errorf("Synthetic prompts do not share the prefix of the recorded prompts")
+	}
+	if firstPrompt[:sharedLength+16] == secondPrompt[:sharedLength+16] {
+		t.Errorf("Synthetic prompts share more than the prefix of the recorded prompts")
+	}
+}
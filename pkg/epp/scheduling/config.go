@@ -17,16 +17,19 @@ limitations under the License.
 package scheduling
 
 import (
+	"errors"
+
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
 )
 
 type SchedulerConfig struct {
-	preSchedulePlugins  []plugins.PreSchedule
-	filters             []plugins.Filter
-	scorers             map[plugins.Scorer]int // map from scorer to weight
-	picker              plugins.Picker
-	postSchedulePlugins []plugins.PostSchedule
-	postResponsePlugins []plugins.PostResponse
+	preSchedulePlugins    []plugins.PreSchedule
+	filters               []plugins.Filter
+	scorers               map[plugins.Scorer]int // map from scorer to weight
+	picker                plugins.Picker
+	postSchedulePlugins   []plugins.PostSchedule
+	postResponsePlugins   []plugins.PostResponse
+	postCompletionPlugins []plugins.PostCompletion
 }
 
 var defPlugin = &defaultPlugin{}
@@ -36,10 +39,34 @@ var defPlugin = &defaultPlugin{}
 
 // For build time plugins changes, it's recommended to change the defaultConfig variable in this file.
 var defaultConfig = &SchedulerConfig{
-	preSchedulePlugins:  []plugins.PreSchedule{},
-	filters:             []plugins.Filter{defPlugin},
-	scorers:             map[plugins.Scorer]int{},
-	picker:              defPlugin,
-	postSchedulePlugins: []plugins.PostSchedule{},
-	postResponsePlugins: []plugins.PostResponse{},
+	preSchedulePlugins:    []plugins.PreSchedule{},
+	filters:               []plugins.Filter{defPlugin},
+	scorers:               map[plugins.Scorer]int{},
+	picker:                defPlugin,
+	postSchedulePlugins:   []plugins.PostSchedule{},
+	postResponsePlugins:   []plugins.PostResponse{},
+	postCompletionPlugins: []plugins.PostCompletion{},
+}
+
+// configErrors are the errors of the configuration files of the schedulers, loaded when the package
+// is initialized.
+var configErrors []error
+
+// ConfigError returns the errors of the configuration files of the schedulers, nil if none. The EPP
+// must not start with an invalid configuration, as it would silently schedule without the plugins
+// of the invalid files.
+func ConfigError() error {
+	return errors.Join(configErrors...)
+}
+
+// schedulerConfigsByName returns the configurations of the schedulers by the names used in the
+// configuration files, the empty name standing for the default scheduler.
+func schedulerConfigsByName() map[string]*SchedulerConfig {
+	return map[string]*SchedulerConfig{
+		"":        defaultConfig,
+		"default": defaultConfig,
+		"prefill": prefillConfig,
+		"decode":  decodeConfig,
+		"encode":  encodeConfig,
+	}
 }
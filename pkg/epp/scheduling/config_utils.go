@@ -27,30 +27,52 @@ import (
 )
 
 const (
-	prefillKvCacheScorerEnablementEnvVar   = "PREFILL_ENABLE_KVCACHE_AWARE_SCORER"
-	prefillLoadAwareScorerEnablementEnvVar = "PREFILL_ENABLE_LOAD_AWARE_SCORER"
-	prefillPrefixScorerEnablementEnvVar    = "PREFILL_ENABLE_PREFIX_AWARE_SCORER"
-	decodeKvCacheScorerEnablementEnvVar    = "DECODE_ENABLE_KVCACHE_AWARE_SCORER"
-	decodeLoadAwareScorerEnablementEnvVar  = "DECODE_ENABLE_LOAD_AWARE_SCORER"
-	decodePrefixScorerEnablementEnvVar     = "DECODE_ENABLE_PREFIX_AWARE_SCORER"
-
-	prefillKvCacheScorerWeightEnvVar   = "PREFILL_KVCACHE_AWARE_SCORER_WEIGHT"
-	prefillLoadAwareScorerWeightEnvVar = "PREFILL_LOAD_AWARE_SCORER_WEIGHT"
-	prefillPrefixScorerWeightEnvVar    = "PREFILL_PREFIX_AWARE_SCORER_WEIGHT"
-	decodeKvCacheScorerWeightEnvVar    = "DECODE_KVCACHE_AWARE_SCORER_WEIGHT"
-	decodeLoadAwareScorerWeightEnvVar  = "DECODE_LOAD_AWARE_SCORER_WEIGHT"
-	decodePrefixScorerWeightEnvVar     = "DECODE_PREFIX_AWARE_SCORER_WEIGHT"
-
-	pdEnabledEnvKey = "PD_ENABLED"
+	encodeLoadAwareScorerEnablementEnvVar     = "ENCODE_ENABLE_LOAD_AWARE_SCORER"
+	prefillKvCacheScorerEnablementEnvVar      = "PREFILL_ENABLE_KVCACHE_AWARE_SCORER"
+	prefillLocalKvCacheScorerEnablementEnvVar = "PREFILL_ENABLE_LOCAL_KVCACHE_AWARE_SCORER"
+	prefillLoadAwareScorerEnablementEnvVar    = "PREFILL_ENABLE_LOAD_AWARE_SCORER"
+	prefillPrefixScorerEnablementEnvVar       = "PREFILL_ENABLE_PREFIX_AWARE_SCORER"
+	decodeKvCacheScorerEnablementEnvVar       = "DECODE_ENABLE_KVCACHE_AWARE_SCORER"
+	decodeLocalKvCacheScorerEnablementEnvVar  = "DECODE_ENABLE_LOCAL_KVCACHE_AWARE_SCORER"
+	decodeLoadAwareScorerEnablementEnvVar     = "DECODE_ENABLE_LOAD_AWARE_SCORER"
+	decodePrefixScorerEnablementEnvVar        = "DECODE_ENABLE_PREFIX_AWARE_SCORER"
+	decodeTopologyScorerEnablementEnvVar      = "DECODE_ENABLE_PD_TOPOLOGY_SCORER"
+
+	encodeLoadAwareScorerWeightEnvVar     = "ENCODE_LOAD_AWARE_SCORER_WEIGHT"
+	prefillKvCacheScorerWeightEnvVar      = "PREFILL_KVCACHE_AWARE_SCORER_WEIGHT"
+	prefillLocalKvCacheScorerWeightEnvVar = "PREFILL_LOCAL_KVCACHE_AWARE_SCORER_WEIGHT"
+	prefillLoadAwareScorerWeightEnvVar    = "PREFILL_LOAD_AWARE_SCORER_WEIGHT"
+	prefillPrefixScorerWeightEnvVar       = "PREFILL_PREFIX_AWARE_SCORER_WEIGHT"
+	decodeKvCacheScorerWeightEnvVar       = "DECODE_KVCACHE_AWARE_SCORER_WEIGHT"
+	decodeLocalKvCacheScorerWeightEnvVar  = "DECODE_LOCAL_KVCACHE_AWARE_SCORER_WEIGHT"
+	decodeLoadAwareScorerWeightEnvVar     = "DECODE_LOAD_AWARE_SCORER_WEIGHT"
+	decodePrefixScorerWeightEnvVar        = "DECODE_PREFIX_AWARE_SCORER_WEIGHT"
+	decodeTopologyScorerWeightEnvVar      = "DECODE_PD_TOPOLOGY_SCORER_WEIGHT"
+
+	pdTopologySameRackScoreEnvVar = "PD_TOPOLOGY_SAME_RACK_SCORE"
+	pdTopologySameZoneScoreEnvVar = "PD_TOPOLOGY_SAME_ZONE_SCORE"
+
+	pdEnabledEnvKey       = "PD_ENABLED"
+	pdEncodeEnabledEnvKey = "PD_ENCODE_ENABLED"
 
 	pdPromptLenThresholdEnvKey  = "PD_PROMPT_LEN_THRESHOLD"
 	pdPromptLenThresholdDefault = 10
+
+	pdDeciderEnvKey                 = "PD_DECIDER"
+	pdUncachedTokensThresholdEnvKey = "PD_UNCACHED_TOKENS_THRESHOLD"
+	pdPrefillLoadRatioEnvKey        = "PD_PREFILL_LOAD_RATIO"
+)
+
+const (
+	promptLengthDeciderName = "prompt-length"
+	cacheAwareDeciderName   = "cache-aware"
 )
 
 const (
-	loadAwareScorerName    = "LoadAwareScorer"
-	kvCacheAwareScorerName = "KVCacheAwareScorer"
-	prefixAwareScorerName  = "PrefixAwareScorer"
+	loadAwareScorerName         = "LoadAwareScorer"
+	kvCacheAwareScorerName      = "KVCacheAwareScorer"
+	localKVCacheAwareScorerName = "LocalKVCacheAwareScorer"
+	prefixAwareScorerName       = "PrefixAwareScorer"
 )
 
 func addScorerByEnvironment(ctx context.Context, config *SchedulerConfig, scorerName string, scorerEnabledEnvKey string, weightEnvKey string, logger logr.Logger) {
@@ -66,7 +88,7 @@ func addScorerByEnvironment(ctx context.Context, config *SchedulerConfig, scorer
 		return
 	}
 
-	defaultConfig.scorers[scorer] = weight
+	config.scorers[scorer] = weight
 	logger.Info("Initialized scorer", "scorer", scorerName, "weight", weight)
 }
 
@@ -76,6 +98,8 @@ func createScorerByName(ctx context.Context, name string) (plugins.Scorer, error
 		return &scorer.LoadAwareScorer{}, nil
 	case kvCacheAwareScorerName:
 		return scorer.NewKVCacheAwareScorer(ctx)
+	case localKVCacheAwareScorerName:
+		return scorer.NewLocalKVCacheAwareScorer(ctx)
 	}
 	return nil, fmt.Errorf("invalid scorer type %s", name)
 }
@@ -0,0 +1,57 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+)
+
+func TestAddScorerByEnvironment(t *testing.T) {
+	t.Setenv(prefillLoadAwareScorerEnablementEnvVar, "true")
+	t.Setenv(prefillLoadAwareScorerWeightEnvVar, "3")
+
+	defaultScorers := len(defaultConfig.scorers)
+	config := &SchedulerConfig{scorers: map[plugins.Scorer]int{}}
+	addScorerByEnvironment(context.Background(), config, loadAwareScorerName,
+		prefillLoadAwareScorerEnablementEnvVar, prefillLoadAwareScorerWeightEnvVar, logr.Discard())
+
+	if len(config.scorers) != 1 {
+		t.Fatalf("Unexpected scorers, want the load aware scorer, got %v", config.scorers)
+	}
+	for scorer, weight := range config.scorers {
+		if scorer.Name() != "load-aware-scorer" || weight != 3 {
+			t.Errorf("Unexpected scorer, want the load aware scorer of weight 3, got %s of weight %d", scorer.Name(), weight)
+		}
+	}
+	// The scorers of a configuration never leak into the default configuration.
+	if got := len(defaultConfig.scorers); got != defaultScorers {
+		t.Errorf("Unexpected default scorers, want %d, got %d", defaultScorers, got)
+	}
+
+	// Disabled scorers are not added.
+	t.Setenv(prefillLoadAwareScorerEnablementEnvVar, "false")
+	config = &SchedulerConfig{scorers: map[plugins.Scorer]int{}}
+	addScorerByEnvironment(context.Background(), config, loadAwareScorerName,
+		prefillLoadAwareScorerEnablementEnvVar, prefillLoadAwareScorerWeightEnvVar, logr.Discard())
+	if len(config.scorers) != 0 {
+		t.Errorf("Unexpected scorers, want none, got %v", config.scorers)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// EventSource publishes the events of the datastore.
+type EventSource interface {
+	Subscribe(ctx context.Context, handler datastore.EventHandler) (unsubscribe func())
+}
+
+// StartPlugins initializes the given plugins, then delivers the pod events of the source to the
+// plugins handling them. Once the context is done, the plugins are unsubscribed and shut down.
+func StartPlugins(ctx context.Context, events EventSource, ps []plugins.Plugin) error {
+	logger := log.FromContext(ctx).WithName("scheduler-plugins")
+
+	initialized := []plugins.Plugin{}
+	for _, plugin := range ps {
+		if initializer, ok := plugin.(plugins.Initializer); ok {
+			if err := initializer.Init(ctx); err != nil {
+				shutdownPlugins(initialized)
+				return fmt.Errorf("failed to initialize plugin %s - %w", plugin.Name(), err)
+			}
+			logger.V(logutil.DEFAULT).Info("Initialized plugin", "plugin", plugin.Name())
+		}
+		initialized = append(initialized, plugin)
+	}
+
+	handlers := []plugins.PodEventHandler{}
+	for _, plugin := range ps {
+		if handler, ok := plugin.(plugins.PodEventHandler); ok {
+			handlers = append(handlers, handler)
+		}
+	}
+	unsubscribe := func() {}
+	if len(handlers) > 0 {
+		unsubscribe = events.Subscribe(ctx, func(event datastore.Event) {
+			if event.Pod == nil {
+				return // not a pod event
+			}
+			logger.V(logutil.DEBUG).Info("Delivering pod event", "type", event.Type, "pod", event.Pod.NamespacedName)
+			for _, handler := range handlers {
+				handler.OnPodEvent(event)
+			}
+		})
+	}
+
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+		shutdownPlugins(ps)
+	}()
+	return nil
+}
+
+func shutdownPlugins(ps []plugins.Plugin) {
+	for _, plugin := range ps {
+		if shutdowner, ok := plugin.(plugins.Shutdowner); ok {
+			shutdowner.Shutdown()
+		}
+	}
+}
+
+// Plugins returns the plugins of the scheduler, each listed once.
+func (s *Scheduler) Plugins() []plugins.Plugin {
+	ps := []plugins.Plugin{}
+	for _, plugin := range s.preSchedulePlugins {
+		ps = append(ps, plugin)
+	}
+	for _, plugin := range s.filters {
+		ps = append(ps, plugin)
+	}
+	for plugin := range s.scorers {
+		ps = append(ps, plugin)
+	}
+	if s.picker != nil {
+		ps = append(ps, s.picker)
+	}
+	for _, plugin := range s.postSchedulePlugins {
+		ps = append(ps, plugin)
+	}
+	for _, plugin := range s.postResponsePlugins {
+		ps = append(ps, plugin)
+	}
+	for _, plugin := range s.postCompletionPlugins {
+		ps = append(ps, plugin)
+	}
+	return uniquePlugins(ps)
+}
+
+// Plugins returns the plugins of all the schedulers and of the decider, each listed once.
+func (s *PDScheduler) Plugins() []plugins.Plugin {
+	ps := []plugins.Plugin{}
+	for _, stage := range s.stages {
+		ps = append(ps, stage.scheduler.Plugins()...)
+	}
+	ps = append(ps, s.decodeScheduler.Plugins()...)
+	ps = append(ps, s.defaultScheduler.Plugins()...)
+	if s.decider != nil {
+		ps = append(ps, s.decider)
+	}
+	return uniquePlugins(ps)
+}
+
+// uniquePlugins removes the duplicates of plugins shared by several extension points or schedulers,
+// e.g., a scorer that is also a post-schedule plugin.
+func uniquePlugins(ps []plugins.Plugin) []plugins.Plugin {
+	seen := map[plugins.Plugin]bool{}
+	unique := []plugins.Plugin{}
+	for _, plugin := range ps {
+		if reflect.TypeOf(plugin).Comparable() {
+			if seen[plugin] {
+				continue
+			}
+			seen[plugin] = true
+		}
+		unique = append(unique, plugin)
+	}
+	return unique
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// lifecyclePlugin is a scorer and post-schedule plugin recording its lifecycle calls.
+type lifecyclePlugin struct {
+	initErr error
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (p *lifecyclePlugin) Name() string { return "lifecycle" }
+
+func (p *lifecyclePlugin) Score(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
+	return nil
+}
+
+func (p *lifecyclePlugin) PostSchedule(ctx *types.SchedulingContext, res *types.Result) {}
+
+func (p *lifecyclePlugin) Init(ctx context.Context) error {
+	p.record("init")
+	return p.initErr
+}
+
+func (p *lifecyclePlugin) OnPodEvent(event datastore.Event) {
+	p.record(string(event.Type) + "/" + event.Pod.NamespacedName.Name)
+}
+
+func (p *lifecyclePlugin) Shutdown() {
+	p.record("shutdown")
+}
+
+func (p *lifecyclePlugin) record(call string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, call)
+}
+
+func (p *lifecyclePlugin) recorded() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string{}, p.calls...)
+}
+
+// fakeEventSource synchronously delivers its events to the subscribers.
+type fakeEventSource struct {
+	events []datastore.Event
+}
+
+func (s *fakeEventSource) Subscribe(ctx context.Context, handler datastore.EventHandler) func() {
+	for _, event := range s.events {
+		handler(event)
+	}
+	return func() {}
+}
+
+func TestStartPlugins(t *testing.T) {
+	pod := &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}
+	events := &fakeEventSource{events: []datastore.Event{
+		{Type: datastore.PodAdded, Pod: pod},
+		{Type: datastore.PoolChanged},
+		{Type: datastore.PodRemoved, Pod: pod},
+	}}
+
+	tests := []struct {
+		name      string
+		initErr   error
+		wantErr   bool
+		wantCalls []string
+	}{
+		{
+			name:      "plugin receives pod events until shutdown",
+			wantCalls: []string{"init", "PodAdded/pod1", "PodRemoved/pod1", "shutdown"},
+		},
+		{
+			name:      "plugin fails to initialize",
+			initErr:   errors.New("no state"),
+			wantErr:   true,
+			wantCalls: []string{"init"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plugin := &lifecyclePlugin{initErr: test.initErr}
+			scheduler := NewSchedulerWithConfig(&fakeDataStore{}, &SchedulerConfig{
+				scorers:             map[plugins.Scorer]int{plugin: 1},
+				postSchedulePlugins: []plugins.PostSchedule{plugin},
+			})
+			if got := scheduler.Plugins(); len(got) != 1 || got[0] != plugin {
+				t.Errorf("Unexpected plugins, want the plugin listed once, got %v", got)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			err := StartPlugins(ctx, events, scheduler.Plugins())
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Unexpected error, want %v, got %v", test.wantErr, err)
+			}
+			cancel()
+
+			deadline := time.Now().Add(time.Second)
+			for len(plugin.recorded()) < len(test.wantCalls) && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if diff := cmp.Diff(test.wantCalls, plugin.recorded()); diff != "" {
+				t.Errorf("Unexpected calls (-want +got): %v", diff)
+			}
+		})
+	}
+}
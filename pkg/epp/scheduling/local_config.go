@@ -18,8 +18,12 @@ package scheduling
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/filter"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/picker"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/scorer"
 	envutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/env"
@@ -27,33 +31,144 @@ import (
 )
 
 const (
-	kvCacheScorerEnablementEnvVar      = "ENABLE_KVCACHE_AWARE_SCORER"
-	loadAwareScorerEnablementEnvVar    = "ENABLE_LOAD_AWARE_SCORER"
-	prefixScorerEnablementEnvVar       = "ENABLE_PREFIX_AWARE_SCORER"
-	sessionAwareScorerEnablementEnvVar = "ENABLE_SESSION_AWARE_SCORER"
-	pdFilterEnablementEnvVar           = "ENABLE_PD_FILTER"
-
-	kvCacheScorerWeightEnvVar      = "KVCACHE_AWARE_SCORER_WEIGHT"
-	loadAwareScorerWeightEnvVar    = "LOAD_AWARE_SCORER_WEIGHT"
-	prefixScorerWeightEnvVar       = "PREFIX_AWARE_SCORER_WEIGHT"
-	sessionAwareScorerWeightEnvVar = "SESSION_AWARE_SCORER_WEIGHT"
+	kvCacheScorerEnablementEnvVar        = "ENABLE_KVCACHE_AWARE_SCORER"
+	localKVCacheScorerEnablementEnvVar   = "ENABLE_LOCAL_KVCACHE_AWARE_SCORER"
+	loadAwareScorerEnablementEnvVar      = "ENABLE_LOAD_AWARE_SCORER"
+	prefixScorerEnablementEnvVar         = "ENABLE_PREFIX_AWARE_SCORER"
+	sessionAwareScorerEnablementEnvVar   = "ENABLE_SESSION_AWARE_SCORER"
+	consistentHashScorerEnablementEnvVar = "ENABLE_CONSISTENT_HASH_SCORER"
+	latencyScorerEnablementEnvVar        = "ENABLE_LATENCY_PREDICTIVE_SCORER"
+	pdFilterEnablementEnvVar             = "ENABLE_PD_FILTER"
+	sloFilterEnablementEnvVar            = "ENABLE_SLO_FILTER"
+	kvHeadroomFilterEnablementEnvVar     = "ENABLE_KV_HEADROOM_FILTER"
+	kvHeadroomScorerEnablementEnvVar     = "ENABLE_KV_HEADROOM_SCORER"
+	zoneLocalityFilterEnablementEnvVar   = "ENABLE_ZONE_LOCALITY_FILTER"
+	zoneLocalityScorerEnablementEnvVar   = "ENABLE_ZONE_LOCALITY_SCORER"
+	contextLengthFilterEnablementEnvVar  = "ENABLE_CONTEXT_LENGTH_FILTER"
+
+	kvCacheScorerWeightEnvVar        = "KVCACHE_AWARE_SCORER_WEIGHT"
+	localKVCacheScorerWeightEnvVar   = "LOCAL_KVCACHE_AWARE_SCORER_WEIGHT"
+	loadAwareScorerWeightEnvVar      = "LOAD_AWARE_SCORER_WEIGHT"
+	prefixScorerWeightEnvVar         = "PREFIX_AWARE_SCORER_WEIGHT"
+	sessionAwareScorerWeightEnvVar   = "SESSION_AWARE_SCORER_WEIGHT"
+	consistentHashScorerWeightEnvVar = "CONSISTENT_HASH_SCORER_WEIGHT"
+	latencyScorerWeightEnvVar        = "LATENCY_PREDICTIVE_SCORER_WEIGHT"
+	kvHeadroomScorerWeightEnvVar     = "KV_HEADROOM_SCORER_WEIGHT"
+	zoneLocalityScorerWeightEnvVar   = "ZONE_LOCALITY_SCORER_WEIGHT"
+
+	sessionTokenKeysDirEnvVar    = "SESSION_TOKEN_KEYS_DIR"
+	sessionTokenEncryptionEnvVar = "SESSION_TOKEN_ENCRYPTION"
+	sessionFallbackScoreEnvVar   = "SESSION_AWARE_SCORER_FALLBACK_SCORE"
+	sessionMaxSessionsEnvVar     = "SESSION_AWARE_SCORER_MAX_SESSIONS"
+
+	consistentHashKeysEnvVar         = "CONSISTENT_HASH_KEYS"
+	consistentHashLoadFactorEnvVar   = "CONSISTENT_HASH_LOAD_FACTOR"
+	consistentHashVirtualNodesEnvVar = "CONSISTENT_HASH_VIRTUAL_NODES"
+
+	latencyForgettingFactorEnvVar     = "LATENCY_PREDICTOR_FORGETTING_FACTOR"
+	latencyMinSamplesEnvVar           = "LATENCY_PREDICTOR_MIN_SAMPLES"
+	latencyExpectedOutputTokensEnvVar = "LATENCY_PREDICTOR_EXPECTED_OUTPUT_TOKENS"
+
+	kvHeadroomDefaultMaxTokensEnvVar = "KV_HEADROOM_DEFAULT_MAX_TOKENS"
+
+	zoneLocalityQueueThresholdEnvVar   = "ZONE_LOCALITY_QUEUE_THRESHOLD"
+	zoneLocalityKVCacheThresholdEnvVar = "ZONE_LOCALITY_KVCACHE_THRESHOLD"
+
+	decisionTreesConfigFileEnvVar = "DECISION_TREES_CONFIG_FILE"
+
+	criticalDecisionTree  = "critical"
+	sheddableDecisionTree = "sheddable"
 )
 
+// prefixAwareScorer is the prefix-aware scorer of the default scheduler, nil if not enabled. Its
+// prefix store is shared with the other plugins estimating prefix cache hits.
+var prefixAwareScorer *scorer.PrefixAwareScorer
+
+// The configurations are set in a single step, as the PD, remote plugins, shadow and experiment
+// configurations build on the default configuration rather than on the initialization order of the
+// files.
 func init() {
 	setDefaultConfig()
+	setPDConfig()
+	setRemotePlugins()
+	setShadowSchedulers()
+	setExperiment()
 }
 
 func setDefaultConfig() {
 	// since the default config is a global variable, we add this function to minimize rebase conflicts.
 	// this configuration is a temporary state, it should be better streamlined.
+	setDecisionTrees()
+	// The prefix scorer is set first, as its prefix store is shared with the plugins set afterwards.
+	setPrefixScorer()
+	setContextLengthFilter()
 	setLoadAwareScorer()
 	setSessionAwareScorer()
+	setConsistentHashScorer()
+	setLatencyPredictiveScorer()
+	setKVHeadroom()
+	setZoneLocality()
 	setKVCacheAwareScorer()
-	setPrefixScorer()
+	setLocalKVCacheAwareScorer()
 
 	defaultConfig.picker = picker.NewMaxScorePicker()
 }
 
+func setDecisionTrees() {
+	ctx := context.Background()
+	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
+
+	path := envutil.GetEnvString(decisionTreesConfigFileEnvVar, "", loggerDebug)
+	if path == "" {
+		loggerDebug.Info("Skipping decision trees configuration as no file is set")
+		return
+	}
+	if err := loadDecisionTrees(path, defPlugin); err != nil {
+		loggerDebug.Error(err, "Failed to load decision trees configuration", "path", path)
+		configErrors = append(configErrors, fmt.Errorf("invalid decision trees configuration %s - %w", path, err))
+		return
+	}
+	loggerDebug.Info("Initialized decision trees", "path", path,
+		"critical", defPlugin.criticalFilter != nil, "sheddable", defPlugin.sheddableFilter != nil)
+}
+
+// loadDecisionTrees sets the critical and sheddable filters of the given plugin to the trees of the
+// given file. An invalid file sets none.
+func loadDecisionTrees(path string, plugin *defaultPlugin) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	trees, err := filter.ParseDecisionTrees(data)
+	if err != nil {
+		return err
+	}
+
+	// Trees other than the critical and sheddable ones are only building blocks for those.
+	plugin.criticalFilter = trees[criticalDecisionTree]
+	plugin.sheddableFilter = trees[sheddableDecisionTree]
+	return nil
+}
+
+func setContextLengthFilter() {
+	ctx := context.Background()
+	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
+
+	if envutil.GetEnvString(contextLengthFilterEnablementEnvVar, "false", loggerDebug) != "true" {
+		loggerDebug.Info("Skipping ContextLengthFilter creation as it is not enabled")
+		return
+	}
+
+	// The filter runs before the capacity filters, so that requests no pod can fit are rejected as bad
+	// requests rather than dropped for lack of capacity. On prefill and decode schedulers it runs after
+	// the role filter, to only consider the pods of the role.
+	contextLengthFilter := &filter.ContextLengthFilter{}
+	defaultConfig.filters = append([]plugins.Filter{contextLengthFilter}, defaultConfig.filters...)
+	prefillConfig.filters = append(prefillConfig.filters, contextLengthFilter)
+	decodeConfig.filters = append(decodeConfig.filters, contextLengthFilter)
+	loggerDebug.Info("Initialized ContextLengthFilter")
+}
+
 func setLoadAwareScorer() {
 	ctx := context.Background()
 	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
@@ -77,14 +192,144 @@ func setSessionAwareScorer() {
 		return
 	}
 
+	sessionAffinityConfig := scorer.DefaultSessionAffinityConfig()
+	sessionAffinityConfig.KeysDir = envutil.GetEnvString(sessionTokenKeysDirEnvVar, "", loggerDebug)
+	sessionAffinityConfig.Encrypt = envutil.GetEnvString(sessionTokenEncryptionEnvVar, "false", loggerDebug) == "true"
+	sessionAffinityConfig.FallbackScore = envutil.GetEnvFloat(sessionFallbackScoreEnvVar, sessionAffinityConfig.FallbackScore, loggerDebug)
+	sessionAffinityConfig.MaxSessions = envutil.GetEnvInt(sessionMaxSessionsEnvVar, sessionAffinityConfig.MaxSessions, loggerDebug)
+
+	sessionAffinity, err := scorer.NewSessionAffinity(ctx, sessionAffinityConfig)
+	if err != nil {
+		loggerDebug.Error(err, "Failed to create SessionAwareScorer")
+		return
+	}
+
 	sessionBasedScorerWeight := envutil.GetEnvInt(sessionAwareScorerWeightEnvVar, 1, loggerDebug)
-	sessionAffinity := scorer.NewSessionAffinity()
 
 	defaultConfig.scorers[sessionAffinity] = sessionBasedScorerWeight
 	defaultConfig.postResponsePlugins = append(defaultConfig.postResponsePlugins, sessionAffinity)
 	loggerDebug.Info("Initialized SessionAwareScorer", "weight", sessionBasedScorerWeight)
 }
 
+func setConsistentHashScorer() {
+	ctx := context.Background()
+	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
+
+	if envutil.GetEnvString(consistentHashScorerEnablementEnvVar, "false", loggerDebug) != "true" {
+		loggerDebug.Info("Skipping ConsistentHashScorer creation as it is not enabled")
+		return
+	}
+
+	consistentHashConfig := scorer.DefaultConsistentHashConfig()
+	if keys := envutil.GetEnvString(consistentHashKeysEnvVar, "", loggerDebug); keys != "" {
+		parsedKeys, err := scorer.ParseHashKeys(keys)
+		if err != nil {
+			loggerDebug.Error(err, "Failed to create ConsistentHashScorer")
+			return
+		}
+		consistentHashConfig.Keys = parsedKeys
+	}
+	consistentHashConfig.LoadFactor = envutil.GetEnvFloat(consistentHashLoadFactorEnvVar, consistentHashConfig.LoadFactor, loggerDebug)
+	consistentHashConfig.VirtualNodes = envutil.GetEnvInt(consistentHashVirtualNodesEnvVar, consistentHashConfig.VirtualNodes, loggerDebug)
+
+	consistentHashScorerWeight := envutil.GetEnvInt(consistentHashScorerWeightEnvVar, 1, loggerDebug)
+	defaultConfig.scorers[scorer.NewConsistentHashScorer(consistentHashConfig)] = consistentHashScorerWeight
+	loggerDebug.Info("Initialized ConsistentHashScorer", "weight", consistentHashScorerWeight, "keys", consistentHashConfig.Keys)
+}
+
+func setLatencyPredictiveScorer() {
+	ctx := context.Background()
+	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
+
+	if envutil.GetEnvString(latencyScorerEnablementEnvVar, "false", loggerDebug) != "true" {
+		loggerDebug.Info("Skipping LatencyPredictiveScorer creation as it is not enabled")
+		return
+	}
+
+	latencyConfig := scorer.DefaultLatencyPredictiveConfig()
+	latencyConfig.ForgettingFactor = envutil.GetEnvFloat(latencyForgettingFactorEnvVar, latencyConfig.ForgettingFactor, loggerDebug)
+	latencyConfig.MinSamples = envutil.GetEnvInt(latencyMinSamplesEnvVar, latencyConfig.MinSamples, loggerDebug)
+	latencyConfig.ExpectedOutputTokens = envutil.GetEnvInt(latencyExpectedOutputTokensEnvVar, latencyConfig.ExpectedOutputTokens, loggerDebug)
+	if prefixAwareScorer != nil {
+		latencyConfig.PrefixStore = prefixAwareScorer.GetPrefixStore()
+	}
+
+	latencyScorer := scorer.NewLatencyPredictiveScorer(latencyConfig)
+	latencyScorerWeight := envutil.GetEnvInt(latencyScorerWeightEnvVar, 1, loggerDebug)
+
+	defaultConfig.scorers[latencyScorer] = latencyScorerWeight
+	defaultConfig.postSchedulePlugins = append(defaultConfig.postSchedulePlugins, latencyScorer)
+	defaultConfig.postCompletionPlugins = append(defaultConfig.postCompletionPlugins, latencyScorer)
+	loggerDebug.Info("Initialized LatencyPredictiveScorer", "weight", latencyScorerWeight)
+
+	// The SLO filter relies on the latency predictions of the scorer. It applies to the prefill and
+	// decode stages of disaggregated requests as well.
+	if envutil.GetEnvString(sloFilterEnablementEnvVar, "false", loggerDebug) == "true" {
+		sloFilter := filter.NewSLOFilter(latencyScorer)
+		for _, config := range []*SchedulerConfig{defaultConfig, prefillConfig, decodeConfig} {
+			config.filters = append(config.filters, sloFilter)
+		}
+		loggerDebug.Info("Initialized SLOFilter")
+	}
+}
+
+func setKVHeadroom() {
+	ctx := context.Background()
+	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
+
+	defaultMaxTokens := envutil.GetEnvInt(kvHeadroomDefaultMaxTokensEnvVar, filter.DefaultKVHeadroomMaxTokens, loggerDebug)
+
+	if envutil.GetEnvString(kvHeadroomFilterEnablementEnvVar, "false", loggerDebug) == "true" {
+		defaultConfig.filters = append(defaultConfig.filters, filter.NewKVHeadroomFilter(defaultMaxTokens))
+		loggerDebug.Info("Initialized KVHeadroomFilter", "defaultMaxTokens", defaultMaxTokens)
+	} else {
+		loggerDebug.Info("Skipping KVHeadroomFilter creation as it is not enabled")
+	}
+
+	if envutil.GetEnvString(kvHeadroomScorerEnablementEnvVar, "false", loggerDebug) == "true" {
+		kvHeadroomScorerWeight := envutil.GetEnvInt(kvHeadroomScorerWeightEnvVar, 1, loggerDebug)
+		defaultConfig.scorers[scorer.NewKVHeadroomScorer(defaultMaxTokens)] = kvHeadroomScorerWeight
+		loggerDebug.Info("Initialized KVHeadroomScorer", "weight", kvHeadroomScorerWeight, "defaultMaxTokens", defaultMaxTokens)
+	} else {
+		loggerDebug.Info("Skipping KVHeadroomScorer creation as it is not enabled")
+	}
+}
+
+func setZoneLocality() {
+	ctx := context.Background()
+	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
+
+	enabled := false
+	if envutil.GetEnvString(zoneLocalityFilterEnablementEnvVar, "false", loggerDebug) == "true" {
+		zoneLocalityConfig := filter.DefaultZoneLocalityConfig()
+		zoneLocalityConfig.QueueThreshold = envutil.GetEnvInt(zoneLocalityQueueThresholdEnvVar, zoneLocalityConfig.QueueThreshold, loggerDebug)
+		zoneLocalityConfig.KVCacheThreshold = envutil.GetEnvFloat(zoneLocalityKVCacheThresholdEnvVar, zoneLocalityConfig.KVCacheThreshold, loggerDebug)
+
+		zoneLocalityFilter := filter.NewZoneLocalityFilter(zoneLocalityConfig)
+		defaultConfig.filters = append(defaultConfig.filters, zoneLocalityFilter)
+		enabled = true
+		loggerDebug.Info("Initialized ZoneLocalityFilter", "queueThreshold", zoneLocalityConfig.QueueThreshold,
+			"kvCacheThreshold", zoneLocalityConfig.KVCacheThreshold)
+	} else {
+		loggerDebug.Info("Skipping ZoneLocalityFilter creation as it is not enabled")
+	}
+
+	if envutil.GetEnvString(zoneLocalityScorerEnablementEnvVar, "false", loggerDebug) == "true" {
+		zoneLocalityScorerWeight := envutil.GetEnvInt(zoneLocalityScorerWeightEnvVar, 1, loggerDebug)
+		defaultConfig.scorers[&scorer.ZoneLocalityScorer{}] = zoneLocalityScorerWeight
+		enabled = true
+		loggerDebug.Info("Initialized ZoneLocalityScorer", "weight", zoneLocalityScorerWeight)
+	} else {
+		loggerDebug.Info("Skipping ZoneLocalityScorer creation as it is not enabled")
+	}
+
+	// The routed requests are counted once, whether the locality is enforced by the filter or
+	// weighted by the scorer.
+	if enabled {
+		defaultConfig.postSchedulePlugins = append(defaultConfig.postSchedulePlugins, &filter.ZoneRoutingRecorder{})
+	}
+}
+
 func setKVCacheAwareScorer() {
 	ctx := context.Background()
 	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
@@ -105,6 +350,26 @@ func setKVCacheAwareScorer() {
 	loggerDebug.Info("Initialized KVCacheAwareScorer", "weight", kvCacheScorerWeight)
 }
 
+func setLocalKVCacheAwareScorer() {
+	ctx := context.Background()
+	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
+
+	if envutil.GetEnvString(localKVCacheScorerEnablementEnvVar, "false", loggerDebug) != "true" {
+		loggerDebug.Info("Skipping LocalKVCacheAwareScorer creation as it is not enabled")
+		return
+	}
+
+	localKVCacheScorer, err := scorer.NewLocalKVCacheAwareScorer(ctx)
+	if err != nil {
+		loggerDebug.Error(err, "Failed to create LocalKVCacheAwareScorer")
+		return
+	}
+
+	localKVCacheScorerWeight := envutil.GetEnvInt(localKVCacheScorerWeightEnvVar, 1, loggerDebug)
+	defaultConfig.scorers[localKVCacheScorer] = localKVCacheScorerWeight
+	loggerDebug.Info("Initialized LocalKVCacheAwareScorer", "weight", localKVCacheScorerWeight)
+}
+
 func setPrefixScorer() {
 	ctx := context.Background()
 	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
@@ -115,9 +380,9 @@ func setPrefixScorer() {
 	}
 
 	prefixScorerWeight := envutil.GetEnvInt(prefixScorerWeightEnvVar, 1, loggerDebug)
-	prefixScorer := scorer.NewPrefixAwareScorer(nil)
-	defaultConfig.scorers[prefixScorer] = prefixScorerWeight // TODO: make configurable
-	defaultConfig.postSchedulePlugins = append(defaultConfig.postSchedulePlugins, prefixScorer)
+	prefixAwareScorer = scorer.NewPrefixAwareScorer(nil)
+	defaultConfig.scorers[prefixAwareScorer] = prefixScorerWeight // TODO: make configurable
+	defaultConfig.postSchedulePlugins = append(defaultConfig.postSchedulePlugins, prefixAwareScorer)
 
 	loggerDebug.Info("Initialized PrefixAwareScorer", "weight", prefixScorerWeight)
 }
@@ -0,0 +1,86 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDecisionTrees(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        string
+		noFile        bool
+		wantErr       bool
+		wantCritical  bool
+		wantSheddable bool
+	}{
+		{
+			name: "valid trees",
+			config: `
+trees:
+  critical:
+    filter: low-queue
+    parameters:
+      threshold: 10
+`,
+			wantCritical: true,
+		},
+		{
+			name: "unknown filter",
+			config: `
+trees:
+  critical:
+    filter: no-such-filter
+`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			config:  "trees: [",
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			noFile:  true,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "decision-trees.yaml")
+			if !test.noFile {
+				if err := os.WriteFile(path, []byte(test.config), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			plugin := &defaultPlugin{}
+			err := loadDecisionTrees(path, plugin)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Unexpected error, want %t, got %v", test.wantErr, err)
+			}
+
+			// An invalid file sets no tree.
+			if (plugin.criticalFilter != nil) != test.wantCritical || (plugin.sheddableFilter != nil) != test.wantSheddable {
+				t.Errorf("Unexpected trees, want critical %t and sheddable %t, got %v and %v",
+					test.wantCritical, test.wantSheddable, plugin.criticalFilter, plugin.sheddableFilter)
+			}
+		})
+	}
+}
@@ -22,52 +22,110 @@ import (
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/decider"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/filter"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/picker"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/scorer"
+	envutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/env"
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
 )
 
 var prefillConfig = &SchedulerConfig{
-	preSchedulePlugins:  []plugins.PreSchedule{},
-	filters:             []plugins.Filter{filter.PrefillFilter},
-	scorers:             map[plugins.Scorer]int{},
-	picker:              picker.NewMaxScorePicker(),
-	postSchedulePlugins: []plugins.PostSchedule{},
-	postResponsePlugins: []plugins.PostResponse{},
+	preSchedulePlugins:    []plugins.PreSchedule{},
+	filters:               []plugins.Filter{filter.PrefillFilter},
+	scorers:               map[plugins.Scorer]int{},
+	picker:                picker.NewMaxScorePicker(),
+	postSchedulePlugins:   []plugins.PostSchedule{},
+	postResponsePlugins:   []plugins.PostResponse{},
+	postCompletionPlugins: []plugins.PostCompletion{},
+}
+var encodeConfig = &SchedulerConfig{
+	preSchedulePlugins:    []plugins.PreSchedule{},
+	filters:               []plugins.Filter{filter.EncodeFilter},
+	scorers:               map[plugins.Scorer]int{},
+	picker:                picker.NewMaxScorePicker(),
+	postSchedulePlugins:   []plugins.PostSchedule{},
+	postResponsePlugins:   []plugins.PostResponse{},
+	postCompletionPlugins: []plugins.PostCompletion{},
 }
 var decodeConfig = &SchedulerConfig{
-	preSchedulePlugins:  []plugins.PreSchedule{},
-	filters:             []plugins.Filter{filter.DecodeFilter},
-	scorers:             map[plugins.Scorer]int{},
-	picker:              picker.NewMaxScorePicker(),
-	postSchedulePlugins: []plugins.PostSchedule{},
-	postResponsePlugins: []plugins.PostResponse{},
+	preSchedulePlugins:    []plugins.PreSchedule{},
+	filters:               []plugins.Filter{filter.DecodeFilter},
+	scorers:               map[plugins.Scorer]int{},
+	picker:                picker.NewMaxScorePicker(),
+	postSchedulePlugins:   []plugins.PostSchedule{},
+	postResponsePlugins:   []plugins.PostResponse{},
+	postCompletionPlugins: []plugins.PostCompletion{},
 }
 
 var PDEnabled = false
 var promptLengthThreshold int
 
-func init() {
+// encodeEnabled enables encoding the multimodal inputs of requests on dedicated encode pods.
+var encodeEnabled = false
+
+// pdDecider decides whether requests are disaggregated, nil to decide by the prompt length.
+var pdDecider plugins.DisaggregationDecider
+
+// setPDConfig sets the encode, prefill and decode configurations, and adds the PD plugins to the
+// default configuration if PD is enabled.
+func setPDConfig() {
 	ctx := context.Background()
 	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
 
+	loadEncodeConfiguration(ctx, loggerDebug)
 	loadPrefillConfiguration(ctx, loggerDebug)
 	loadDecodeConfiguration(ctx, loggerDebug)
 
 	// set IsPDEnabled by environment
 	PDEnabled = getPDEnabledFromEnvironment(loggerDebug)
 	promptLengthThreshold = getPDPromptLenThresholdFromEnvironment(loggerDebug)
+	encodeEnabled = envutil.GetEnvString(pdEncodeEnabledEnvKey, "false", loggerDebug) == "true"
 
 	// update default config if pd is enabled
 	if PDEnabled {
 		defaultConfig.filters = append(defaultConfig.filters, filter.DecodeFilter)
+		loadDeciderConfiguration(loggerDebug)
+	}
+}
+
+// loadDeciderConfiguration sets the policy deciding whether requests are disaggregated.
+func loadDeciderConfiguration(logger logr.Logger) {
+	switch name := envutil.GetEnvString(pdDeciderEnvKey, promptLengthDeciderName, logger); name {
+	case promptLengthDeciderName:
+		logger.Info("Disaggregating requests by prompt length", "threshold", promptLengthThreshold)
+	case cacheAwareDeciderName:
+		deciderConfig := decider.DefaultCacheAwareConfig()
+		deciderConfig.UncachedTokensThreshold = envutil.GetEnvInt(pdUncachedTokensThresholdEnvKey, deciderConfig.UncachedTokensThreshold, logger)
+		deciderConfig.PrefillLoadRatio = envutil.GetEnvFloat(pdPrefillLoadRatioEnvKey, deciderConfig.PrefillLoadRatio, logger)
+		if prefixAwareScorer != nil {
+			deciderConfig.PrefixStore = prefixAwareScorer.GetPrefixStore()
+		}
+		cacheAwareDecider := decider.NewCacheAwareDecider(deciderConfig)
+
+		// the decider tracks the prompt prefixes held by the pods serving the whole request or its decode stage
+		defaultConfig.postSchedulePlugins = append(defaultConfig.postSchedulePlugins, cacheAwareDecider)
+		decodeConfig.postSchedulePlugins = append(decodeConfig.postSchedulePlugins, cacheAwareDecider)
+		pdDecider = cacheAwareDecider
+		logger.Info("Initialized CacheAwareDecider", "uncachedTokensThreshold", deciderConfig.UncachedTokensThreshold,
+			"prefillLoadRatio", deciderConfig.PrefillLoadRatio)
+	default:
+		logger.Info("Unknown PD decider, disaggregating requests by prompt length", "decider", name)
 	}
 }
 
+func loadEncodeConfiguration(ctx context.Context, logger logr.Logger) {
+	// add scorers
+	addScorerByEnvironment(ctx, encodeConfig, loadAwareScorerName, encodeLoadAwareScorerEnablementEnvVar,
+		encodeLoadAwareScorerWeightEnvVar, logger)
+}
+
 func loadPrefillConfiguration(ctx context.Context, logger logr.Logger) {
 	// add scorers
 	addScorerByEnvironment(ctx, prefillConfig, kvCacheAwareScorerName, prefillKvCacheScorerEnablementEnvVar,
 		prefillKvCacheScorerWeightEnvVar, logger)
+	addScorerByEnvironment(ctx, prefillConfig, localKVCacheAwareScorerName, prefillLocalKvCacheScorerEnablementEnvVar,
+		prefillLocalKvCacheScorerWeightEnvVar, logger)
 	addScorerByEnvironment(ctx, prefillConfig, loadAwareScorerName, prefillLoadAwareScorerEnablementEnvVar,
 		prefillLoadAwareScorerWeightEnvVar, logger)
 	addScorerByEnvironment(ctx, prefillConfig, prefixAwareScorerName, prefillPrefixScorerEnablementEnvVar,
@@ -78,8 +136,29 @@ func loadDecodeConfiguration(ctx context.Context, logger logr.Logger) {
 	// add scorers
 	addScorerByEnvironment(ctx, decodeConfig, kvCacheAwareScorerName, decodeKvCacheScorerEnablementEnvVar,
 		decodeKvCacheScorerWeightEnvVar, logger)
+	addScorerByEnvironment(ctx, decodeConfig, localKVCacheAwareScorerName, decodeLocalKvCacheScorerEnablementEnvVar,
+		decodeLocalKvCacheScorerWeightEnvVar, logger)
 	addScorerByEnvironment(ctx, decodeConfig, loadAwareScorerName, decodeLoadAwareScorerEnablementEnvVar,
 		decodeLoadAwareScorerWeightEnvVar, logger)
 	addScorerByEnvironment(ctx, decodeConfig, prefixAwareScorerName, decodePrefixScorerEnablementEnvVar,
 		decodePrefixScorerWeightEnvVar, logger)
+	setDecodeTopologyScorer(logger)
+}
+
+// setDecodeTopologyScorer adds the PDTopologyScorer to the decode scheduler, preferring decode pods
+// close to the selected prefill pod.
+func setDecodeTopologyScorer(logger logr.Logger) {
+	if envutil.GetEnvString(decodeTopologyScorerEnablementEnvVar, "false", logger) != "true" {
+		logger.Info("Skipping PDTopologyScorer creation as it is not enabled")
+		return
+	}
+
+	topologyConfig := scorer.DefaultPDTopologyConfig()
+	topologyConfig.SameRackScore = envutil.GetEnvFloat(pdTopologySameRackScoreEnvVar, topologyConfig.SameRackScore, logger)
+	topologyConfig.SameZoneScore = envutil.GetEnvFloat(pdTopologySameZoneScoreEnvVar, topologyConfig.SameZoneScore, logger)
+
+	weight := envutil.GetEnvInt(decodeTopologyScorerWeightEnvVar, 1, logger)
+	decodeConfig.scorers[scorer.NewPDTopologyScorer(topologyConfig)] = weight
+	logger.Info("Initialized PDTopologyScorer", "weight", weight, "sameRackScore", topologyConfig.SameRackScore,
+		"sameZoneScore", topologyConfig.SameZoneScore)
 }
@@ -20,71 +20,205 @@ package scheduling
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/decider"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
 )
 
 const (
-	prefillPodHeader = "x-prefiller-url"
+	// EncodePodHeader and PrefillPodHeader are the request headers set to the url of the pods
+	// encoding and prefilling the request, when disaggregated.
+	EncodePodHeader  = "x-encoder-url"
+	PrefillPodHeader = "x-prefiller-url"
 )
 
 func NewPDScheduler(datastore Datastore) *PDScheduler {
-	return NewPDSchedulerWithConfig(datastore, prefillConfig, decodeConfig, defaultConfig)
+	return NewPDSchedulerWithConfig(datastore, pdStages(func(config *SchedulerConfig) *SchedulerConfig { return config }),
+		decodeConfig, defaultConfig, pdDecider)
 }
 
-func NewPDSchedulerWithConfig(datastore Datastore, pConfig *SchedulerConfig, dConfig *SchedulerConfig, defConfig *SchedulerConfig) *PDScheduler {
+// pdStages returns the configured stages ahead of the decode stage, with the configuration of every
+// stage mapped by the given function.
+func pdStages(config func(*SchedulerConfig) *SchedulerConfig) []PDStageConfig {
+	stages := []PDStageConfig{}
+	if encodeEnabled {
+		stages = append(stages, PDStageConfig{Role: backendmetrics.Encode, Config: config(encodeConfig)})
+	}
+	return append(stages, PDStageConfig{Role: backendmetrics.Prefill, Config: config(prefillConfig)})
+}
+
+// PDStageConfig configures a stage requests may go through on dedicated pods ahead of the decode
+// stage. The supported roles are encode, for requests with multimodal inputs, and prefill, for the
+// requests disaggregated by the decider.
+type PDStageConfig struct {
+	Role   backendmetrics.PodRole
+	Config *SchedulerConfig
+}
+
+// NewPDSchedulerWithConfig creates a new PDScheduler, running the given stages in order ahead of the
+// decode stage. Stages of unsupported roles are ignored. If the decider is nil, requests are
+// disaggregated by their prompt length.
+func NewPDSchedulerWithConfig(datastore Datastore, stageConfigs []PDStageConfig, dConfig *SchedulerConfig,
+	defConfig *SchedulerConfig, pdDecider plugins.DisaggregationDecider) *PDScheduler {
+	if pdDecider == nil {
+		pdDecider = decider.NewPromptLengthDecider(promptLengthThreshold)
+	}
+
+	stages := []*schedulingStage{}
+	for _, stageConfig := range stageConfigs {
+		stage := &schedulingStage{role: stageConfig.Role, scheduler: NewSchedulerWithConfig(datastore, stageConfig.Config)}
+		switch stageConfig.Role {
+		case backendmetrics.Encode:
+			stage.header = EncodePodHeader
+			stage.applies = func(sCtx *types.SchedulingContext) bool {
+				return sCtx.Req.HasMultimodalInput()
+			}
+			// the model server encodes the inputs itself when no encode pod is available
+			stage.optional = true
+		case backendmetrics.Prefill:
+			stage.header = PrefillPodHeader
+			stage.applies = func(sCtx *types.SchedulingContext) bool {
+				before := time.Now()
+				disaggregate := pdDecider.Disaggregate(sCtx)
+				metrics.RecordSchedulerPluginProcessingLatency(plugins.DisaggregationPluginType, pdDecider.Name(), time.Since(before))
+				return disaggregate
+			}
+		default:
+			continue
+		}
+		stages = append(stages, stage)
+	}
+
 	return &PDScheduler{
 		datastore:        datastore,
-		prefillScheduler: NewSchedulerWithConfig(datastore, pConfig),
+		stages:           stages,
+		decider:          pdDecider,
 		decodeScheduler:  NewSchedulerWithConfig(datastore, dConfig),
 		defaultScheduler: NewSchedulerWithConfig(datastore, defConfig),
 	}
 }
 
+// schedulingStage is a stage of a disaggregated request served by a dedicated pod ahead of the
+// decode stage, e.g., the prefill stage.
+type schedulingStage struct {
+	// role is the role of the pods serving the stage.
+	role backendmetrics.PodRole
+	// header is the request header carrying the url of the pod serving the stage.
+	header    string
+	scheduler *Scheduler
+	// applies decides whether the request goes through the stage.
+	applies func(sCtx *types.SchedulingContext) bool
+	// optional stages are skipped when they fail to find a pod, instead of failing the request.
+	optional bool
+}
+
 type PDScheduler struct {
-	datastore        Datastore
-	prefillScheduler *Scheduler
+	datastore Datastore
+	// stages are the stages requests may go through before the decode stage, in order.
+	stages           []*schedulingStage
+	decider          plugins.DisaggregationDecider
 	decodeScheduler  *Scheduler
 	defaultScheduler *Scheduler
 }
 
 // Schedule finds the target pod based on metrics and the requested lora adapter.
-// PD scheduler uses three base schedulers to process requests, the overall configuration is currently loaded from environment variables.
-// If the request prompt is short enough (defined by the threshold in the configuration) - use the default behavior
-// If the request prompt is long enough to use prefill-decode process:
-// 1 - find the pod for prefill, save its url in a special header. For this, use the Scheduler configured for this goal, which uses the prefill filter
-// and scorers according to the configuration.
-// 2 - find the pod for decode, use the Scheduler configured for this goal, which uses the decode filer and scorers defined in the configuration
+// PD scheduler uses a base scheduler per stage to process requests, the overall configuration is currently loaded from environment variables.
+// 1 - if encoding is enabled and the request holds multimodal inputs, find the pod for encode and save its url in a special header.
+// If no pod can encode the request, it is encoded by the prefill or decode pod.
+// 2 - if the decider disaggregates the request (e.g., its prompt is long and not cached), find the pod for prefill and save its url
+// in a special header. For this, use the Scheduler configured for this goal, which uses the prefill filter and scorers according to
+// the configuration.
+// 3 - if the request was prefilled, find the pod for decode, use the Scheduler configured for this goal, which uses the decode filer
+// and scorers defined in the configuration. Otherwise, use the default behavior.
 func (s *PDScheduler) Schedule(ctx context.Context, req *types.LLMRequest) (*types.Result, error) {
 	logger := log.FromContext(ctx).WithValues("pd-schedule", req)
 
-	if len(req.Prompt) < promptLengthThreshold {
-		// the prompt is short enough - use the default scheduling logic
-		return s.defaultScheduler.Schedule(ctx, req)
-	}
-
 	sCtx, err := createSchedulerContext(ctx, req, s.datastore)
 	if err != nil {
 		return nil, err
 	}
 
-	// prompt requires processing on two pods - prefill and decode
-	// start with calculating of the prefill pod
-	res, err := s.prefillScheduler.scheduleWithContext(ctx, sCtx, req, logger)
-	if err != nil {
-		return nil, err
+	// Every scheduling run gets its own clone of the cycle state, so that the state written by the
+	// plugins of a run, e.g., prefill, is not seen by the plugins of another run, e.g., decode. The
+	// state written before the runs, e.g., the prompt rendered by the decider, is shared by all.
+	cycleState := sCtx.CycleState
+	prefilled := false
+	for _, stage := range s.stages {
+		sCtx.CycleState = cycleState
+		if !stage.applies(sCtx) {
+			continue
+		}
+		sCtx.CycleState = cycleState.Clone()
+		res, err := stage.scheduler.scheduleWithContext(sCtx, logger)
+		if err != nil && stage.optional {
+			logger.V(logutil.DEFAULT).Info("Skipping scheduling stage", "role", stage.role, "error", err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if stage.role == backendmetrics.Prefill {
+			prefilled = true
+		}
+		if res.TargetPod == nil {
+			continue
+		}
+		url := fmt.Sprintf("http://%s:%d", res.TargetPod.GetPod().Address, sCtx.TargetPort)
+		sCtx.MutatedHeaders[stage.header] = url
+		if stage.role == backendmetrics.Prefill {
+			// let the decode scorers take the prefill pod into account, e.g., its topology
+			sCtx.PrefillPod = res.TargetPod
+		}
 	}
 
-	if res.TargetPod != nil {
-		url := fmt.Sprintf("http://%s:%d", res.TargetPod.GetPod().Address, sCtx.TargetPort)
-		sCtx.MutatedHeaders[prefillPodHeader] = url
+	sCtx.CycleState = cycleState.Clone()
+	if !prefilled {
+		// the request is not worth disaggregating - use the default scheduling logic
+		return s.defaultScheduler.scheduleWithContext(sCtx, logger)
 	}
 
 	// get decode pod
-	return s.decodeScheduler.scheduleWithContext(ctx, sCtx, req, logger)
+	decodeRes, err := s.decodeScheduler.scheduleWithContext(sCtx, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if sCtx.PrefillPod != nil && decodeRes.TargetPod != nil {
+		locality := decodeRes.TargetPod.GetPod().LocalityTo(sCtx.PrefillPod.GetPod())
+		metrics.RecordPDPairing(string(locality))
+	}
+	return decodeRes, nil
 }
 
 func (s *PDScheduler) RunPostResponsePlugins(ctx context.Context, req *types.LLMRequest, targetPodName string) (*types.Result, error) {
-	return s.decodeScheduler.RunPostResponsePlugins(ctx, req, targetPodName)
+	res, err := s.decodeScheduler.RunPostResponsePlugins(ctx, req, targetPodName)
+	if err != nil {
+		return nil, err
+	}
+	// The post-completion plugins of the default scheduler are run too.
+	res.NeedsCompletion = res.NeedsCompletion || len(s.defaultScheduler.postCompletionPlugins) > 0
+	return res, nil
+}
+
+// RunPostCompletionPlugins runs the post-completion plugins of both the default and the decode
+// schedulers, as the request may have been scheduled by either one. Plugins configured for both are
+// run once.
+func (s *PDScheduler) RunPostCompletionPlugins(ctx context.Context, req *types.LLMRequest, targetPodName string, latency *types.ResponseLatency) error {
+	postCompletionPlugins := []plugins.PostCompletion{}
+	seen := map[plugins.PostCompletion]bool{}
+	for _, schedulerPlugins := range [][]plugins.PostCompletion{s.defaultScheduler.postCompletionPlugins, s.decodeScheduler.postCompletionPlugins} {
+		for _, plugin := range schedulerPlugins {
+			if !seen[plugin] {
+				seen[plugin] = true
+				postCompletionPlugins = append(postCompletionPlugins, plugin)
+			}
+		}
+	}
+	return runPostCompletionPlugins(ctx, s.datastore, postCompletionPlugins, req, targetPodName, latency)
 }
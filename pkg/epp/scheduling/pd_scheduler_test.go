@@ -22,10 +22,12 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/sashabaranov/go-openai"
 	k8stypes "k8s.io/apimachinery/pkg/types"
 	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics" // Import config for thresholds
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/filter"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/picker"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
 )
 
@@ -38,6 +40,9 @@ func TestPDSchedule(t *testing.T) {
 	prefillConfig.scorers = map[plugins.Scorer]int{}
 	decodeConfig.filters = []plugins.Filter{filter.DecodeFilter}
 	decodeConfig.scorers = map[plugins.Scorer]int{}
+	encodeEnabled = true
+	encodeConfig.filters = []plugins.Filter{filter.EncodeFilter}
+	encodeConfig.scorers = map[plugins.Scorer]int{}
 
 	pod1 := &backendmetrics.FakePodMetrics{
 		Pod: &backendmetrics.Pod{
@@ -55,6 +60,14 @@ func TestPDSchedule(t *testing.T) {
 		},
 		Metrics: &backendmetrics.Metrics{},
 	}
+	pod3 := &backendmetrics.FakePodMetrics{
+		Pod: &backendmetrics.Pod{
+			NamespacedName: k8stypes.NamespacedName{Name: "pod3"},
+			Address:        "9.10.11.12",
+			Role:           backendmetrics.Encode,
+		},
+		Metrics: &backendmetrics.Metrics{},
+	}
 	wantPod1 := &types.PodMetrics{
 		Pod: &backendmetrics.Pod{
 			NamespacedName: k8stypes.NamespacedName{Name: "pod1"},
@@ -131,6 +144,61 @@ func TestPDSchedule(t *testing.T) {
 				MutatedHeaders: map[string]string{"x-prefiller-url": "http://1.2.3.4:0"},
 			},
 		},
+		{
+			name: "1E1P1D",
+			req: &types.LLMRequest{
+				Model:               "critical",
+				ResolvedTargetModel: "critical",
+				Critical:            true,
+				ChatCompletionRequest: &types.KVCacheChatCompletionRequest{
+					Messages: []openai.ChatCompletionMessage{{
+						Role: "user",
+						MultiContent: []openai.ChatMessagePart{
+							{Type: openai.ChatMessagePartTypeText, Text: "describe this image"},
+							{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: "https://example.com/image.png"}},
+						},
+					}},
+				},
+			},
+			// pod3 encodes the image, pod1 prefills, and pod2 will be picked because it is the decode pod
+			input: []*backendmetrics.FakePodMetrics{pod1, pod2, pod3},
+			wantRes: &types.Result{
+				TargetPod: &types.ScoredPod{
+					Pod:   wantPod2,
+					Score: 0.0,
+				},
+				MutatedHeaders: map[string]string{
+					"x-encoder-url":   "http://9.10.11.12:0",
+					"x-prefiller-url": "http://1.2.3.4:0",
+				},
+			},
+		},
+		{
+			name: "no encode pods",
+			req: &types.LLMRequest{
+				Model:               "critical",
+				ResolvedTargetModel: "critical",
+				Critical:            true,
+				ChatCompletionRequest: &types.KVCacheChatCompletionRequest{
+					Messages: []openai.ChatCompletionMessage{{
+						Role: "user",
+						MultiContent: []openai.ChatMessagePart{
+							{Type: openai.ChatMessagePartTypeText, Text: "describe this image"},
+							{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: "https://example.com/image.png"}},
+						},
+					}},
+				},
+			},
+			// the encode stage is skipped, and pod1 prefills the request, encoding the image itself
+			input: []*backendmetrics.FakePodMetrics{pod1, pod2},
+			wantRes: &types.Result{
+				TargetPod: &types.ScoredPod{
+					Pod:   wantPod2,
+					Score: 0.0,
+				},
+				MutatedHeaders: map[string]string{"x-prefiller-url": "http://1.2.3.4:0"},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -152,3 +220,127 @@ func TestPDSchedule(t *testing.T) {
 		})
 	}
 }
+
+// stageStateRecorder records, for each scheduling run, whether the cycle state holds the prompt and
+// the state written by the previous runs, then writes its own state.
+type stageStateRecorder struct {
+	sawPrompt []bool
+	sawStage  []bool
+}
+
+const testStageStateKey types.StateKey = "test/stage"
+
+func (r *stageStateRecorder) Name() string { return "stage-state-recorder" }
+
+func (r *stageStateRecorder) PreSchedule(ctx *types.SchedulingContext) {
+	_, err := ctx.CycleState.Read(types.PromptStateKey)
+	r.sawPrompt = append(r.sawPrompt, err == nil)
+	_, err = ctx.CycleState.Read(testStageStateKey)
+	r.sawStage = append(r.sawStage, err == nil)
+	ctx.CycleState.Write(testStageStateKey, &types.PromptState{Text: "written by a stage"})
+}
+
+func TestPDScheduleCycleState(t *testing.T) {
+	PDEnabled = true
+	promptLengthThreshold = 10
+	pdDecider = nil
+	recorder := &stageStateRecorder{}
+	prefillConfig.filters = []plugins.Filter{filter.PrefillFilter}
+	prefillConfig.scorers = map[plugins.Scorer]int{}
+	prefillConfig.preSchedulePlugins = []plugins.PreSchedule{recorder}
+	decodeConfig.filters = []plugins.Filter{filter.DecodeFilter}
+	decodeConfig.scorers = map[plugins.Scorer]int{}
+	decodeConfig.preSchedulePlugins = []plugins.PreSchedule{recorder}
+	t.Cleanup(func() {
+		prefillConfig.preSchedulePlugins = []plugins.PreSchedule{}
+		decodeConfig.preSchedulePlugins = []plugins.PreSchedule{}
+	})
+
+	pods := []*backendmetrics.FakePodMetrics{
+		{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "prefill"}, Role: backendmetrics.Prefill},
+			Metrics: &backendmetrics.Metrics{},
+		},
+		{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "decode"}, Role: backendmetrics.Decode},
+			Metrics: &backendmetrics.Metrics{},
+		},
+	}
+	req := &types.LLMRequest{Model: "critical", ResolvedTargetModel: "critical", Critical: true, Prompt: "12345678901"}
+	if _, err := NewPDScheduler(&fakeDataStore{pods: pods}).Schedule(context.Background(), req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The prompt rendered by the decider is shared by the prefill and decode runs, while the state
+	// written by the prefill run is not seen by the decode run.
+	if diff := cmp.Diff([]bool{true, true}, recorder.sawPrompt); diff != "" {
+		t.Errorf("Unexpected prompt state (-want +got): %v", diff)
+	}
+	if diff := cmp.Diff([]bool{false, false}, recorder.sawStage); diff != "" {
+		t.Errorf("Unexpected stage state (-want +got): %v", diff)
+	}
+}
+
+func TestPDSchedulerStages(t *testing.T) {
+	promptLengthThreshold = 10
+	prefill := &SchedulerConfig{filters: []plugins.Filter{filter.PrefillFilter}, scorers: map[plugins.Scorer]int{}, picker: picker.NewMaxScorePicker()}
+	decode := &SchedulerConfig{filters: []plugins.Filter{filter.DecodeFilter}, scorers: map[plugins.Scorer]int{}, picker: picker.NewMaxScorePicker()}
+	encode := &SchedulerConfig{filters: []plugins.Filter{filter.EncodeFilter}, scorers: map[plugins.Scorer]int{}, picker: picker.NewMaxScorePicker()}
+
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "prefill"}, Address: "1.1.1.1", Role: backendmetrics.Prefill}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "decode"}, Address: "2.2.2.2", Role: backendmetrics.Decode}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "encode"}, Address: "3.3.3.3", Role: backendmetrics.Encode}, Metrics: &backendmetrics.Metrics{}},
+	}
+	req := &types.LLMRequest{
+		Model:               "critical",
+		ResolvedTargetModel: "critical",
+		Critical:            true,
+		ChatCompletionRequest: &types.KVCacheChatCompletionRequest{
+			Messages: []openai.ChatCompletionMessage{{
+				Role: "user",
+				MultiContent: []openai.ChatMessagePart{
+					{Type: openai.ChatMessagePartTypeText, Text: "describe this image"},
+					{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: "https://example.com/image.png"}},
+				},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		stages      []PDStageConfig
+		wantHeaders map[string]string
+	}{
+		{
+			name:        "encode and prefill",
+			stages:      []PDStageConfig{{Role: backendmetrics.Encode, Config: encode}, {Role: backendmetrics.Prefill, Config: prefill}},
+			wantHeaders: map[string]string{EncodePodHeader: "http://3.3.3.3:0", PrefillPodHeader: "http://1.1.1.1:0"},
+		},
+		{
+			name:        "prefill only",
+			stages:      []PDStageConfig{{Role: backendmetrics.Prefill, Config: prefill}},
+			wantHeaders: map[string]string{PrefillPodHeader: "http://1.1.1.1:0"},
+		},
+		{
+			name:        "unsupported role ignored",
+			stages:      []PDStageConfig{{Role: backendmetrics.Decode, Config: decode}, {Role: backendmetrics.Prefill, Config: prefill}},
+			wantHeaders: map[string]string{PrefillPodHeader: "http://1.1.1.1:0"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler := NewPDSchedulerWithConfig(&fakeDataStore{pods: pods}, test.stages, decode, decode, nil)
+			res, err := scheduler.Schedule(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := res.TargetPod.GetPod().NamespacedName.Name; got != "decode" {
+				t.Errorf("Unexpected target pod, want decode, got %s", got)
+			}
+			if diff := cmp.Diff(test.wantHeaders, res.MutatedHeaders); diff != "" {
+				t.Errorf("Unexpected headers (-want +got): %v", diff)
+			}
+		})
+	}
+}
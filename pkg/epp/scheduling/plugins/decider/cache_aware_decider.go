@@ -0,0 +1,164 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package decider
+
+import (
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/scorer"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+const (
+	defaultUncachedTokensThreshold = 512
+	defaultPrefillLoadRatio        = 2.0
+)
+
+// CacheAwareConfig contains initialization configuration for CacheAwareDecider.
+type CacheAwareConfig struct {
+	// UncachedTokensThreshold is the minimal number of prompt tokens missing from the KV-cache of the
+	// best decode pod for the request to be disaggregated.
+	UncachedTokensThreshold int
+	// PrefillLoadRatio stops disaggregation while the prefill pods average more than PrefillLoadRatio
+	// times the waiting requests of the decode pods (or of a single waiting request if less).
+	PrefillLoadRatio float64
+	// PrefixStore tracks the prompt prefixes held by the pods, typically the store of the
+	// prefix-aware scorer, which purges it. If nil, the decider tracks the prefixes in a store of its
+	// own, configured by PrefixStoreConfig.
+	PrefixStore *scorer.PrefixStore
+	// PrefixStoreConfig configures the store of the decider when no store is shared.
+	PrefixStoreConfig *scorer.PrefixStoreConfig
+}
+
+// DefaultCacheAwareConfig returns a CacheAwareConfig instance with default configuration.
+func DefaultCacheAwareConfig() *CacheAwareConfig {
+	return &CacheAwareConfig{
+		UncachedTokensThreshold: defaultUncachedTokensThreshold,
+		PrefillLoadRatio:        defaultPrefillLoadRatio,
+		PrefixStoreConfig:       scorer.DefaultPrefixStoreConfig(),
+	}
+}
+
+// CacheAwareDecider disaggregates requests whose prompt is mostly missing from the KV-cache of the
+// decode pods, as long as the prefill pods are not much more loaded than the decode pods.
+//
+// The prompt prefixes held by the decode pods are tracked as the requests are scheduled, so the
+// decider must also run as a post-schedule plugin of the schedulers selecting the decode pods. A
+// shared store is populated by the decider too, as its owner does not see the decode pods.
+type CacheAwareDecider struct {
+	uncachedTokensThreshold int
+	prefillLoadRatio        float64
+	prefixStore             *scorer.PrefixStore
+	// ownsPrefixStore is true if the decider purges the prefix store itself.
+	ownsPrefixStore bool
+}
+
+var _ plugins.DisaggregationDecider = &CacheAwareDecider{}
+var _ plugins.PostSchedule = &CacheAwareDecider{}
+var _ plugins.PodEventHandler = &CacheAwareDecider{}
+
+// NewCacheAwareDecider creates a new CacheAwareDecider with the given configuration.
+// If the config is nil, default is used.
+func NewCacheAwareDecider(config *CacheAwareConfig) *CacheAwareDecider {
+	if config == nil {
+		config = DefaultCacheAwareConfig()
+	}
+	prefixStore, ownsPrefixStore := config.PrefixStore, false
+	if prefixStore == nil {
+		prefixStore, ownsPrefixStore = scorer.NewPrefixStore(config.PrefixStoreConfig), true
+	}
+	return &CacheAwareDecider{
+		uncachedTokensThreshold: config.UncachedTokensThreshold,
+		prefillLoadRatio:        config.PrefillLoadRatio,
+		prefixStore:             prefixStore,
+		ownsPrefixStore:         ownsPrefixStore,
+	}
+}
+
+func (d *CacheAwareDecider) Name() string {
+	return "cache-aware-decider"
+}
+
+func (d *CacheAwareDecider) Disaggregate(ctx *types.SchedulingContext) bool {
+	if ctx.Req == nil {
+		return false
+	}
+	loggerDebug := ctx.Logger.V(logutil.DEBUG)
+
+	prefillPods, decodePods := []types.Pod{}, []types.Pod{}
+	for _, pod := range ctx.PodsSnapshot {
+		switch pod.GetPod().Role {
+		case metrics.Prefill:
+			prefillPods = append(prefillPods, pod)
+		case metrics.Decode, metrics.Both:
+			decodePods = append(decodePods, pod)
+		}
+	}
+	if len(prefillPods) == 0 || len(decodePods) == 0 {
+		return false
+	}
+
+	prompt := ctx.PromptText()
+	matches := d.prefixStore.FindMatchingPods(prompt, ctx.Req.Model)
+	cachedBlocks := 0
+	for _, pod := range decodePods {
+		cachedBlocks = max(cachedBlocks, matches[pod.GetPod().NamespacedName.String()])
+	}
+	uncachedTokens := types.EstimatedTokens(len(prompt) - cachedBlocks*d.prefixStore.BlockSize())
+	if uncachedTokens < d.uncachedTokensThreshold {
+		loggerDebug.Info("Not disaggregating, the prompt is mostly cached on a decode pod", "uncachedTokens", uncachedTokens)
+		return false
+	}
+
+	prefillLoad, decodeLoad := averageWaitingQueueSize(prefillPods), averageWaitingQueueSize(decodePods)
+	if prefillLoad > d.prefillLoadRatio*max(decodeLoad, 1) {
+		loggerDebug.Info("Not disaggregating, the prefill pods are overloaded", "prefillLoad", prefillLoad, "decodeLoad", decodeLoad)
+		return false
+	}
+	return true
+}
+
+// PostSchedule records the prompt prefix as held by the pod the request was scheduled to.
+func (d *CacheAwareDecider) PostSchedule(ctx *types.SchedulingContext, res *types.Result) {
+	if ctx.Req == nil || res.TargetPod == nil || res.TargetPod.GetPod() == nil {
+		return
+	}
+	if err := d.prefixStore.AddEntry(ctx.Req.Model, ctx.PromptText(), &res.TargetPod.GetPod().NamespacedName); err != nil {
+		ctx.Logger.V(logutil.DEBUG).Error(err, "Failed to add entry to prefix store", "pod", res.TargetPod)
+	}
+}
+
+// OnPodEvent implements the PodEventHandler interface.
+// It purges the prefixes of removed pods from the store of the decider, a shared store being purged
+// by its owner.
+func (d *CacheAwareDecider) OnPodEvent(event datastore.Event) {
+	if d.ownsPrefixStore && event.Type == datastore.PodRemoved {
+		d.prefixStore.RemovePod(event.Pod.NamespacedName)
+	}
+}
+
+// averageWaitingQueueSize returns the average waiting queue size of the pods, normalized by their
+// capacity.
+func averageWaitingQueueSize(pods []types.Pod) float64 {
+	total := 0.0
+	for _, pod := range pods {
+		total += float64(pod.GetMetrics().WaitingQueueSize) / pod.GetPod().CapacityWeight()
+	}
+	return total / float64(len(pods))
+}
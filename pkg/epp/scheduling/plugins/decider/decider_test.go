@@ -0,0 +1,192 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package decider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/scorer"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func newPod(name string, role backendmetrics.PodRole, waiting int) *types.PodMetrics {
+	return &types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}, Role: role},
+		Metrics: &backendmetrics.Metrics{WaitingQueueSize: waiting},
+	}
+}
+
+func TestPromptLengthDecider(t *testing.T) {
+	chatRequest := &types.LLMRequest{
+		ChatCompletionRequest: &types.KVCacheChatCompletionRequest{
+			Messages: []openai.ChatCompletionMessage{{Role: "user", Content: strings.Repeat("a", 20)}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		req  *types.LLMRequest
+		want bool
+	}{
+		{
+			name: "short prompt",
+			req:  &types.LLMRequest{Prompt: "123"},
+			want: false,
+		},
+		{
+			name: "long prompt",
+			req:  &types.LLMRequest{Prompt: "12345678901"},
+			want: true,
+		},
+		{
+			name: "long chat completion",
+			req:  chatRequest,
+			want: true,
+		},
+	}
+
+	d := NewPromptLengthDecider(10)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), test.req, nil, 0)
+			if got := d.Disaggregate(ctx); got != test.want {
+				t.Errorf("Unexpected decision, want %t, got %t", test.want, got)
+			}
+		})
+	}
+}
+
+func TestCacheAwareDecider(t *testing.T) {
+	blockSize := 16
+	newDecider := func() *CacheAwareDecider {
+		return NewCacheAwareDecider(&CacheAwareConfig{
+			UncachedTokensThreshold: 8,
+			PrefillLoadRatio:        2,
+			PrefixStoreConfig:       &scorer.PrefixStoreConfig{CacheSize: 100, BlockSize: blockSize, BlockCacheSize: 10},
+		})
+	}
+
+	prefill := newPod("prefill", backendmetrics.Prefill, 0)
+	busyPrefill := newPod("busy-prefill", backendmetrics.Prefill, 10)
+	decode := newPod("decode", backendmetrics.Decode, 1)
+	both := newPod("both", backendmetrics.Both, 0)
+
+	// 8 blocks, estimated to 32 tokens.
+	prompt := strings.Repeat("0123456789abcdef", 8)
+
+	tests := []struct {
+		name string
+		pods []types.Pod
+		// cachedOn is the pod the prompt was previously scheduled to, if any.
+		cachedOn types.Pod
+		// cachedChars is the length of the prompt prefix previously scheduled.
+		cachedChars int
+		want        bool
+	}{
+		{
+			name: "uncached prompt",
+			pods: []types.Pod{prefill, decode},
+			want: true,
+		},
+		{
+			name:        "prompt mostly cached on a decode pod",
+			pods:        []types.Pod{prefill, decode},
+			cachedOn:    both,
+			cachedChars: len(prompt),
+			want:        true, // cached on a pod absent from the pool
+		},
+		{
+			name:        "prompt mostly cached on a pod of the pool",
+			pods:        []types.Pod{prefill, decode, both},
+			cachedOn:    both,
+			cachedChars: len(prompt),
+			want:        false,
+		},
+		{
+			name:        "prompt partly cached on a pod of the pool",
+			pods:        []types.Pod{prefill, decode, both},
+			cachedOn:    both,
+			cachedChars: 4 * blockSize,
+			want:        true,
+		},
+		{
+			name: "overloaded prefill pods",
+			pods: []types.Pod{busyPrefill, decode},
+			want: false,
+		},
+		{
+			name: "no prefill pods",
+			pods: []types.Pod{decode, both},
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := newDecider()
+			if test.cachedOn != nil {
+				cachedReq := &types.LLMRequest{Model: "model", Prompt: prompt[:test.cachedChars]}
+				ctx := types.NewSchedulingContext(context.Background(), cachedReq, test.pods, 0)
+				d.PostSchedule(ctx, &types.Result{TargetPod: test.cachedOn})
+			}
+
+			req := &types.LLMRequest{Model: "model", Prompt: prompt}
+			ctx := types.NewSchedulingContext(context.Background(), req, test.pods, 0)
+			if got := d.Disaggregate(ctx); got != test.want {
+				t.Errorf("Unexpected decision, want %t, got %t", test.want, got)
+			}
+		})
+	}
+}
+
+func TestCacheAwareDeciderPrefixStore(t *testing.T) {
+	storeConfig := &scorer.PrefixStoreConfig{CacheSize: 100, BlockSize: 16, BlockCacheSize: 10}
+	prefill := newPod("prefill", backendmetrics.Prefill, 0)
+	decode := newPod("decode", backendmetrics.Decode, 0)
+	pods := []types.Pod{prefill, decode}
+	prompt := strings.Repeat("0123456789abcdef", 8)
+	req := &types.LLMRequest{Model: "model", Prompt: prompt}
+	removed := datastore.Event{Type: datastore.PodRemoved, Pod: decode.GetPod()}
+
+	// The decider relies on the prefixes added to a shared store by its owner, which purges it.
+	shared := scorer.NewPrefixStore(storeConfig)
+	d := NewCacheAwareDecider(&CacheAwareConfig{UncachedTokensThreshold: 8, PrefillLoadRatio: 2, PrefixStore: shared})
+	if err := shared.AddEntry("model", prompt, &decode.GetPod().NamespacedName); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	d.OnPodEvent(removed)
+	if d.Disaggregate(types.NewSchedulingContext(context.Background(), req, pods, 0)) {
+		t.Error("Unexpected disaggregation of a prompt cached in the shared store")
+	}
+
+	// The decider purges the prefixes of removed pods from its own store.
+	d = NewCacheAwareDecider(&CacheAwareConfig{UncachedTokensThreshold: 8, PrefillLoadRatio: 2, PrefixStoreConfig: storeConfig})
+	d.PostSchedule(types.NewSchedulingContext(context.Background(), req, pods, 0), &types.Result{TargetPod: decode})
+	if d.Disaggregate(types.NewSchedulingContext(context.Background(), req, pods, 0)) {
+		t.Error("Unexpected disaggregation of a cached prompt")
+	}
+	d.OnPodEvent(removed)
+	if !d.Disaggregate(types.NewSchedulingContext(context.Background(), req, pods, 0)) {
+		t.Error("Expected the disaggregation of a prompt cached on a removed pod")
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package decider implements the policies deciding whether requests are disaggregated into a
+// prefill stage and a decode stage.
+package decider
+
+import (
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// PromptLengthDecider disaggregates requests whose prompt, or flattened chat completion messages,
+// is at least threshold characters long.
+type PromptLengthDecider struct {
+	threshold int
+}
+
+var _ plugins.DisaggregationDecider = &PromptLengthDecider{}
+
+// NewPromptLengthDecider creates a new PromptLengthDecider with the given threshold in characters.
+func NewPromptLengthDecider(threshold int) *PromptLengthDecider {
+	return &PromptLengthDecider{threshold: threshold}
+}
+
+func (d *PromptLengthDecider) Name() string {
+	return "prompt-length-decider"
+}
+
+func (d *PromptLengthDecider) Disaggregate(ctx *types.SchedulingContext) bool {
+	return ctx.Req != nil && len(ctx.PromptText()) >= d.threshold
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	errutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/error"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// contextLengthErrorMargin is the relative error of the estimated number of tokens of a text prompt,
+// as the actual number of characters per token varies with the text and the tokenizer.
+const contextLengthErrorMargin = 0.25
+
+// ContextLengthFilter keeps the pods whose maximum context length fits the request prompt plus its
+// maximum number of generated tokens, avoiding pods on which the model server would reject the
+// request.
+//
+// Pods with an unknown maximum context length are kept. When no pod can fit the request, it is
+// rejected as a bad request, since no amount of capacity would let the pool serve it. As the number
+// of tokens of text prompts is estimated, the request is only rejected if it does not fit within the
+// error margin of the estimate, and is otherwise kept on the pods of the longest context.
+type ContextLengthFilter struct{}
+
+var _ plugins.Filter = &ContextLengthFilter{}
+
+func (f *ContextLengthFilter) Name() string {
+	return "context-length-filter"
+}
+
+func (f *ContextLengthFilter) Filter(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+	if ctx.Req == nil {
+		return pods
+	}
+
+	required := ctx.Req.EstimatedPromptTokens() + ctx.Req.MaxTokens
+	// Only the tokens estimated from the text are uncertain, the token IDs and max tokens are exact.
+	margin := int(float64(types.EstimatedTokens(len(ctx.Req.PromptText()))) * contextLengthErrorMargin)
+	filtered := []types.Pod{}
+	longest := 0
+	for _, pod := range pods {
+		maxModelLen := podMaxModelLen(pod)
+		if maxModelLen == 0 || maxModelLen >= required {
+			filtered = append(filtered, pod)
+		}
+		longest = max(longest, maxModelLen)
+	}
+	if len(filtered) > 0 || len(pods) == 0 {
+		return filtered
+	}
+	if required-margin <= longest {
+		ctx.Logger.V(logutil.DEBUG).Info("Request context may fit within the error margin of the estimate",
+			"requiredTokens", required, "margin", margin, "longestContext", longest)
+		for _, pod := range pods {
+			if podMaxModelLen(pod) == longest {
+				filtered = append(filtered, pod)
+			}
+		}
+		return filtered
+	}
+
+	ctx.Logger.V(logutil.DEBUG).Info("No pod can fit the request context", "requiredTokens", required, "longestContext", longest)
+	ctx.Rejection = errutil.Error{
+		Code: errutil.ContextLengthExceeded,
+		Msg: fmt.Sprintf("request of about %d tokens, including max_tokens, exceeds the maximum context length of all pods (%d tokens)",
+			required, longest),
+	}
+	return filtered
+}
+
+// podMaxModelLen returns the maximum context length of the pod, scraped or else configured on the
+// pod, and 0 if unknown.
+func podMaxModelLen(pod types.Pod) int {
+	if maxModelLen := pod.GetMetrics().MaxModelLen; maxModelLen > 0 {
+		return maxModelLen
+	}
+	return pod.GetPod().MaxModelLen
+}
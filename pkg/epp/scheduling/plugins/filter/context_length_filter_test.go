@@ -0,0 +1,107 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	errutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/error"
+)
+
+func TestContextLengthFilter(t *testing.T) {
+	newPod := func(name string, labelMaxModelLen, scrapedMaxModelLen int) *types.PodMetrics {
+		return &types.PodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}, MaxModelLen: labelMaxModelLen},
+			Metrics: &backendmetrics.Metrics{MaxModelLen: scrapedMaxModelLen},
+		}
+	}
+	long := newPod("long", 131072, 0)
+	short := newPod("short", 0, 8192)
+	scrapedShort := newPod("scraped-short", 131072, 8192) // the scraped length takes precedence
+	unknown := newPod("unknown", 0, 0)
+
+	// 40000 characters, estimated to 10000 tokens.
+	longPrompt := strings.Repeat("a", 40000)
+
+	tests := []struct {
+		name       string
+		req        *types.LLMRequest
+		input      []types.Pod
+		output     []types.Pod
+		wantReject bool
+	}{
+		{
+			name:   "short requests fit all pods",
+			req:    &types.LLMRequest{Prompt: "hello", MaxTokens: 100},
+			input:  []types.Pod{long, short, unknown},
+			output: []types.Pod{long, short, unknown},
+		},
+		{
+			name:   "long prompts are kept off short context pods",
+			req:    &types.LLMRequest{Prompt: longPrompt},
+			input:  []types.Pod{long, short, scrapedShort, unknown},
+			output: []types.Pod{long, unknown},
+		},
+		{
+			name:   "max tokens counts towards the context",
+			req:    &types.LLMRequest{Prompt: "hello", MaxTokens: 10000},
+			input:  []types.Pod{long, short},
+			output: []types.Pod{long},
+		},
+		{
+			name:   "request fitting within the error margin is kept on the longest context pods",
+			req:    &types.LLMRequest{Prompt: longPrompt},
+			input:  []types.Pod{short, newPod("shorter", 4096, 0)},
+			output: []types.Pod{short},
+		},
+		{
+			name:       "request of token IDs exceeding the context is rejected",
+			req:        &types.LLMRequest{PromptTokens: 8193},
+			input:      []types.Pod{short},
+			output:     []types.Pod{},
+			wantReject: true,
+		},
+		{
+			name:       "request no pod can fit is rejected",
+			req:        &types.LLMRequest{Prompt: longPrompt, MaxTokens: 200000},
+			input:      []types.Pod{long, short},
+			output:     []types.Pod{},
+			wantReject: true,
+		},
+	}
+
+	filter := &ContextLengthFilter{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), test.req, test.input, 0)
+			got := filter.Filter(ctx, test.input)
+
+			if diff := cmp.Diff(test.output, got); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+			if rejected := errutil.CanonicalCode(ctx.Rejection) == errutil.ContextLengthExceeded; rejected != test.wantReject {
+				t.Errorf("Unexpected rejection, want %t, got %v", test.wantReject, ctx.Rejection)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/config"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/yaml"
+)
+
+// FilterFactory creates a filter from its parameters, which are nil if not configured.
+type FilterFactory func(parameters json.RawMessage) (plugins.Filter, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]FilterFactory{
+		"low-queue": func(parameters json.RawMessage) (plugins.Filter, error) {
+			params := struct {
+				Threshold int `json:"threshold"`
+			}{Threshold: config.Conf.QueueingThresholdLoRA}
+			if err := unmarshalParameters(parameters, &params); err != nil {
+				return nil, err
+			}
+			return &baseFilter{name: LowQueueFilter.name, filter: toFilterFunc(queueThresholdPredicate(params.Threshold))}, nil
+		},
+		"least-queue":    staticFilterFactory(LeastQueueFilter),
+		"least-kv-cache": staticFilterFactory(LeastKVCacheFilter),
+		"lora-affinity": func(parameters json.RawMessage) (plugins.Filter, error) {
+			params := struct {
+				Threshold float64 `json:"threshold"`
+			}{Threshold: config.Conf.LoraAffinityThreshold}
+			if err := unmarshalParameters(parameters, &params); err != nil {
+				return nil, err
+			}
+			return &baseFilter{name: LoRAAffinityFilter.name, filter: loRASoftAffinity(params.Threshold)}, nil
+		},
+		"has-capacity": func(parameters json.RawMessage) (plugins.Filter, error) {
+			params := struct {
+				QueueThreshold   int     `json:"queueThreshold"`
+				KVCacheThreshold float64 `json:"kvCacheThreshold"`
+			}{QueueThreshold: config.Conf.QueueThresholdCritical, KVCacheThreshold: config.Conf.KVCacheThreshold}
+			if err := unmarshalParameters(parameters, &params); err != nil {
+				return nil, err
+			}
+			return &baseFilter{
+				name:   HasCapacityFilter.name,
+				filter: toFilterFunc(queueThresholdPredicate(params.QueueThreshold).and(kvCacheThresholdPredicate(params.KVCacheThreshold))),
+			}, nil
+		},
+		"prefill": staticFilterFactory(PrefillFilter),
+		"decode":  staticFilterFactory(DecodeFilter),
+		"encode":  staticFilterFactory(EncodeFilter),
+	}
+)
+
+// RegisterFilter registers a filter factory under the given name, so that decision trees can
+// reference it. A filter registered under an existing name replaces it.
+func RegisterFilter(name string, factory FilterFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+func staticFilterFactory(filter plugins.Filter) FilterFactory {
+	return func(parameters json.RawMessage) (plugins.Filter, error) {
+		if len(parameters) > 0 {
+			return nil, fmt.Errorf("filter %s takes no parameters", filter.Name())
+		}
+		return filter, nil
+	}
+}
+
+func unmarshalParameters(parameters json.RawMessage, params any) error {
+	if len(parameters) == 0 {
+		return nil
+	}
+	return json.Unmarshal(parameters, params)
+}
+
+// DecisionTreeConfig declares named decision trees of filters, e.g.:
+//
+//	trees:
+//	  critical:
+//	    filter: low-queue
+//	    parameters:
+//	      threshold: 64
+//	    nextOnSuccessOrFailure:
+//	      filter: least-kv-cache
+//	  sheddable:
+//	    filter: has-capacity
+//	    nextOnSuccess:
+//	      tree: critical
+type DecisionTreeConfig struct {
+	Trees map[string]*DecisionTreeSpec `json:"trees"`
+}
+
+// DecisionTreeSpec declares a node of a decision tree, see DecisionTreeFilter.
+type DecisionTreeSpec struct {
+	// Filter is the registered name of the filter of the node.
+	Filter string `json:"filter,omitempty"`
+	// Parameters are the parameters of the filter.
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+	// Tree is the name of another tree of the configuration used as the filter of the node. Exactly
+	// one of Filter and Tree must be set.
+	Tree string `json:"tree,omitempty"`
+
+	NextOnSuccess          *DecisionTreeSpec `json:"nextOnSuccess,omitempty"`
+	NextOnFailure          *DecisionTreeSpec `json:"nextOnFailure,omitempty"`
+	NextOnSuccessOrFailure *DecisionTreeSpec `json:"nextOnSuccessOrFailure,omitempty"`
+}
+
+// ParseDecisionTrees parses a YAML, or JSON, DecisionTreeConfig and builds its trees.
+func ParseDecisionTrees(data []byte) (map[string]plugins.Filter, error) {
+	cfg := &DecisionTreeConfig{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse decision trees - %w", err)
+	}
+	return BuildDecisionTrees(cfg)
+}
+
+// BuildDecisionTrees builds the trees of the configuration, referenced by name. It fails if a tree
+// references an unregistered filter or an undeclared tree, or if trees reference each other in a
+// cycle.
+func BuildDecisionTrees(cfg *DecisionTreeConfig) (map[string]plugins.Filter, error) {
+	b := &treeBuilder{
+		specs:    cfg.Trees,
+		trees:    make(map[string]plugins.Filter, len(cfg.Trees)),
+		building: map[string]bool{},
+	}
+	names := make([]string, 0, len(cfg.Trees))
+	for name := range cfg.Trees {
+		names = append(names, name)
+	}
+	sort.Strings(names) // report errors deterministically
+	for _, name := range names {
+		if _, err := b.buildTree(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return b.trees, nil
+}
+
+type treeBuilder struct {
+	specs map[string]*DecisionTreeSpec
+	trees map[string]plugins.Filter
+	// building holds the trees being built, to detect cycles.
+	building map[string]bool
+}
+
+// buildTree builds the named tree, given the path of the trees referencing it.
+func (b *treeBuilder) buildTree(name string, path []string) (plugins.Filter, error) {
+	if tree, ok := b.trees[name]; ok {
+		return tree, nil
+	}
+	path = append(path, name)
+	if b.building[name] {
+		return nil, fmt.Errorf("decision trees reference each other in a cycle: %s", strings.Join(path, " -> "))
+	}
+	spec, ok := b.specs[name]
+	if !ok || spec == nil {
+		return nil, fmt.Errorf("decision tree %q is not declared", name)
+	}
+
+	b.building[name] = true
+	tree, err := b.buildNode(spec, path)
+	if err != nil {
+		return nil, fmt.Errorf("decision tree %q: %w", name, err)
+	}
+	delete(b.building, name)
+	b.trees[name] = tree
+	return tree, nil
+}
+
+func (b *treeBuilder) buildNode(spec *DecisionTreeSpec, path []string) (plugins.Filter, error) {
+	if spec == nil {
+		return nil, nil
+	}
+
+	var current plugins.Filter
+	var err error
+	switch {
+	case spec.Filter != "" && spec.Tree != "":
+		return nil, fmt.Errorf("node sets both filter %q and tree %q", spec.Filter, spec.Tree)
+	case spec.Filter != "":
+		registryMu.RLock()
+		factory, ok := registry[spec.Filter]
+		registryMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("filter %q is not registered", spec.Filter)
+		}
+		if current, err = factory(spec.Parameters); err != nil {
+			return nil, fmt.Errorf("invalid parameters of filter %q - %w", spec.Filter, err)
+		}
+	case spec.Tree != "":
+		if len(spec.Parameters) > 0 {
+			return nil, fmt.Errorf("tree %q takes no parameters", spec.Tree)
+		}
+		if current, err = b.buildTree(spec.Tree, path); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("node sets neither a filter nor a tree")
+	}
+
+	if spec.NextOnSuccess == nil && spec.NextOnFailure == nil && spec.NextOnSuccessOrFailure == nil {
+		return current, nil
+	}
+	tree := &DecisionTreeFilter{Current: current}
+	if tree.NextOnSuccess, err = b.buildNode(spec.NextOnSuccess, path); err != nil {
+		return nil, err
+	}
+	if tree.NextOnFailure, err = b.buildNode(spec.NextOnFailure, path); err != nil {
+		return nil, err
+	}
+	if tree.NextOnSuccessOrFailure, err = b.buildNode(spec.NextOnSuccessOrFailure, path); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
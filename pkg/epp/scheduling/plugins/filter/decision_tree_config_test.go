@@ -0,0 +1,162 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestParseDecisionTrees(t *testing.T) {
+	idle := &types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "idle"}},
+		Metrics: &backendmetrics.Metrics{WaitingQueueSize: 0, KVCacheUsagePercent: 0.5},
+	}
+	empty := &types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "empty"}},
+		Metrics: &backendmetrics.Metrics{WaitingQueueSize: 0, KVCacheUsagePercent: 0.1},
+	}
+	busy := &types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "busy"}},
+		Metrics: &backendmetrics.Metrics{WaitingQueueSize: 20, KVCacheUsagePercent: 0.1},
+	}
+	pods := []types.Pod{idle, empty, busy}
+
+	data := `
+trees:
+  critical:
+    filter: low-queue
+    parameters:
+      threshold: 10
+    nextOnSuccessOrFailure:
+      filter: least-kv-cache
+  sheddable:
+    filter: has-capacity
+    parameters:
+      queueThreshold: 5
+      kvCacheThreshold: 0.3
+    nextOnSuccess:
+      tree: critical
+`
+	trees, err := ParseDecisionTrees([]byte(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		tree   string
+		input  []types.Pod
+		output []types.Pod
+	}{
+		{
+			name:   "critical",
+			tree:   "critical",
+			input:  pods,
+			output: []types.Pod{empty},
+		},
+		{
+			name:   "sheddable references critical",
+			tree:   "sheddable",
+			input:  pods,
+			output: []types.Pod{empty},
+		},
+		{
+			name:   "sheddable without capacity",
+			tree:   "sheddable",
+			input:  []types.Pod{idle, busy},
+			output: []types.Pod{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, test.input, 0)
+			got := trees[test.tree].Filter(ctx, test.input)
+			if diff := cmp.Diff(test.output, got); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestParseDecisionTreesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "unknown filter",
+			data: "trees: {a: {filter: no-such-filter}}",
+			want: `filter "no-such-filter" is not registered`,
+		},
+		{
+			name: "unknown tree",
+			data: "trees: {a: {filter: least-queue, nextOnFailure: {tree: b}}}",
+			want: `decision tree "b" is not declared`,
+		},
+		{
+			name: "cycle",
+			data: "trees: {a: {tree: b}, b: {filter: least-queue, nextOnSuccess: {tree: a}}}",
+			want: "cycle: a -> b -> a",
+		},
+		{
+			name: "self reference",
+			data: "trees: {a: {filter: least-queue, nextOnSuccess: {tree: a}}}",
+			want: "cycle: a -> a",
+		},
+		{
+			name: "filter and tree",
+			data: "trees: {a: {filter: least-queue, tree: b}, b: {filter: decode}}",
+			want: "sets both filter",
+		},
+		{
+			name: "neither filter nor tree",
+			data: "trees: {a: {nextOnSuccess: {filter: decode}}}",
+			want: "neither a filter nor a tree",
+		},
+		{
+			name: "unexpected parameters",
+			data: "trees: {a: {filter: decode, parameters: {threshold: 1}}}",
+			want: "takes no parameters",
+		},
+		{
+			name: "invalid parameters",
+			data: "trees: {a: {filter: low-queue, parameters: {threshold: high}}}",
+			want: `invalid parameters of filter "low-queue"`,
+		},
+		{
+			name: "unknown field",
+			data: "trees: {a: {filter: decode, onSuccess: {filter: prefill}}}",
+			want: "failed to parse decision trees",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseDecisionTrees([]byte(test.data))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Expected error containing %q, got %v", test.want, err)
+			}
+		})
+	}
+}
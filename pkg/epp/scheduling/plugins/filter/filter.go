@@ -131,29 +131,38 @@ var LeastQueueFilter = &baseFilter{
 // we should consider them all instead of the absolute minimum one. This worked better than picking
 // the least one as it gives more choices for the next filter, which on aggregate gave better
 // results.
+// Queue sizes are normalized by the capacity of the pods, so that pods on faster accelerators are
+// allowed longer queues.
 // TODO: Compare this strategy with other strategies such as top K.
 func leastQueuingFilterFunc(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
-	min := math.MaxInt
-	max := 0
+	min := math.MaxFloat64
+	var max float64 = 0
 	filtered := []types.Pod{}
 
 	for _, pod := range pods {
-		if pod.GetMetrics().WaitingQueueSize <= min {
-			min = pod.GetMetrics().WaitingQueueSize
+		queue := normalizedWaitingQueueSize(pod)
+		if queue <= min {
+			min = queue
 		}
-		if pod.GetMetrics().WaitingQueueSize >= max {
-			max = pod.GetMetrics().WaitingQueueSize
+		if queue >= max {
+			max = queue
 		}
 	}
 
 	for _, pod := range pods {
-		if pod.GetMetrics().WaitingQueueSize >= min && pod.GetMetrics().WaitingQueueSize <= min+(max-min)/len(pods) {
+		queue := normalizedWaitingQueueSize(pod)
+		if queue >= min && queue <= min+(max-min)/float64(len(pods)) {
 			filtered = append(filtered, pod)
 		}
 	}
 	return filtered
 }
 
+// normalizedWaitingQueueSize returns the waiting queue size of the pod divided by its capacity.
+func normalizedWaitingQueueSize(pod types.Pod) float64 {
+	return float64(pod.GetMetrics().WaitingQueueSize) / pod.GetPod().CapacityWeight()
+}
+
 var LowQueueFilter = &baseFilter{
 	name:   "low queueing filter",
 	filter: toFilterFunc((queueThresholdPredicate(config.Conf.QueueingThresholdLoRA))),
@@ -214,6 +223,18 @@ var LoRAAffinityFilter = &baseFilter{
 //   - Filtered slice of pod metrics based on affinity and availability
 //   - Error if any issues occur during filtering
 func loRASoftAffinityFilterFunc(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+	return loRASoftAffinity(config.Conf.LoraAffinityThreshold)(ctx, pods)
+}
+
+// loRASoftAffinity returns the loRASoftAffinityFilterFunc strategy with the given probability of
+// selecting pods with affinity.
+func loRASoftAffinity(affinityThreshold float64) filterFunc {
+	return func(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+		return loRASoftAffinityFilter(ctx, pods, affinityThreshold)
+	}
+}
+
+func loRASoftAffinityFilter(ctx *types.SchedulingContext, pods []types.Pod, affinityThreshold float64) []types.Pod {
 
 	// Pre-allocate slices with estimated capacity
 	filtered_affinity := make([]types.Pod, 0, len(pods))
@@ -237,7 +258,7 @@ func loRASoftAffinityFilterFunc(ctx *types.SchedulingContext, pods []types.Pod)
 
 	// If both groups have pods, use probability to select which group to return
 	if len(filtered_affinity) > 0 && len(filtered_available) > 0 {
-		if randGen.Float64() < config.Conf.LoraAffinityThreshold {
+		if randGen.Float64() < affinityThreshold {
 			return filtered_affinity
 		}
 		return filtered_available
@@ -259,9 +280,11 @@ var HasCapacityFilter = &baseFilter{
 // podPredicate is a filter function to check whether a pod is desired.
 type podPredicate func(req *types.LLMRequest, pod types.Pod) bool
 
+// queueThresholdPredicate checks the waiting queue size of the pod against the threshold, scaled by
+// the capacity of the pod.
 func queueThresholdPredicate(queueThreshold int) podPredicate {
 	return func(req *types.LLMRequest, pod types.Pod) bool {
-		return pod.GetMetrics().WaitingQueueSize <= queueThreshold
+		return normalizedWaitingQueueSize(pod) <= float64(queueThreshold)
 	}
 }
 
@@ -108,6 +108,37 @@ func TestFilterFunc(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "least queuing normalized by capacity",
+			f:    leastQueuingFilterFunc,
+			input: []types.Pod{
+				&types.PodMetrics{
+					Pod: &backendmetrics.Pod{Capacity: 4},
+					Metrics: &backendmetrics.Metrics{
+						WaitingQueueSize: 8,
+					},
+				},
+				&types.PodMetrics{
+					Pod: &backendmetrics.Pod{Capacity: 1},
+					Metrics: &backendmetrics.Metrics{
+						WaitingQueueSize: 6,
+					},
+				},
+				&types.PodMetrics{
+					Metrics: &backendmetrics.Metrics{
+						WaitingQueueSize: 5,
+					},
+				},
+			},
+			output: []types.Pod{
+				&types.PodMetrics{
+					Pod: &backendmetrics.Pod{Capacity: 4},
+					Metrics: &backendmetrics.Metrics{
+						WaitingQueueSize: 8,
+					},
+				},
+			},
+		},
 		{
 			name:   "least kv cache empty input",
 			f:      leastKVCacheFilterFunc,
@@ -182,6 +213,34 @@ func TestFilterFunc(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "queue threshold scaled by capacity",
+			f:    toFilterFunc(queueThresholdPredicate(2)),
+			input: []types.Pod{
+				&types.PodMetrics{
+					// Below the threshold scaled by the capacity, should return.
+					Pod: &backendmetrics.Pod{Capacity: 2},
+					Metrics: &backendmetrics.Metrics{
+						WaitingQueueSize: 4,
+					},
+				},
+				&types.PodMetrics{
+					// Above the threshold, should not return.
+					Pod: &backendmetrics.Pod{Capacity: 1},
+					Metrics: &backendmetrics.Metrics{
+						WaitingQueueSize: 4,
+					},
+				},
+			},
+			output: []types.Pod{
+				&types.PodMetrics{
+					Pod: &backendmetrics.Pod{Capacity: 2},
+					Metrics: &backendmetrics.Metrics{
+						WaitingQueueSize: 4,
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
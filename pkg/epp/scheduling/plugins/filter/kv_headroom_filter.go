@@ -0,0 +1,74 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// DefaultKVHeadroomMaxTokens is the number of generated tokens assumed for requests that do not set
+// a maximum.
+const DefaultKVHeadroomMaxTokens = 256
+
+// KVHeadroomFilter keeps the pods with enough free KV-cache tokens to hold the request prompt and
+// its maximum number of generated tokens, avoiding pods on which the request would be preempted.
+//
+// Pods with an unknown KV-cache capacity are kept. When no pod has enough headroom, sheddable
+// requests are dropped by returning no pods, while other requests are passed all the pods.
+type KVHeadroomFilter struct {
+	defaultMaxTokens int
+}
+
+var _ plugins.Filter = &KVHeadroomFilter{}
+
+// NewKVHeadroomFilter creates a new KVHeadroomFilter, assuming the given number of generated tokens
+// for requests that do not set a maximum.
+func NewKVHeadroomFilter(defaultMaxTokens int) *KVHeadroomFilter {
+	return &KVHeadroomFilter{defaultMaxTokens: defaultMaxTokens}
+}
+
+func (f *KVHeadroomFilter) Name() string {
+	return "kv-headroom-filter"
+}
+
+func (f *KVHeadroomFilter) Filter(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+	if ctx.Req == nil {
+		return pods
+	}
+
+	required := ctx.Req.RequiredKVCacheTokens(f.defaultMaxTokens)
+	filtered := []types.Pod{}
+	for _, pod := range pods {
+		free, known := pod.GetMetrics().FreeKVCacheTokens()
+		if !known || free >= required {
+			filtered = append(filtered, pod)
+		}
+	}
+	if len(filtered) > 0 {
+		return filtered
+	}
+
+	loggerDebug := ctx.Logger.V(logutil.DEBUG)
+	if ctx.Req.Sheddable {
+		loggerDebug.Info("No pod has enough KV-cache headroom, dropping sheddable request", "requiredTokens", required)
+		return filtered
+	}
+	loggerDebug.Info("No pod has enough KV-cache headroom, serving on a best effort basis", "requiredTokens", required)
+	return pods
+}
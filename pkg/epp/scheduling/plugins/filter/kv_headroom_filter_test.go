@@ -0,0 +1,93 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestKVHeadroomFilter(t *testing.T) {
+	newPod := func(name string, capacity int, usage float64) *types.PodMetrics {
+		return &types.PodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}},
+			Metrics: &backendmetrics.Metrics{KvCacheMaxTokenCapacity: capacity, KVCacheUsagePercent: usage},
+		}
+	}
+	roomy := newPod("roomy", 10000, 0.5) // 5000 free tokens
+	tight := newPod("tight", 10000, 0.9) // 1000 free tokens
+	unknown := newPod("unknown", 0, 0.9)
+
+	// 400 characters, estimated to 100 tokens.
+	prompt := strings.Repeat("a", 400)
+
+	tests := []struct {
+		name   string
+		req    *types.LLMRequest
+		input  []types.Pod
+		output []types.Pod
+	}{
+		{
+			name:   "pods with enough headroom are kept",
+			req:    &types.LLMRequest{Prompt: prompt, MaxTokens: 2000},
+			input:  []types.Pod{roomy, tight},
+			output: []types.Pod{roomy},
+		},
+		{
+			name:   "default max tokens is used when the request does not set it",
+			req:    &types.LLMRequest{Prompt: prompt},
+			input:  []types.Pod{roomy, tight},
+			output: []types.Pod{roomy, tight},
+		},
+		{
+			name:   "pods with unknown capacity are kept",
+			req:    &types.LLMRequest{Prompt: prompt, MaxTokens: 2000},
+			input:  []types.Pod{tight, unknown},
+			output: []types.Pod{unknown},
+		},
+		{
+			name:   "sheddable request is dropped when no pod has enough headroom",
+			req:    &types.LLMRequest{Prompt: prompt, MaxTokens: 8000, Sheddable: true},
+			input:  []types.Pod{roomy, tight},
+			output: []types.Pod{},
+		},
+		{
+			name:   "other requests are served on a best effort basis",
+			req:    &types.LLMRequest{Prompt: prompt, MaxTokens: 8000},
+			input:  []types.Pod{roomy, tight},
+			output: []types.Pod{roomy, tight},
+		},
+	}
+
+	filter := NewKVHeadroomFilter(DefaultKVHeadroomMaxTokens)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), test.req, test.input, 0)
+			got := filter.Filter(ctx, test.input)
+
+			if diff := cmp.Diff(test.output, got); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
@@ -57,3 +57,22 @@ func decodeFilterFunc(ctx *types.SchedulingContext, pods []types.Pod) []types.Po
 
 	return filteredPods
 }
+
+// EncodeFilter - filters out all pods that are not marked as encode pod role
+var EncodeFilter = &baseFilter{
+	name:   "encode_filter",
+	filter: encodeFilterFunc,
+}
+
+// encodeFilterFunc filters out all pods that are not marked as "encode"
+func encodeFilterFunc(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+	filteredPods := make([]types.Pod, 0)
+
+	for _, pod := range pods {
+		if pod.GetPod().Role == metrics.Encode {
+			filteredPods = append(filteredPods, pod)
+		}
+	}
+
+	return filteredPods
+}
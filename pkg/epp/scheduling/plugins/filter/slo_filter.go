@@ -0,0 +1,88 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// LatencyPredictor predicts the latencies of requests on pods.
+type LatencyPredictor interface {
+	// PredictLatencies returns the latencies the request is predicted to be served with on the given
+	// pods. Pods without a prediction are omitted.
+	PredictLatencies(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]types.LatencyPrediction
+}
+
+// SLOFilter keeps the pods predicted to meet the latency objectives of the request.
+//
+// Pods without a prediction are kept, as nothing is known about them yet. When no pod is predicted
+// to meet the objectives, sheddable requests are dropped by returning no pods, while other requests
+// are passed all the pods and queue on the model servers.
+type SLOFilter struct {
+	predictor LatencyPredictor
+}
+
+var _ plugins.Filter = &SLOFilter{}
+
+// NewSLOFilter creates a new SLOFilter with the given latency predictor.
+func NewSLOFilter(predictor LatencyPredictor) *SLOFilter {
+	return &SLOFilter{predictor: predictor}
+}
+
+func (f *SLOFilter) Name() string {
+	return "slo-filter"
+}
+
+func (f *SLOFilter) Filter(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+	if ctx.Req == nil || !ctx.Req.HasObjectives() {
+		return pods
+	}
+	loggerDebug := ctx.Logger.V(logutil.DEBUG)
+
+	predictions := f.predictor.PredictLatencies(ctx, pods)
+	filtered := []types.Pod{}
+	for _, pod := range pods {
+		prediction, ok := predictions[pod]
+		if !ok || meetsObjectives(ctx.Req, prediction) {
+			filtered = append(filtered, pod)
+		}
+	}
+	if len(filtered) > 0 {
+		return filtered
+	}
+
+	if ctx.Req.Sheddable {
+		loggerDebug.Info("No pod is predicted to meet the latency objectives, dropping sheddable request",
+			"ttftObjective", ctx.Req.TTFTObjective, "tpotObjective", ctx.Req.TPOTObjective)
+		return filtered
+	}
+	loggerDebug.Info("No pod is predicted to meet the latency objectives, serving on a best effort basis",
+		"ttftObjective", ctx.Req.TTFTObjective, "tpotObjective", ctx.Req.TPOTObjective)
+	return pods
+}
+
+func meetsObjectives(req *types.LLMRequest, prediction types.LatencyPrediction) bool {
+	if req.TTFTObjective > 0 && prediction.TTFT > req.TTFTObjective {
+		return false
+	}
+	if req.TPOTObjective > 0 && prediction.TPOT > req.TPOTObjective {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+type fakeLatencyPredictor map[string]types.LatencyPrediction
+
+func (p fakeLatencyPredictor) PredictLatencies(_ *types.SchedulingContext, pods []types.Pod) map[types.Pod]types.LatencyPrediction {
+	predictions := make(map[types.Pod]types.LatencyPrediction)
+	for _, pod := range pods {
+		if prediction, ok := p[pod.GetPod().NamespacedName.Name]; ok {
+			predictions[pod] = prediction
+		}
+	}
+	return predictions
+}
+
+func TestSLOFilter(t *testing.T) {
+	fast := &types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "fast"}}}
+	slowTTFT := &types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "slow-ttft"}}}
+	slowTPOT := &types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "slow-tpot"}}}
+	unknown := &types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "unknown"}}}
+
+	predictor := fakeLatencyPredictor{
+		"fast":      {TTFT: 100 * time.Millisecond, TPOT: 10 * time.Millisecond},
+		"slow-ttft": {TTFT: time.Second, TPOT: 10 * time.Millisecond},
+		"slow-tpot": {TTFT: 100 * time.Millisecond, TPOT: 100 * time.Millisecond},
+	}
+	objectives := func(sheddable bool) *types.LLMRequest {
+		return &types.LLMRequest{TTFTObjective: 500 * time.Millisecond, TPOTObjective: 50 * time.Millisecond, Sheddable: sheddable}
+	}
+
+	tests := []struct {
+		name   string
+		req    *types.LLMRequest
+		input  []types.Pod
+		output []types.Pod
+	}{
+		{
+			name:   "no objectives",
+			req:    &types.LLMRequest{},
+			input:  []types.Pod{fast, slowTTFT, slowTPOT},
+			output: []types.Pod{fast, slowTTFT, slowTPOT},
+		},
+		{
+			name:   "pods violating an objective are filtered out",
+			req:    objectives(false),
+			input:  []types.Pod{fast, slowTTFT, slowTPOT},
+			output: []types.Pod{fast},
+		},
+		{
+			name:   "only the TTFT objective is enforced",
+			req:    &types.LLMRequest{TTFTObjective: 500 * time.Millisecond},
+			input:  []types.Pod{fast, slowTTFT, slowTPOT},
+			output: []types.Pod{fast, slowTPOT},
+		},
+		{
+			name:   "pods without predictions are kept",
+			req:    objectives(true),
+			input:  []types.Pod{slowTTFT, unknown},
+			output: []types.Pod{unknown},
+		},
+		{
+			name:   "sheddable request is dropped when no pod meets the objectives",
+			req:    objectives(true),
+			input:  []types.Pod{slowTTFT, slowTPOT},
+			output: []types.Pod{},
+		},
+		{
+			name:   "other requests are served on a best effort basis",
+			req:    objectives(false),
+			input:  []types.Pod{slowTTFT, slowTPOT},
+			output: []types.Pod{slowTTFT, slowTPOT},
+		},
+	}
+
+	filter := NewSLOFilter(predictor)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), test.req, test.input, 0)
+			got := filter.Filter(ctx, test.input)
+
+			if diff := cmp.Diff(test.output, got); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/config"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// unknownZone is the zone reported in metrics for gateways and pods whose zone is unknown.
+const unknownZone = "unknown"
+
+// ZoneLocalityConfig contains initialization configuration for ZoneLocalityFilter.
+type ZoneLocalityConfig struct {
+	// QueueThreshold is the waiting queue size, normalized by the pod capacity, from which a pod has
+	// no capacity left.
+	QueueThreshold int
+	// KVCacheThreshold is the KV-cache usage from which a pod has no capacity left.
+	KVCacheThreshold float64
+}
+
+// DefaultZoneLocalityConfig returns a ZoneLocalityConfig instance with default configuration.
+func DefaultZoneLocalityConfig() *ZoneLocalityConfig {
+	return &ZoneLocalityConfig{
+		QueueThreshold:   config.Conf.QueueThresholdCritical,
+		KVCacheThreshold: config.Conf.KVCacheThreshold,
+	}
+}
+
+// ZoneLocalityFilter keeps the pods in the zone of the gateway that received the request, to avoid
+// cross-zone traffic. Requests spill over to the pods of other zones only when no pod of the
+// gateway zone has capacity left, and are passed all the pods when no pod has capacity at all.
+//
+// Requests from a gateway of unknown zone are not filtered.
+type ZoneLocalityFilter struct {
+	hasCapacity podPredicate
+}
+
+var _ plugins.Filter = &ZoneLocalityFilter{}
+
+// NewZoneLocalityFilter creates a new ZoneLocalityFilter with the given configuration.
+// If the config is nil, default is used.
+func NewZoneLocalityFilter(cfg *ZoneLocalityConfig) *ZoneLocalityFilter {
+	if cfg == nil {
+		cfg = DefaultZoneLocalityConfig()
+	}
+	return &ZoneLocalityFilter{
+		hasCapacity: queueThresholdPredicate(cfg.QueueThreshold).and(kvCacheThresholdPredicate(cfg.KVCacheThreshold)),
+	}
+}
+
+func (f *ZoneLocalityFilter) Name() string {
+	return "zone-locality-filter"
+}
+
+func (f *ZoneLocalityFilter) Filter(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+	if ctx.Req == nil || ctx.Req.GatewayZone == "" {
+		return pods
+	}
+
+	local := []types.Pod{}
+	remote := []types.Pod{}
+	for _, pod := range pods {
+		if !f.hasCapacity(ctx.Req, pod) {
+			continue
+		}
+		if pod.GetPod().Zone == ctx.Req.GatewayZone {
+			local = append(local, pod)
+		} else {
+			remote = append(remote, pod)
+		}
+	}
+
+	loggerDebug := ctx.Logger.V(logutil.DEBUG)
+	switch {
+	case len(local) > 0:
+		return local
+	case len(remote) > 0:
+		loggerDebug.Info("No pod with capacity in the gateway zone, spilling over to other zones", "zone", ctx.Req.GatewayZone)
+		return remote
+	default:
+		loggerDebug.Info("No pod with capacity, ignoring zones", "zone", ctx.Req.GatewayZone)
+		return pods
+	}
+}
+
+// ZoneRoutingRecorder counts the routed requests by the zone of the gateway and of the selected pod.
+// A single recorder is configured when the zone locality filter, scorer or both are enabled.
+type ZoneRoutingRecorder struct{}
+
+var _ plugins.PostSchedule = &ZoneRoutingRecorder{}
+
+func (r *ZoneRoutingRecorder) Name() string {
+	return "zone-routing-recorder"
+}
+
+// PostSchedule counts the request by the zone of the gateway and of the selected pod.
+func (r *ZoneRoutingRecorder) PostSchedule(ctx *types.SchedulingContext, res *types.Result) {
+	if ctx.Req == nil || res == nil || res.TargetPod == nil {
+		return
+	}
+	metrics.RecordZoneRouting(zoneOrUnknown(ctx.Req.GatewayZone), zoneOrUnknown(res.TargetPod.GetPod().Zone))
+}
+
+func zoneOrUnknown(zone string) string {
+	if zone == "" {
+		return unknownZone
+	}
+	return zone
+}
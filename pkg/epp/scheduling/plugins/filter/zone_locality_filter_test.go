@@ -0,0 +1,85 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestZoneLocalityFilter(t *testing.T) {
+	newPod := func(name, zone string, waiting int) *types.PodMetrics {
+		return &types.PodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}, Zone: zone},
+			Metrics: &backendmetrics.Metrics{WaitingQueueSize: waiting, KVCacheUsagePercent: 0.2},
+		}
+	}
+	localIdle := newPod("local-idle", "zone-a", 0)
+	localBusy := newPod("local-busy", "zone-a", 10)
+	remoteIdle := newPod("remote-idle", "zone-b", 0)
+	remoteBusy := newPod("remote-busy", "zone-b", 10)
+	unknownIdle := newPod("unknown-idle", "", 0)
+
+	tests := []struct {
+		name   string
+		req    *types.LLMRequest
+		input  []types.Pod
+		output []types.Pod
+	}{
+		{
+			name:   "pods in the gateway zone are kept",
+			req:    &types.LLMRequest{GatewayZone: "zone-a"},
+			input:  []types.Pod{localIdle, localBusy, remoteIdle, unknownIdle},
+			output: []types.Pod{localIdle},
+		},
+		{
+			name:   "spill over to other zones when the gateway zone has no capacity",
+			req:    &types.LLMRequest{GatewayZone: "zone-a"},
+			input:  []types.Pod{localBusy, remoteIdle, remoteBusy, unknownIdle},
+			output: []types.Pod{remoteIdle, unknownIdle},
+		},
+		{
+			name:   "all pods are kept when no pod has capacity",
+			req:    &types.LLMRequest{GatewayZone: "zone-a"},
+			input:  []types.Pod{localBusy, remoteBusy},
+			output: []types.Pod{localBusy, remoteBusy},
+		},
+		{
+			name:   "requests from a gateway of unknown zone are not filtered",
+			req:    &types.LLMRequest{},
+			input:  []types.Pod{localIdle, localBusy, remoteIdle},
+			output: []types.Pod{localIdle, localBusy, remoteIdle},
+		},
+	}
+
+	filter := NewZoneLocalityFilter(&ZoneLocalityConfig{QueueThreshold: 5, KVCacheThreshold: 0.8})
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), test.req, test.input, 0)
+			got := filter.Filter(ctx, test.input)
+
+			if diff := cmp.Diff(test.output, got); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
@@ -17,16 +17,21 @@ limitations under the License.
 package plugins
 
 import (
+	"context"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
 )
 
 const (
-	PreSchedulerPluginType = "PreSchedule"
-	FilterPluginType       = "Filter"
-	ScorerPluginType       = "Scorer"
-	PostSchedulePluginType = "PostSchedule"
-	PickerPluginType       = "Picker"
-	PostResponsePluginType = "PostResponse"
+	PreSchedulerPluginType   = "PreSchedule"
+	FilterPluginType         = "Filter"
+	ScorerPluginType         = "Scorer"
+	PostSchedulePluginType   = "PostSchedule"
+	PickerPluginType         = "Picker"
+	PostResponsePluginType   = "PostResponse"
+	PostCompletionPluginType = "PostCompletion"
+	DisaggregationPluginType = "Disaggregation"
 )
 
 // Plugin defines the interface for scheduler plugins, combining scoring, filtering,
@@ -74,3 +79,38 @@ type PostResponse interface {
 	Plugin
 	PostResponse(ctx *types.SchedulingContext, pod types.Pod)
 }
+
+// PostCompletion is called by the scheduler once the response of a request was fully received, with
+// the latencies observed for the request. The given pod argument is the pod that served the request.
+type PostCompletion interface {
+	Plugin
+	PostCompletion(ctx *types.SchedulingContext, pod types.Pod, latency *types.ResponseLatency)
+}
+
+// DisaggregationDecider decides whether a request is split into a prefill stage and a decode stage
+// served by different pods, or served entirely by a single pod.
+type DisaggregationDecider interface {
+	Plugin
+	Disaggregate(ctx *types.SchedulingContext) bool
+}
+
+// Initializer is called once when the scheduler starts, before the pod events are delivered. It can
+// be used to load or warm the plugin state. An error fails the start of the scheduler.
+type Initializer interface {
+	Plugin
+	Init(ctx context.Context) error
+}
+
+// PodEventHandler is called with the pod events of the datastore, i.e., pods added, updated and
+// removed, starting with an added event per existing pod. Stateful plugins use it to purge or warm
+// the state of pods. Events are delivered in order on a dedicated goroutine.
+type PodEventHandler interface {
+	Plugin
+	OnPodEvent(event datastore.Event)
+}
+
+// Shutdowner is called once when the scheduler stops, to release the plugin resources.
+type Shutdowner interface {
+	Plugin
+	Shutdown()
+}
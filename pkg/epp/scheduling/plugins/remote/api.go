@@ -0,0 +1,185 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package remote provides scheduler plugins delegating to an external process over gRPC, so that
+// scheduling logic can be added without compiling it into the EPP.
+//
+// The external process implements the RemotePlugin gRPC service, whose messages are JSON encoded
+// with the "json" gRPC content-subtype (application/grpc+json):
+//
+//	service epp.scheduling.v1alpha1.RemotePlugin {
+//	  rpc Score(ScheduleRequest) returns (ScoreResponse);
+//	  rpc Filter(ScheduleRequest) returns (FilterResponse);
+//	}
+//
+// Go processes can implement the Server interface and register it with RegisterServer.
+package remote
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+const (
+	// ServiceName is the full name of the gRPC service implemented by remote plugins.
+	ServiceName = "epp.scheduling.v1alpha1.RemotePlugin"
+
+	scoreMethod  = "/" + ServiceName + "/Score"
+	filterMethod = "/" + ServiceName + "/Filter"
+)
+
+// ScheduleRequest is sent to remote plugins with the request being scheduled and the candidate
+// pods.
+type ScheduleRequest struct {
+	Request RequestSummary `json:"request"`
+	Pods    []PodSummary   `json:"pods"`
+}
+
+// RequestSummary summarizes the request being scheduled.
+type RequestSummary struct {
+	ID          string `json:"id,omitempty"`
+	Model       string `json:"model"`
+	TargetModel string `json:"targetModel"`
+	Critical    bool   `json:"critical,omitempty"`
+	Sheddable   bool   `json:"sheddable,omitempty"`
+	// Headers are the request headers allowed by the configuration of the plugin.
+	Headers map[string]string `json:"headers,omitempty"`
+	// PromptTokens is the estimated number of tokens of the prompt.
+	PromptTokens int `json:"promptTokens"`
+	MaxTokens    int `json:"maxTokens,omitempty"`
+}
+
+// PodSummary summarizes a candidate pod and its latest metrics.
+type PodSummary struct {
+	// Name is the namespaced name of the pod, "<namespace>/<name>", by which responses reference it.
+	Name                    string   `json:"name"`
+	Address                 string   `json:"address"`
+	Role                    string   `json:"role"`
+	Zone                    string   `json:"zone,omitempty"`
+	WaitingQueueSize        int      `json:"waitingQueueSize"`
+	RunningQueueSize        int      `json:"runningQueueSize"`
+	KVCacheUsagePercent     float64  `json:"kvCacheUsagePercent"`
+	KVCacheMaxTokenCapacity int      `json:"kvCacheMaxTokenCapacity,omitempty"`
+	ActiveModels            []string `json:"activeModels,omitempty"`
+}
+
+// ScoreResponse holds the scores of the pods, within the range of [0,1], by pod name. Pods without
+// a score are not scored.
+type ScoreResponse struct {
+	Scores map[string]float64 `json:"scores"`
+}
+
+// FilterResponse holds the names of the pods passing the filter.
+type FilterResponse struct {
+	Pods []string `json:"pods"`
+}
+
+// Server is the interface implemented by remote plugins written in Go.
+type Server interface {
+	Score(ctx context.Context, req *ScheduleRequest) (*ScoreResponse, error)
+	Filter(ctx context.Context, req *ScheduleRequest) (*FilterResponse, error)
+}
+
+// RegisterServer registers a remote plugin implementation with the given gRPC server.
+func RegisterServer(s *grpc.Server, srv Server) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*Server)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Score",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				req := &ScheduleRequest{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				handler := func(ctx context.Context, req any) (any, error) {
+					return srv.(Server).Score(ctx, req.(*ScheduleRequest))
+				}
+				if interceptor == nil {
+					return handler(ctx, req)
+				}
+				return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: scoreMethod}, handler)
+			},
+		},
+		{
+			MethodName: "Filter",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				req := &ScheduleRequest{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				handler := func(ctx context.Context, req any) (any, error) {
+					return srv.(Server).Filter(ctx, req.(*ScheduleRequest))
+				}
+				if interceptor == nil {
+					return handler(ctx, req)
+				}
+				return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: filterMethod}, handler)
+			},
+		},
+	},
+}
+
+// newScheduleRequest summarizes the scheduling context and the candidate pods for remote plugins,
+// with the request headers of the given allow-list, by lower case name.
+func newScheduleRequest(ctx *types.SchedulingContext, pods []types.Pod, headers map[string]bool) *ScheduleRequest {
+	req := &ScheduleRequest{Pods: make([]PodSummary, 0, len(pods))}
+	if ctx.Req != nil {
+		req.Request = RequestSummary{
+			ID:           ctx.Req.RequestID,
+			Model:        ctx.Req.Model,
+			TargetModel:  ctx.Req.ResolvedTargetModel,
+			Critical:     ctx.Req.Critical,
+			Sheddable:    ctx.Req.Sheddable,
+			PromptTokens: ctx.Req.EstimatedPromptTokens(),
+			MaxTokens:    ctx.Req.MaxTokens,
+		}
+		for name, value := range ctx.Req.Headers {
+			if headers[strings.ToLower(name)] {
+				if req.Request.Headers == nil {
+					req.Request.Headers = map[string]string{}
+				}
+				req.Request.Headers[name] = value
+			}
+		}
+	}
+	for _, pod := range pods {
+		metrics := pod.GetMetrics()
+		activeModels := make([]string, 0, len(metrics.ActiveModels))
+		for model := range metrics.ActiveModels {
+			activeModels = append(activeModels, model)
+		}
+		req.Pods = append(req.Pods, PodSummary{
+			Name:                    pod.GetPod().NamespacedName.String(),
+			Address:                 pod.GetPod().Address,
+			Role:                    pod.GetPod().Role.String(),
+			Zone:                    pod.GetPod().Zone,
+			WaitingQueueSize:        metrics.WaitingQueueSize,
+			RunningQueueSize:        metrics.RunningQueueSize,
+			KVCacheUsagePercent:     metrics.KVCacheUsagePercent,
+			KVCacheMaxTokenCapacity: metrics.KvCacheMaxTokenCapacity,
+			ActiveModels:            activeModels,
+		})
+	}
+	return req
+}
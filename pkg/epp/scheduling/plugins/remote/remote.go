@@ -0,0 +1,230 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// DefaultTimeout is the default deadline of the calls to remote plugins.
+const DefaultTimeout = 50 * time.Millisecond
+
+// Config contains initialization configuration for remote plugins.
+type Config struct {
+	// Name identifies the plugin, e.g., in logs and in the plugin latency metric.
+	Name string
+	// Address is the gRPC target of the plugin, e.g., "unix:///var/run/plugin.sock" or
+	// "plugin.default.svc:9000".
+	Address string
+	// Timeout is the deadline of each call to the plugin. The scheduling falls back when exceeded.
+	Timeout time.Duration
+	// FailClosed makes a failing remote filter drop all pods rather than pass them all through.
+	FailClosed bool
+	// Headers is the allow-list of request headers sent to the plugin, case insensitive. Other
+	// headers, e.g., authorization and cookie, are never sent.
+	Headers []string
+	// TLS secures the connection to the plugin, which is otherwise in plaintext. Nil for plaintext.
+	TLS *TLSConfig
+}
+
+// TLSConfig configures the TLS connection to a remote plugin.
+type TLSConfig struct {
+	// CAFile is the PEM file of the certificate authorities verifying the plugin certificate, the
+	// system ones if empty.
+	CAFile string
+	// CertFile and KeyFile are the PEM files of the client certificate and key, for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name verified in the plugin certificate, the address host if empty.
+	ServerName string
+}
+
+// client calls a remote plugin.
+type client struct {
+	name       string
+	conn       *grpc.ClientConn
+	timeout    time.Duration
+	failClosed bool
+	headers    map[string]bool
+}
+
+func newClient(cfg *Config) (*client, error) {
+	if cfg.Name == "" || cfg.Address == "" {
+		return nil, errors.New("remote plugin name and address are required")
+	}
+	creds := insecure.NewCredentials()
+	if cfg.TLS != nil {
+		tlsConfig, err := cfg.TLS.load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS configuration of remote plugin %s - %w", cfg.Name, err)
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	// The connection is established lazily, and re-established on failures, by the first calls.
+	conn, err := grpc.NewClient(cfg.Address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(jsonCodec{})))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client of remote plugin %s - %w", cfg.Name, err)
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	headers := make(map[string]bool, len(cfg.Headers))
+	for _, header := range cfg.Headers {
+		headers[strings.ToLower(header)] = true
+	}
+	return &client{name: cfg.Name, conn: conn, timeout: timeout, failClosed: cfg.FailClosed, headers: headers}, nil
+}
+
+// load returns the TLS configuration of the client, reading its certificate files.
+func (c *TLSConfig) load() (*tls.Config, error) {
+	tlsConfig := &tls.Config{ServerName: c.ServerName, MinVersion: tls.VersionTLS12}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", c.CAFile)
+		}
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func (c *client) call(ctx *types.SchedulingContext, method string, pods []types.Pod, res any) error {
+	callCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.conn.Invoke(callCtx, method, newScheduleRequest(ctx, pods, c.headers), res)
+}
+
+// Close closes the connection to the remote plugin.
+func (c *client) Close() error {
+	return c.conn.Close()
+}
+
+// Shutdown implements the Shutdowner interface, closing the connection when the scheduler stops.
+func (c *client) Shutdown() {
+	_ = c.Close()
+}
+
+// Scorer scores pods by calling a remote plugin. When the call fails or exceeds its deadline, the
+// pods are not scored, leaving the decision to the other scorers.
+type Scorer struct {
+	*client
+}
+
+var _ plugins.Scorer = &Scorer{}
+var _ plugins.Shutdowner = &Scorer{}
+
+// NewScorer creates a new Scorer calling the remote plugin with the given configuration.
+func NewScorer(cfg *Config) (*Scorer, error) {
+	c, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Scorer{client: c}, nil
+}
+
+func (s *Scorer) Name() string {
+	return "remote-scorer/" + s.name
+}
+
+func (s *Scorer) Score(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
+	res := &ScoreResponse{}
+	if err := s.call(ctx, scoreMethod, pods, res); err != nil {
+		ctx.Logger.V(logutil.DEFAULT).Error(err, "Remote scorer failed, skipping scoring", "plugin", s.name)
+		return nil
+	}
+
+	scoredPods := make(map[types.Pod]float64, len(res.Scores))
+	for _, pod := range pods {
+		if score, ok := res.Scores[pod.GetPod().NamespacedName.String()]; ok {
+			scoredPods[pod] = min(max(score, 0), 1)
+		}
+	}
+	return scoredPods
+}
+
+// Filter filters pods by calling a remote plugin. When the call fails or exceeds its deadline, all
+// the pods pass the filter, unless configured to fail closed.
+type Filter struct {
+	*client
+}
+
+var _ plugins.Filter = &Filter{}
+var _ plugins.Shutdowner = &Filter{}
+
+// NewFilter creates a new Filter calling the remote plugin with the given configuration.
+func NewFilter(cfg *Config) (*Filter, error) {
+	c, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{client: c}, nil
+}
+
+func (f *Filter) Name() string {
+	return "remote-filter/" + f.name
+}
+
+func (f *Filter) Filter(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+	res := &FilterResponse{}
+	if err := f.call(ctx, filterMethod, pods, res); err != nil {
+		if f.failClosed {
+			ctx.Logger.V(logutil.DEFAULT).Error(err, "Remote filter failed, filtering out all pods", "plugin", f.name)
+			return []types.Pod{}
+		}
+		ctx.Logger.V(logutil.DEFAULT).Error(err, "Remote filter failed, skipping filtering", "plugin", f.name)
+		return pods
+	}
+
+	kept := make(map[string]bool, len(res.Pods))
+	for _, name := range res.Pods {
+		kept[name] = true
+	}
+	filtered := []types.Pod{}
+	for _, pod := range pods {
+		if kept[pod.GetPod().NamespacedName.String()] {
+			filtered = append(filtered, pod)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// fakeServer prefers idle pods: it scores pods by their waiting queue, and filters out busy pods.
+// It records the headers of the last scored request.
+type fakeServer struct {
+	delay   time.Duration
+	headers map[string]string
+}
+
+func (s *fakeServer) Score(ctx context.Context, req *ScheduleRequest) (*ScoreResponse, error) {
+	time.Sleep(s.delay)
+	s.headers = req.Request.Headers
+	res := &ScoreResponse{Scores: map[string]float64{}}
+	for _, pod := range req.Pods {
+		res.Scores[pod.Name] = 1 / float64(1+pod.WaitingQueueSize)
+	}
+	res.Scores["default/gone"] = 1 // pods that are not candidates are ignored
+	return res, nil
+}
+
+func (s *fakeServer) Filter(ctx context.Context, req *ScheduleRequest) (*FilterResponse, error) {
+	time.Sleep(s.delay)
+	res := &FilterResponse{}
+	for _, pod := range req.Pods {
+		if pod.WaitingQueueSize == 0 && req.Request.Model == "my-model" {
+			res.Pods = append(res.Pods, pod.Name)
+		}
+	}
+	return res, nil
+}
+
+func startServer(t *testing.T, srv Server) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "plugin.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve(t, lis, grpc.NewServer(), srv)
+	return "unix://" + socket
+}
+
+func serve(t *testing.T, lis net.Listener, s *grpc.Server, srv Server) {
+	t.Helper()
+	RegisterServer(s, srv)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	t.Cleanup(s.Stop)
+}
+
+func TestRemotePlugins(t *testing.T) {
+	newPod := func(name string, waiting int) *types.PodMetrics {
+		return &types.PodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name, Namespace: "default"}},
+			Metrics: &backendmetrics.Metrics{WaitingQueueSize: waiting},
+		}
+	}
+	idle := newPod("idle", 0)
+	busy := newPod("busy", 3)
+	pods := []types.Pod{idle, busy}
+
+	fast := startServer(t, &fakeServer{})
+	slow := startServer(t, &fakeServer{delay: 200 * time.Millisecond})
+
+	tests := []struct {
+		name       string
+		address    string
+		failClosed bool
+		wantScores map[types.Pod]float64
+		wantPods   []types.Pod
+	}{
+		{
+			name:       "remote plugin answers",
+			address:    fast,
+			wantScores: map[types.Pod]float64{idle: 1, busy: 0.25},
+			wantPods:   []types.Pod{idle},
+		},
+		{
+			name:       "remote plugin times out",
+			address:    slow,
+			wantScores: nil,
+			wantPods:   pods,
+		},
+		{
+			name:       "remote filter fails closed",
+			address:    slow,
+			failClosed: true,
+			wantScores: nil,
+			wantPods:   []types.Pod{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &Config{Name: "test", Address: test.address, Timeout: 100 * time.Millisecond, FailClosed: test.failClosed}
+			scorer, err := NewScorer(cfg)
+			if err != nil {
+				t.Fatalf("Failed to create scorer: %v", err)
+			}
+			defer func() { _ = scorer.Close() }()
+			filter, err := NewFilter(cfg)
+			if err != nil {
+				t.Fatalf("Failed to create filter: %v", err)
+			}
+			defer func() { _ = filter.Close() }()
+
+			ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{Model: "my-model"}, pods, 0)
+			if diff := cmp.Diff(test.wantScores, scorer.Score(ctx, pods)); diff != "" {
+				t.Errorf("Unexpected scores (-want +got): %v", diff)
+			}
+			if diff := cmp.Diff(test.wantPods, filter.Filter(ctx, pods)); diff != "" {
+				t.Errorf("Unexpected pods (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestRemotePluginHeaders(t *testing.T) {
+	srv := &fakeServer{}
+	scorer, err := NewScorer(&Config{Name: "test", Address: startServer(t, srv), Timeout: time.Second, Headers: []string{"X-User-Tier"}})
+	if err != nil {
+		t.Fatalf("Failed to create scorer: %v", err)
+	}
+	defer func() { _ = scorer.Close() }()
+
+	req := &types.LLMRequest{Model: "my-model", Headers: map[string]string{
+		"x-user-tier":   "gold",
+		"authorization": "Bearer secret",
+		"cookie":        "session=secret",
+	}}
+	pods := []types.Pod{&types.PodMetrics{Pod: &backendmetrics.Pod{}, Metrics: &backendmetrics.Metrics{}}}
+	if scores := scorer.Score(types.NewSchedulingContext(context.Background(), req, pods, 0), pods); scores == nil {
+		t.Fatal("Expected the remote scorer to answer")
+	}
+	if diff := cmp.Diff(map[string]string{"x-user-tier": "gold"}, srv.headers); diff != "" {
+		t.Errorf("Unexpected headers (-want +got): %v", diff)
+	}
+}
+
+func TestRemotePluginTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeSelfSignedCertificate(t, certFile, keyFile)
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve(t, lis, grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}}))), &fakeServer{})
+
+	pods := []types.Pod{&types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "idle", Namespace: "default"}},
+		Metrics: &backendmetrics.Metrics{},
+	}}
+	tests := []struct {
+		name       string
+		tls        *TLSConfig
+		wantScores map[types.Pod]float64
+	}{
+		{
+			name:       "trusted certificate",
+			tls:        &TLSConfig{CAFile: certFile, ServerName: "localhost"},
+			wantScores: map[types.Pod]float64{pods[0]: 1},
+		},
+		{
+			name: "plaintext",
+		},
+		{
+			name: "untrusted certificate",
+			tls:  &TLSConfig{ServerName: "localhost"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scorer, err := NewScorer(&Config{Name: "test", Address: lis.Addr().String(), Timeout: time.Second, TLS: test.tls})
+			if err != nil {
+				t.Fatalf("Failed to create scorer: %v", err)
+			}
+			defer func() { _ = scorer.Close() }()
+
+			ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{Model: "my-model"}, pods, 0)
+			if diff := cmp.Diff(test.wantScores, scorer.Score(ctx, pods)); diff != "" {
+				t.Errorf("Unexpected scores (-want +got): %v", diff)
+			}
+		})
+	}
+
+	if _, err := NewScorer(&Config{Name: "test", Address: lis.Addr().String(), TLS: &TLSConfig{CAFile: keyFile}}); err == nil {
+		t.Error("Expected an error for a CA file without certificates")
+	}
+}
+
+// writeSelfSignedCertificate writes a self-signed certificate of localhost and its key in PEM.
+func writeSelfSignedCertificate(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,302 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+const (
+	consistentHashScorerName = "consistent-hash-scorer"
+
+	// HashKeyHeaderPrefix selects a request header as the hash key, e.g., "header:x-user-id".
+	// Header names are case insensitive.
+	HashKeyHeaderPrefix = "header:"
+	// HashKeyUserField selects the OpenAI "user" request body field as the hash key.
+	HashKeyUserField = "body:user"
+
+	defaultHashLoadFactor   = 0.25
+	defaultHashVirtualNodes = 100
+)
+
+// ConsistentHashConfig contains initialization configuration for ConsistentHashScorer.
+type ConsistentHashConfig struct {
+	// Keys lists the request keys to hash, in order of preference. The first key present in the
+	// request is used. Supported keys are "header:<name>" and "body:user".
+	Keys []string
+	// LoadFactor bounds the load of a pod to (1+LoadFactor) times the average load of the pods.
+	// Requests whose pod is above the bound spill over to the next pod on the ring.
+	LoadFactor float64
+	// VirtualNodes is the number of points each pod has on the ring.
+	VirtualNodes int
+}
+
+// DefaultConsistentHashConfig returns a ConsistentHashConfig instance with default configuration.
+func DefaultConsistentHashConfig() *ConsistentHashConfig {
+	return &ConsistentHashConfig{
+		Keys:         []string{HashKeyHeaderPrefix + "x-user-id", HashKeyUserField},
+		LoadFactor:   defaultHashLoadFactor,
+		VirtualNodes: defaultHashVirtualNodes,
+	}
+}
+
+// ParseHashKeys parses a comma separated list of hash keys and validates them.
+func ParseHashKeys(keys string) ([]string, error) {
+	res := []string{}
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if key != HashKeyUserField && (!strings.HasPrefix(key, HashKeyHeaderPrefix) || key == HashKeyHeaderPrefix) {
+			return nil, fmt.Errorf("invalid hash key %q", key)
+		}
+		res = append(res, normalizeHashKey(key))
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no hash keys in %q", keys)
+	}
+	return res, nil
+}
+
+// normalizeHashKey lowercases the name of a header key, as the request headers are lowercase.
+func normalizeHashKey(key string) string {
+	if !strings.HasPrefix(key, HashKeyHeaderPrefix) {
+		return key
+	}
+	return HashKeyHeaderPrefix + strings.ToLower(strings.TrimPrefix(key, HashKeyHeaderPrefix))
+}
+
+// ConsistentHashScorer pins requests sharing the same key (e.g., a user or a conversation) to the
+// same pod, by hashing the key onto a consistent hash ring of the pool's pods. Scaling the pool
+// only remaps the keys of the added or removed pods.
+//
+// The hashing is bounded by load: a pod loaded beyond (1+LoadFactor) times the average load is
+// skipped and the request goes to the next pod on the ring, so hot keys cannot overload a pod.
+// The selected pod scores 1, all other pods score 0. Requests without a key are not scored.
+// The ring is built from the pods of the first scored request and rebuilt after pods are added
+// or removed.
+type ConsistentHashScorer struct {
+	keys         []string
+	loadFactor   float64
+	virtualNodes int
+
+	mu   sync.RWMutex
+	ring *hashRing
+	// generation counts the pod events invalidating the ring, so that a ring built from the pods
+	// seen before an event is not stored after it.
+	generation uint64
+}
+
+var _ plugins.Scorer = &ConsistentHashScorer{}
+var _ plugins.PodEventHandler = &ConsistentHashScorer{}
+
+// NewConsistentHashScorer creates a new ConsistentHashScorer with the given configuration.
+// If the config is nil, default is used.
+func NewConsistentHashScorer(config *ConsistentHashConfig) *ConsistentHashScorer {
+	if config == nil {
+		config = DefaultConsistentHashConfig()
+	}
+	virtualNodes := config.VirtualNodes
+	if virtualNodes <= 0 {
+		virtualNodes = defaultHashVirtualNodes
+	}
+	keys := make([]string, 0, len(config.Keys))
+	for _, key := range config.Keys {
+		keys = append(keys, normalizeHashKey(key))
+	}
+	return &ConsistentHashScorer{
+		keys:         keys,
+		loadFactor:   config.LoadFactor,
+		virtualNodes: virtualNodes,
+	}
+}
+
+func (s *ConsistentHashScorer) Name() string {
+	return consistentHashScorerName
+}
+
+// Score gives the score 1 to the pod the request key maps to, and 0 to the rest of the pods.
+func (s *ConsistentHashScorer) Score(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
+	loggerDebug := log.FromContext(ctx).WithName(consistentHashScorerName).V(logutil.DEBUG)
+	if ctx.Req == nil || len(pods) == 0 {
+		return nil
+	}
+
+	key := s.requestKey(ctx.Req)
+	if key == "" {
+		loggerDebug.Info("Request has no hash key, skipping scoring")
+		return nil
+	}
+
+	// The ring spans all pods of the pool rather than the filtered candidates, so that filtering
+	// does not remap keys.
+	ringPods := ctx.PodsSnapshot
+	if len(ringPods) == 0 {
+		ringPods = pods
+	}
+	ring := s.getRing(ringPods)
+
+	candidates := make(map[string]types.Pod, len(pods))
+	totalLoad := 0
+	for _, pod := range pods {
+		candidates[pod.GetPod().NamespacedName.String()] = pod
+		totalLoad += podLoad(pod)
+	}
+	// Account for the incoming request when computing the bound, so an idle pool has a bound of 1.
+	bound := int(math.Ceil((1 + s.loadFactor) * float64(totalLoad+1) / float64(len(pods))))
+
+	selected := ring.lookup(xxhash.Sum64String(key), func(name string) bool {
+		pod, ok := candidates[name]
+		return ok && podLoad(pod) < bound
+	})
+	if selected == "" {
+		// All candidates are above the bound, fall back to the first candidate on the ring.
+		selected = ring.lookup(xxhash.Sum64String(key), func(name string) bool {
+			_, ok := candidates[name]
+			return ok
+		})
+	}
+
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for name, pod := range candidates {
+		if name == selected {
+			scoredPods[pod] = 1.0
+		} else {
+			scoredPods[pod] = 0.0
+		}
+	}
+	loggerDebug.Info("Hashed request key", "selected", selected, "bound", bound)
+	return scoredPods
+}
+
+// requestKey returns the value of the first configured key present in the request.
+func (s *ConsistentHashScorer) requestKey(req *types.LLMRequest) string {
+	for _, key := range s.keys {
+		if key == HashKeyUserField {
+			if req.User != "" {
+				return req.User
+			}
+			continue
+		}
+		if value := req.Headers[strings.TrimPrefix(key, HashKeyHeaderPrefix)]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// podLoad returns the number of requests on the pod.
+func podLoad(pod types.Pod) int {
+	return pod.GetMetrics().WaitingQueueSize + pod.GetMetrics().RunningQueueSize
+}
+
+// getRing returns the current ring, building it from the given pods if it was invalidated.
+func (s *ConsistentHashScorer) getRing(pods []types.Pod) *hashRing {
+	s.mu.RLock()
+	ring, generation := s.ring, s.generation
+	s.mu.RUnlock()
+	if ring != nil {
+		return ring
+	}
+
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.GetPod().NamespacedName.String())
+	}
+	ring = newHashRing(names, s.virtualNodes)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.generation != generation {
+		// The pods changed while building the ring, the next request rebuilds it.
+		return ring
+	}
+	if s.ring == nil {
+		s.ring = ring
+	}
+	return s.ring
+}
+
+// OnPodEvent implements the PodEventHandler interface.
+// It invalidates the ring when pods are added or removed, the next request rebuilds it.
+func (s *ConsistentHashScorer) OnPodEvent(event datastore.Event) {
+	if event.Type != datastore.PodAdded && event.Type != datastore.PodRemoved {
+		return
+	}
+	s.mu.Lock()
+	s.ring = nil
+	s.generation++
+	s.mu.Unlock()
+}
+
+// hashRing is an immutable consistent hash ring.
+type hashRing struct {
+	points []uint64
+	owners map[uint64]string
+}
+
+func newHashRing(names []string, virtualNodes int) *hashRing {
+	ring := &hashRing{
+		points: make([]uint64, 0, len(names)*virtualNodes),
+		owners: make(map[uint64]string, len(names)*virtualNodes),
+	}
+	for _, name := range names {
+		for i := 0; i < virtualNodes; i++ {
+			point := xxhash.Sum64String(name + "#" + strconv.Itoa(i))
+			if _, taken := ring.owners[point]; taken {
+				continue
+			}
+			ring.owners[point] = name
+			ring.points = append(ring.points, point)
+		}
+	}
+	sort.Slice(ring.points, func(i, j int) bool { return ring.points[i] < ring.points[j] })
+	return ring
+}
+
+// lookup walks the ring clockwise from the given hash and returns the first pod accepted by the
+// given predicate, or an empty string if none is.
+func (r *hashRing) lookup(hash uint64, accept func(name string) bool) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	start := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+	visited := make(map[string]bool)
+	for i := 0; i < len(r.points); i++ {
+		name := r.owners[r.points[(start+i)%len(r.points)]]
+		if visited[name] {
+			continue
+		}
+		if accept(name) {
+			return name
+		}
+		visited[name] = true
+	}
+	return ""
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/scorer"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// hashSelected returns the name of the pod selected by the scorer for the given user.
+func hashSelected(t *testing.T, s *scorer.ConsistentHashScorer, user string, pods []types.Pod) string {
+	t.Helper()
+	sCtx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{User: user}, pods, 0)
+	selected := ""
+	for pod, score := range s.Score(sCtx, pods) {
+		if score == 1.0 {
+			if selected != "" {
+				t.Fatalf("More than one pod selected for user %q", user)
+			}
+			selected = pod.GetPod().NamespacedName.Name
+		}
+	}
+	return selected
+}
+
+func TestConsistentHashScorerStickiness(t *testing.T) {
+	s := scorer.NewConsistentHashScorer(nil)
+	pods := []types.Pod{newSessionTestPod("pod1", 0), newSessionTestPod("pod2", 0), newSessionTestPod("pod3", 0)}
+
+	used := map[string]bool{}
+	for i := 0; i < 30; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		first := hashSelected(t, s, user, pods)
+		if first == "" {
+			t.Fatalf("No pod selected for user %q", user)
+		}
+		if again := hashSelected(t, s, user, pods); again != first {
+			t.Errorf("User %q moved from %s to %s", user, first, again)
+		}
+		used[first] = true
+	}
+	if len(used) != len(pods) {
+		t.Errorf("Expected keys to spread over all %d pods, got %v", len(pods), used)
+	}
+}
+
+func TestConsistentHashScorerHeaderKey(t *testing.T) {
+	keys, err := scorer.ParseHashKeys("header:X-Conversation-ID, body:user")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s := scorer.NewConsistentHashScorer(&scorer.ConsistentHashConfig{Keys: keys, LoadFactor: 0.25, VirtualNodes: 100})
+	pods := []types.Pod{newSessionTestPod("pod1", 0), newSessionTestPod("pod2", 0)}
+
+	if got := s.Score(types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, pods, 0), pods); got != nil {
+		t.Errorf("Expected a request without key not to be scored, got %v", got)
+	}
+
+	req := &types.LLMRequest{Headers: map[string]string{"x-conversation-id": "abc"}, User: "ignored"}
+	byHeader := s.Score(types.NewSchedulingContext(context.Background(), req, pods, 0), pods)
+	if len(byHeader) != len(pods) {
+		t.Errorf("Expected all pods to be scored, got %v", byHeader)
+	}
+
+	// Header keys are case insensitive, the configured header matches the lowercase request header.
+	mixedCase := scorer.NewConsistentHashScorer(&scorer.ConsistentHashConfig{Keys: []string{"header:X-Conversation-ID"}})
+	if got := mixedCase.Score(types.NewSchedulingContext(context.Background(), req, pods, 0), pods); len(got) != len(pods) {
+		t.Errorf("Expected all pods to be scored by the mixed case header key, got %v", got)
+	}
+
+	for _, invalid := range []string{"", "header:", "body:model", "x-user-id"} {
+		if _, err := scorer.ParseHashKeys(invalid); err == nil {
+			t.Errorf("Expected an error for hash keys %q", invalid)
+		}
+	}
+}
+
+func TestConsistentHashScorerBoundedLoad(t *testing.T) {
+	s := scorer.NewConsistentHashScorer(nil)
+	pods := []types.Pod{newSessionTestPod("pod1", 0), newSessionTestPod("pod2", 0), newSessionTestPod("pod3", 0)}
+
+	user := "hot-user"
+	home := hashSelected(t, s, user, pods)
+
+	// Load the home pod well above the average, the request spills over to another pod.
+	loaded := make([]types.Pod, 0, len(pods))
+	for _, pod := range pods {
+		waiting := 1
+		if pod.GetPod().NamespacedName.Name == home {
+			waiting = 20
+		}
+		loaded = append(loaded, newSessionTestPod(pod.GetPod().NamespacedName.Name, waiting))
+	}
+	if spill := hashSelected(t, s, user, loaded); spill == home || spill == "" {
+		t.Errorf("Expected the request to spill over from the overloaded pod %s, got %q", home, spill)
+	}
+
+	// When all pods are overloaded relative to the bound, a pod is still selected.
+	single := []types.Pod{newSessionTestPod(home, 50)}
+	if got := hashSelected(t, s, user, single); got != home {
+		t.Errorf("Expected %s to be selected, got %q", home, got)
+	}
+}
+
+func TestConsistentHashScorerMinimalRemapping(t *testing.T) {
+	s := scorer.NewConsistentHashScorer(nil)
+	pods := []types.Pod{newSessionTestPod("pod1", 0), newSessionTestPod("pod2", 0), newSessionTestPod("pod3", 0)}
+	scaledPods := append([]types.Pod{newSessionTestPod("pod4", 0)}, pods...)
+
+	const numKeys = 200
+	moved := 0
+	for i := 0; i < numKeys; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		before := hashSelected(t, s, user, pods)
+		s.OnPodEvent(datastore.Event{Type: datastore.PodAdded, Pod: scaledPods[0].GetPod()})
+		after := hashSelected(t, s, user, scaledPods)
+		s.OnPodEvent(datastore.Event{Type: datastore.PodRemoved, Pod: scaledPods[0].GetPod()})
+		if before != after {
+			if after != "pod4" {
+				t.Errorf("User %q moved from %s to existing pod %s", user, before, after)
+			}
+			moved++
+		}
+	}
+	// Ideally a quarter of the keys move to the new pod.
+	if moved == 0 || moved > numKeys/2 {
+		t.Errorf("Expected about %d keys to move, got %d", numKeys/4, moved)
+	}
+}
+
+func TestConsistentHashScorerRingRebuild(t *testing.T) {
+	s := scorer.NewConsistentHashScorer(nil)
+	pods := []types.Pod{newSessionTestPod("pod1", 0), newSessionTestPod("pod2", 0)}
+	newPod := newSessionTestPod("pod3", 0)
+	scaledPods := append([]types.Pod{newPod}, pods...)
+
+	// Find a user mapped to the new pod once it joins the ring.
+	user := ""
+	for i := 0; i < 100 && user == ""; i++ {
+		candidate := fmt.Sprintf("user-%d", i)
+		s.OnPodEvent(datastore.Event{Type: datastore.PodAdded, Pod: newPod.GetPod()})
+		if hashSelected(t, s, candidate, scaledPods) == "pod3" {
+			user = candidate
+		}
+		s.OnPodEvent(datastore.Event{Type: datastore.PodRemoved, Pod: newPod.GetPod()})
+		hashSelected(t, s, candidate, pods)
+	}
+	if user == "" {
+		t.Fatal("No user mapped to the new pod")
+	}
+
+	// Until the pod is added to the datastore, the ring is not rebuilt and the new pod is not selected.
+	if got := hashSelected(t, s, user, scaledPods); got == "pod3" {
+		t.Errorf("Expected the ring not to be rebuilt without a pod event, got %s", got)
+	}
+	// Updates of pods do not change the ring.
+	s.OnPodEvent(datastore.Event{Type: datastore.PodUpdated, Pod: newPod.GetPod()})
+	if got := hashSelected(t, s, user, scaledPods); got == "pod3" {
+		t.Errorf("Expected the ring not to be rebuilt on a pod update, got %s", got)
+	}
+	s.OnPodEvent(datastore.Event{Type: datastore.PodAdded, Pod: newPod.GetPod()})
+	if got := hashSelected(t, s, user, scaledPods); got != "pod3" {
+		t.Errorf("Expected the ring to be rebuilt with pod3, got %s", got)
+	}
+}
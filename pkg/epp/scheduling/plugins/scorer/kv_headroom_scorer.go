@@ -0,0 +1,64 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// KVHeadroomScorer scores pods by the KV-cache headroom left once the request is admitted, i.e.,
+// the free KV-cache tokens minus the request prompt and maximum generated tokens, relative to the
+// KV-cache capacity of the pod. Pods without enough headroom score 0.
+//
+// Pods with an unknown KV-cache capacity are not scored.
+type KVHeadroomScorer struct {
+	defaultMaxTokens int
+}
+
+var _ plugins.Scorer = &KVHeadroomScorer{}
+
+// NewKVHeadroomScorer creates a new KVHeadroomScorer, assuming the given number of generated tokens
+// for requests that do not set a maximum.
+func NewKVHeadroomScorer(defaultMaxTokens int) *KVHeadroomScorer {
+	return &KVHeadroomScorer{defaultMaxTokens: defaultMaxTokens}
+}
+
+func (s *KVHeadroomScorer) Name() string {
+	return "kv-headroom-scorer"
+}
+
+func (s *KVHeadroomScorer) Score(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
+	if ctx.Req == nil {
+		return nil
+	}
+
+	required := ctx.Req.RequiredKVCacheTokens(s.defaultMaxTokens)
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		free, known := pod.GetMetrics().FreeKVCacheTokens()
+		if !known {
+			continue
+		}
+		headroom := float64(free-required) / float64(pod.GetMetrics().KvCacheMaxTokenCapacity)
+		scoredPods[pod] = min(max(headroom, 0), 1)
+	}
+	if len(scoredPods) == 0 {
+		return nil
+	}
+	return scoredPods
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/scorer"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestKVHeadroomScorer(t *testing.T) {
+	newPod := func(name string, capacity int, usage float64) *types.PodMetrics {
+		return &types.PodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}},
+			Metrics: &backendmetrics.Metrics{KvCacheMaxTokenCapacity: capacity, KVCacheUsagePercent: usage},
+		}
+	}
+	empty := newPod("empty", 10000, 0)
+	half := newPod("half", 10000, 0.5)
+	full := newPod("full", 10000, 0.99)
+	unknown := newPod("unknown", 0, 0)
+
+	// 400 characters, estimated to 100 tokens, plus 900 generated tokens.
+	req := &types.LLMRequest{Prompt: strings.Repeat("a", 400), MaxTokens: 900}
+
+	tests := []struct {
+		name  string
+		req   *types.LLMRequest
+		input []types.Pod
+		want  map[types.Pod]float64
+	}{
+		{
+			name:  "pods are scored by their headroom",
+			req:   req,
+			input: []types.Pod{empty, half, full, unknown},
+			want:  map[types.Pod]float64{empty: 0.9, half: 0.4, full: 0},
+		},
+		{
+			name:  "pods with unknown capacity are not scored",
+			req:   req,
+			input: []types.Pod{unknown},
+			want:  nil,
+		},
+	}
+
+	s := scorer.NewKVHeadroomScorer(256)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), test.req, test.input, 0)
+			got := s.Score(ctx, test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
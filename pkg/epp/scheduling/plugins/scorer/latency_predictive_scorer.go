@@ -0,0 +1,418 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"maps"
+	"math"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+const (
+	latencyPredictiveScorerName = "latency-predictive-scorer"
+
+	ttftLatency = "ttft"
+	tpotLatency = "tpot"
+	// poolModelKey is the key of the regression fitted on the observations of all pods, used for
+	// pods without enough observations of their own. It cannot collide with a pod namespaced name.
+	poolModelKey = "pool"
+
+	defaultForgettingFactor     = 0.995
+	defaultMinSamples           = 20
+	defaultExpectedOutputTokens = 128
+
+	// prefixMatchesStateKey is the key of the prefix matches of the request computed by Score, so that
+	// PostSchedule sees the prefix cache as it was when the pods were scored.
+	prefixMatchesStateKey = types.StateKey(latencyPredictiveScorerName + "/prefix-matches")
+
+	// pendingRequestTimeout is how long the features of a scheduled request are kept while waiting
+	// for its response to complete.
+	pendingRequestTimeout = 10 * time.Minute
+	pendingPurgeFrequency = time.Minute
+)
+
+// latencyFeatures are the names of the regression features, in order.
+var latencyFeatures = []string{"intercept", "waiting_queue", "running_queue", "kv_cache_usage", "prompt_kchars", "prefix_hit"}
+
+// LatencyPredictiveConfig contains initialization configuration for LatencyPredictiveScorer.
+type LatencyPredictiveConfig struct {
+	// ForgettingFactor in (0,1] discounts older observations, lower values adapt faster.
+	ForgettingFactor float64
+	// MinSamples is the number of observations a pod regression needs before it is used, until then
+	// the regression fitted on all pods is used.
+	MinSamples int
+	// ExpectedOutputTokens is the number of output tokens assumed when combining the predicted TTFT
+	// and TPOT into the predicted latency of a request.
+	ExpectedOutputTokens int
+	// PrefixStore estimates the prefix cache hits of the requests. It is populated and purged by its
+	// owner, typically the prefix-aware scorer. If nil, the scorer populates a store of its own.
+	PrefixStore *PrefixStore
+}
+
+// DefaultLatencyPredictiveConfig returns a LatencyPredictiveConfig instance with default configuration.
+func DefaultLatencyPredictiveConfig() *LatencyPredictiveConfig {
+	return &LatencyPredictiveConfig{
+		ForgettingFactor:     defaultForgettingFactor,
+		MinSamples:           defaultMinSamples,
+		ExpectedOutputTokens: defaultExpectedOutputTokens,
+	}
+}
+
+// LatencyPredictiveScorer scores pods by the latency they are predicted to serve the request with.
+//
+// For every pod, it fits online linear regressions of the observed TTFT and TPOT against the pod
+// waiting and running queues and KV-cache usage at scheduling time, the prompt length and the
+// fraction of the prompt found in the pod prefix cache. The predicted latency of a request on a pod
+// is TTFT + ExpectedOutputTokens * TPOT, and pods are scored in [0,1] from the slowest to the
+// fastest. The regression coefficients are exported as metrics.
+type LatencyPredictiveScorer struct {
+	forgettingFactor     float64
+	minSamples           int
+	expectedOutputTokens int
+
+	prefixStore *PrefixStore
+	// ownsPrefixStore is true if the scorer populates the prefix store itself.
+	ownsPrefixStore bool
+
+	mu sync.Mutex
+	// key: pod namespaced name (or poolModelKey), value: the pod regressions
+	models map[string]*latencyModels
+	// key: request ID, value: the request features on the pod it was scheduled to
+	pending   map[string]*pendingRequest
+	lastPurge time.Time
+}
+
+type latencyModels struct {
+	ttft *onlineRegression
+	tpot *onlineRegression
+}
+
+type pendingRequest struct {
+	pod       string
+	features  []float64
+	scheduled time.Time
+}
+
+var _ plugins.Scorer = &LatencyPredictiveScorer{}
+var _ plugins.PostSchedule = &LatencyPredictiveScorer{}
+var _ plugins.PostCompletion = &LatencyPredictiveScorer{}
+var _ plugins.PodEventHandler = &LatencyPredictiveScorer{}
+
+// NewLatencyPredictiveScorer creates a new LatencyPredictiveScorer with the given configuration.
+// If the config is nil, default is used.
+func NewLatencyPredictiveScorer(config *LatencyPredictiveConfig) *LatencyPredictiveScorer {
+	if config == nil {
+		config = DefaultLatencyPredictiveConfig()
+	}
+	forgettingFactor := config.ForgettingFactor
+	if forgettingFactor <= 0 || forgettingFactor > 1 {
+		forgettingFactor = defaultForgettingFactor
+	}
+	prefixStore, ownsPrefixStore := config.PrefixStore, false
+	if prefixStore == nil {
+		prefixStore, ownsPrefixStore = NewPrefixStore(nil), true
+	}
+	return &LatencyPredictiveScorer{
+		forgettingFactor:     forgettingFactor,
+		minSamples:           config.MinSamples,
+		expectedOutputTokens: config.ExpectedOutputTokens,
+		prefixStore:          prefixStore,
+		ownsPrefixStore:      ownsPrefixStore,
+		models:               make(map[string]*latencyModels),
+		pending:              make(map[string]*pendingRequest),
+	}
+}
+
+func (s *LatencyPredictiveScorer) Name() string {
+	return latencyPredictiveScorerName
+}
+
+// Score scores the given pods by their predicted latency for the request. Pods are not scored
+// until enough responses were observed.
+func (s *LatencyPredictiveScorer) Score(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
+	loggerDebug := log.FromContext(ctx).WithName(latencyPredictiveScorerName).V(logutil.DEBUG)
+	if ctx.Req == nil {
+		loggerDebug.Info("Request is nil, skipping scoring")
+		return nil
+	}
+
+	predictions := make(map[types.Pod]float64, len(pods))
+	for pod, prediction := range s.PredictLatencies(ctx, pods) {
+		predictions[pod] = prediction.TTFT.Seconds() + float64(s.expectedOutputTokens)*prediction.TPOT.Seconds()
+	}
+	if len(predictions) == 0 {
+		loggerDebug.Info("Not enough observations to predict latencies, skipping scoring")
+		return nil
+	}
+	loggerDebug.Info("Predicted latencies", "predictions", predictions)
+
+	minLatency, maxLatency := math.Inf(1), math.Inf(-1)
+	for _, latency := range predictions {
+		minLatency = math.Min(minLatency, latency)
+		maxLatency = math.Max(maxLatency, latency)
+	}
+
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		latency, ok := predictions[pod]
+		switch {
+		case !ok:
+			scoredPods[pod] = 0.0
+		case maxLatency == minLatency:
+			scoredPods[pod] = 1.0
+		default:
+			scoredPods[pod] = (maxLatency - latency) / (maxLatency - minLatency)
+		}
+	}
+	return scoredPods
+}
+
+// PredictLatencies returns the latencies the request is predicted to be served with on the given
+// pods. Pods are omitted until enough responses were observed.
+func (s *LatencyPredictiveScorer) PredictLatencies(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]types.LatencyPrediction {
+	if ctx.Req == nil {
+		return nil
+	}
+	prompt := ctx.PromptText()
+	prefixMatches := s.prefixMatches(ctx, prompt)
+
+	predictions := make(map[types.Pod]types.LatencyPrediction, len(pods))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, pod := range pods {
+		if pod.GetPod() == nil {
+			continue
+		}
+		name := pod.GetPod().NamespacedName.String()
+		models := s.modelsFor(name)
+		if models == nil {
+			continue
+		}
+		x := s.features(pod, prompt, prefixMatches[name])
+		predictions[pod] = types.LatencyPrediction{
+			TTFT: secondsToDuration(models.ttft.predict(x)),
+			TPOT: secondsToDuration(models.tpot.predict(x)),
+		}
+	}
+	return predictions
+}
+
+// prefixMatchesState holds the number of prompt blocks found in the prefix cache of each pod.
+type prefixMatchesState struct {
+	matches map[string]int
+}
+
+func (s *prefixMatchesState) Clone() types.StateData {
+	return &prefixMatchesState{matches: maps.Clone(s.matches)}
+}
+
+// prefixMatches returns the number of prompt blocks found in the prefix cache of each pod, computed
+// once per scheduling cycle.
+func (s *LatencyPredictiveScorer) prefixMatches(ctx *types.SchedulingContext, prompt string) map[string]int {
+	if ctx.CycleState == nil {
+		return s.prefixStore.FindMatchingPods(prompt, ctx.Req.Model)
+	}
+	if state, err := types.ReadCycleState[*prefixMatchesState](ctx.CycleState, prefixMatchesStateKey); err == nil {
+		return state.matches
+	}
+	matches := s.prefixStore.FindMatchingPods(prompt, ctx.Req.Model)
+	ctx.CycleState.Write(prefixMatchesStateKey, &prefixMatchesState{matches: matches})
+	return matches
+}
+
+// secondsToDuration converts a predicted latency in seconds to a duration, clamping it at zero.
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Max(seconds, 0) * float64(time.Second))
+}
+
+// modelsFor returns the regressions to predict the latencies of the given pod with, or nil if
+// there are not enough observations yet. Must be called with the lock held.
+func (s *LatencyPredictiveScorer) modelsFor(pod string) *latencyModels {
+	if models, ok := s.models[pod]; ok && models.ttft.samples >= s.minSamples && models.tpot.samples >= s.minSamples {
+		return models
+	}
+	if models, ok := s.models[poolModelKey]; ok && models.ttft.samples >= s.minSamples && models.tpot.samples >= s.minSamples {
+		return models
+	}
+	return nil
+}
+
+// features returns the regression features of the request on the given pod, see latencyFeatures.
+func (s *LatencyPredictiveScorer) features(pod types.Pod, prompt string, prefixBlocks int) []float64 {
+	prefixHit := 0.0
+	if len(prompt) > 0 {
+		prefixHit = math.Min(float64(prefixBlocks*s.prefixStore.blockSize)/float64(len(prompt)), 1)
+	}
+	podMetrics := pod.GetMetrics()
+	return []float64{
+		1,
+		float64(podMetrics.WaitingQueueSize),
+		float64(podMetrics.RunningQueueSize),
+		podMetrics.KVCacheUsagePercent,
+		float64(len(prompt)) / 1000,
+		prefixHit,
+	}
+}
+
+// PostSchedule implements the PostSchedulePlugin interface.
+// It keeps the request features on the selected pod until the response completes.
+func (s *LatencyPredictiveScorer) PostSchedule(ctx *types.SchedulingContext, res *types.Result) {
+	debugLogger := log.FromContext(ctx).WithName(latencyPredictiveScorerName).V(logutil.DEBUG)
+	if ctx.Req == nil || ctx.Req.RequestID == "" || res.TargetPod == nil || res.TargetPod.GetPod() == nil {
+		debugLogger.Info("Missing request ID or target pod, skipping PostSchedule")
+		return
+	}
+
+	pod := res.TargetPod
+	name := pod.GetPod().NamespacedName.String()
+	prompt := ctx.PromptText()
+	x := s.features(pod, prompt, s.prefixMatches(ctx, prompt)[name])
+
+	if s.ownsPrefixStore {
+		if err := s.prefixStore.AddEntry(ctx.Req.Model, prompt, &pod.GetPod().NamespacedName); err != nil {
+			debugLogger.Error(err, "Failed to add entry to prefix store", "pod", name)
+		}
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[ctx.Req.RequestID] = &pendingRequest{pod: name, features: x, scheduled: now}
+	if now.Sub(s.lastPurge) > pendingPurgeFrequency {
+		for requestID, pending := range s.pending {
+			if now.Sub(pending.scheduled) > pendingRequestTimeout {
+				delete(s.pending, requestID)
+			}
+		}
+		s.lastPurge = now
+	}
+}
+
+// PostCompletion implements the PostCompletionPlugin interface.
+// It fits the regressions of the pod that served the request to the observed latencies.
+func (s *LatencyPredictiveScorer) PostCompletion(ctx *types.SchedulingContext, _ types.Pod, latency *types.ResponseLatency) {
+	if ctx.Req == nil || latency == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending, ok := s.pending[ctx.Req.RequestID]
+	if !ok {
+		return
+	}
+	delete(s.pending, ctx.Req.RequestID)
+
+	var ttft, tpot float64
+	for _, key := range []string{pending.pod, poolModelKey} {
+		models, ok := s.models[key]
+		if !ok {
+			models = &latencyModels{
+				ttft: newOnlineRegression(len(latencyFeatures), s.forgettingFactor),
+				tpot: newOnlineRegression(len(latencyFeatures), s.forgettingFactor),
+			}
+			s.models[key] = models
+		}
+		ttft, tpot = s.observedLatencies(models, pending.features, latency)
+		if ttft > 0 {
+			models.ttft.update(pending.features, ttft)
+			metrics.RecordLatencyPredictorModel(key, ttftLatency, latencyFeatures, models.ttft.coefficients, models.ttft.samples)
+		}
+		if tpot > 0 {
+			models.tpot.update(pending.features, tpot)
+			metrics.RecordLatencyPredictorModel(key, tpotLatency, latencyFeatures, models.tpot.coefficients, models.tpot.samples)
+		}
+	}
+	log.FromContext(ctx).WithName(latencyPredictiveScorerName).V(logutil.TRACE).Info("Observed latencies",
+		"pod", pending.pod, "ttft", ttft, "tpot", tpot)
+
+	s.removeStaleModels(ctx.PodsSnapshot)
+}
+
+// observedLatencies returns the TTFT and TPOT observed for a request, zero if unknown. The TTFT of
+// a response that is not streamed is unknown, unless it has a single output token, e.g., embeddings.
+// Its TPOT is then derived from the end-to-end latency and the TTFT predicted by the given
+// regressions, once trained. Must be called with the lock held.
+func (s *LatencyPredictiveScorer) observedLatencies(models *latencyModels, x []float64, latency *types.ResponseLatency) (float64, float64) {
+	if latency.TTFT > 0 {
+		return latency.TTFT.Seconds(), latency.TPOT().Seconds()
+	}
+	e2e := latency.E2E.Seconds()
+	if e2e <= 0 {
+		return 0, 0
+	}
+	if latency.CompletionTokens <= 1 {
+		return e2e, 0
+	}
+	if models.ttft.samples < s.minSamples {
+		return 0, 0
+	}
+	decode := e2e - math.Max(models.ttft.predict(x), 0)
+	if decode <= 0 {
+		return 0, 0
+	}
+	return 0, decode / float64(latency.CompletionTokens-1)
+}
+
+// OnPodEvent implements the PodEventHandler interface.
+// It drops the regressions of removed pods, and purges their prefixes from the store of the scorer, a
+// shared store being purged by its owner.
+func (s *LatencyPredictiveScorer) OnPodEvent(event datastore.Event) {
+	if event.Type != datastore.PodRemoved {
+		return
+	}
+	if s.ownsPrefixStore {
+		s.prefixStore.RemovePod(event.Pod.NamespacedName)
+	}
+	key := event.Pod.NamespacedName.String()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.models[key]; ok {
+		delete(s.models, key)
+		metrics.DeleteLatencyPredictorModel(key, ttftLatency, latencyFeatures)
+		metrics.DeleteLatencyPredictorModel(key, tpotLatency, latencyFeatures)
+	}
+}
+
+// removeStaleModels drops the regressions of pods that are no longer in the pool. Must be called
+// with the lock held.
+func (s *LatencyPredictiveScorer) removeStaleModels(pods []types.Pod) {
+	if len(pods) == 0 {
+		return
+	}
+	current := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		current[pod.GetPod().NamespacedName.String()] = true
+	}
+	for key := range s.models {
+		if key == poolModelKey || current[key] {
+			continue
+		}
+		delete(s.models, key)
+		metrics.DeleteLatencyPredictorModel(key, ttftLatency, latencyFeatures)
+		metrics.DeleteLatencyPredictorModel(key, tpotLatency, latencyFeatures)
+	}
+}
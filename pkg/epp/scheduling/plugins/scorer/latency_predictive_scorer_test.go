@@ -0,0 +1,190 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/scorer"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func newLatencyTestPod(name string, waiting, running int, kvCacheUsage float64) *types.PodMetrics {
+	return &types.PodMetrics{
+		Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name, Namespace: "default"}},
+		Metrics: &backendmetrics.Metrics{
+			WaitingQueueSize:    waiting,
+			RunningQueueSize:    running,
+			KVCacheUsagePercent: kvCacheUsage,
+		},
+	}
+}
+
+// observe schedules a request on the given pod and completes it with the given latencies.
+func observe(s *scorer.LatencyPredictiveScorer, requestID string, pod types.Pod, ttft, tpot time.Duration) {
+	const completionTokens = 11
+	observeLatency(s, requestID, pod, &types.ResponseLatency{
+		TTFT:             ttft,
+		E2E:              ttft + (completionTokens-1)*tpot,
+		CompletionTokens: completionTokens,
+	})
+}
+
+// observeLatency schedules a request on the given pod and completes it with the given latency.
+func observeLatency(s *scorer.LatencyPredictiveScorer, requestID string, pod types.Pod, latency *types.ResponseLatency) {
+	req := &types.LLMRequest{Model: "model", Prompt: "hello", RequestID: requestID}
+	sCtx := types.NewSchedulingContext(context.Background(), req, []types.Pod{pod}, 0)
+	s.PostSchedule(sCtx, &types.Result{TargetPod: pod})
+	s.PostCompletion(types.NewSchedulingContext(context.Background(), &types.LLMRequest{RequestID: requestID}, nil, 0), pod, latency)
+}
+
+func TestLatencyPredictiveScorer(t *testing.T) {
+	config := scorer.DefaultLatencyPredictiveConfig()
+	config.MinSamples = 10
+	s := scorer.NewLatencyPredictiveScorer(config)
+
+	idle := newLatencyTestPod("idle", 0, 1, 0.1)
+	busy := newLatencyTestPod("busy", 8, 20, 0.7)
+	loaded := newLatencyTestPod("loaded", 4, 10, 0.4)
+	pods := []types.Pod{idle, busy, loaded}
+	req := &types.LLMRequest{Model: "model", Prompt: "hello"}
+
+	if got := s.Score(types.NewSchedulingContext(context.Background(), req, pods, 0), pods); got != nil {
+		t.Fatalf("Expected no scores without observations, got %v", got)
+	}
+
+	// Latencies grow with the queues of the pods.
+	for i := 0; i < 60; i++ {
+		waiting, running := i%10, i%25
+		pod := newLatencyTestPod(fmt.Sprintf("pod%d", i%3), waiting, running, float64(i%10)/10)
+		ttft := 100*time.Millisecond + time.Duration(waiting)*50*time.Millisecond
+		tpot := 10*time.Millisecond + time.Duration(running)*time.Millisecond
+		observe(s, fmt.Sprintf("req-%d", i), pod, ttft, tpot)
+	}
+
+	got := s.Score(types.NewSchedulingContext(context.Background(), req, pods, 0), pods)
+	if got[idle] != 1.0 || got[busy] != 0.0 {
+		t.Errorf("Expected the idle pod to score 1 and the busy pod 0, got %v", got)
+	}
+	if got[loaded] <= got[busy] || got[loaded] >= got[idle] {
+		t.Errorf("Expected the loaded pod to score between the idle and the busy pods, got %v", got)
+	}
+
+	// Completions of unknown requests are ignored.
+	observe(s, "", idle, time.Hour, time.Hour)
+	if again := s.Score(types.NewSchedulingContext(context.Background(), req, pods, 0), pods); again[idle] != 1.0 {
+		t.Errorf("Expected the idle pod to still score 1, got %v", again)
+	}
+}
+
+func TestLatencyPredictiveScorerNotStreamed(t *testing.T) {
+	config := scorer.DefaultLatencyPredictiveConfig()
+	config.MinSamples = 5
+	s := scorer.NewLatencyPredictiveScorer(config)
+	pod := newLatencyTestPod("pod", 2, 4, 0.2)
+	pods := []types.Pod{pod}
+	req := &types.LLMRequest{Model: "model", Prompt: "hello"}
+
+	// Single token responses, e.g., embeddings, are observed as TTFT.
+	for i := 0; i < 5; i++ {
+		observeLatency(s, fmt.Sprintf("embed-%d", i), pod, &types.ResponseLatency{E2E: 200 * time.Millisecond, CompletionTokens: 1})
+	}
+	if got := s.PredictLatencies(types.NewSchedulingContext(context.Background(), req, pods, 0), pods); len(got) != 0 {
+		t.Fatalf("Expected no predictions without TPOT observations, got %v", got)
+	}
+
+	// The TPOT of longer responses is derived from the predicted TTFT.
+	for i := 0; i < 5; i++ {
+		observeLatency(s, fmt.Sprintf("complete-%d", i), pod,
+			&types.ResponseLatency{E2E: 200*time.Millisecond + 10*20*time.Millisecond, CompletionTokens: 11})
+	}
+	got := s.PredictLatencies(types.NewSchedulingContext(context.Background(), req, pods, 0), pods)
+	prediction, ok := got[pod]
+	if !ok {
+		t.Fatalf("Expected a prediction for the pod, got %v", got)
+	}
+	if diff := prediction.TTFT - 200*time.Millisecond; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Errorf("Expected a TTFT of 200ms, got %v", prediction.TTFT)
+	}
+	if diff := prediction.TPOT - 20*time.Millisecond; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Errorf("Expected a TPOT of 20ms, got %v", prediction.TPOT)
+	}
+}
+
+func TestLatencyPredictiveScorerSharedPrefixStore(t *testing.T) {
+	storeConfig := scorer.DefaultPrefixStoreConfig()
+	storeConfig.BlockSize = 4
+	store := scorer.NewPrefixStore(storeConfig)
+	config := scorer.DefaultLatencyPredictiveConfig()
+	config.PrefixStore = store
+	s := scorer.NewLatencyPredictiveScorer(config)
+
+	pod := newLatencyTestPod("pod", 0, 0, 0)
+	req := &types.LLMRequest{Model: "model", Prompt: "hello world", RequestID: "r1"}
+	s.PostSchedule(types.NewSchedulingContext(context.Background(), req, []types.Pod{pod}, 0), &types.Result{TargetPod: pod})
+
+	// The shared store is populated by its owner only.
+	if got := store.FindMatchingPods(req.Prompt, req.Model); len(got) != 0 {
+		t.Errorf("Expected the shared prefix store not to be populated by the scorer, got %v", got)
+	}
+
+	// The shared store is purged by its owner only.
+	if err := store.AddEntry(req.Model, req.Prompt, &pod.GetPod().NamespacedName); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.OnPodEvent(datastore.Event{Type: datastore.PodRemoved, Pod: pod.GetPod()})
+	if got := store.FindMatchingPods(req.Prompt, req.Model); len(got) != 1 {
+		t.Errorf("Expected the shared prefix store not to be purged by the scorer, got %v", got)
+	}
+}
+
+func TestResponseLatencyTPOT(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency types.ResponseLatency
+		want    time.Duration
+	}{
+		{
+			name:    "streamed",
+			latency: types.ResponseLatency{TTFT: time.Second, E2E: 3 * time.Second, CompletionTokens: 5},
+			want:    500 * time.Millisecond,
+		},
+		{
+			name:    "not streamed",
+			latency: types.ResponseLatency{E2E: 3 * time.Second, CompletionTokens: 5},
+			want:    0,
+		},
+		{
+			name:    "single token",
+			latency: types.ResponseLatency{TTFT: time.Second, E2E: time.Second, CompletionTokens: 1},
+			want:    0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.latency.TPOT(); got != test.want {
+				t.Errorf("Expected TPOT %v, got %v", test.want, got)
+			}
+		})
+	}
+}
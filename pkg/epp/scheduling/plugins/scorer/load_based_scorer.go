@@ -33,12 +33,14 @@ func (s *LoadAwareScorer) Name() string {
 // Pod with empty waiting requests queue is scored with 0.5
 // Pod with requests in the queue will get score between 0.5 and 0.
 // Score 0 will get pod with number of requests in the queue equal to the threshold used in load-based filter (QueueingThresholdLoRA)
+// The queue size is normalized by the capacity of the pod, so that a pod with twice the capacity is
+// scored as if its queue was half as long.
 // In future pods with additional capacity will get score higher than 0.5
 func (s *LoadAwareScorer) Score(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
 	scoredPods := make(map[types.Pod]float64)
 
 	for _, pod := range pods {
-		waitingRequests := float64(pod.GetMetrics().WaitingQueueSize)
+		waitingRequests := float64(pod.GetMetrics().WaitingQueueSize) / pod.GetPod().CapacityWeight()
 
 		if waitingRequests == 0 {
 			scoredPods[pod] = 0.5
@@ -0,0 +1,226 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/kvcache"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	envutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/env"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+const (
+	localKVCacheAwareScorerName = "local-kvcache-aware-scorer"
+	kvCacheTokenizersDirEnvVar  = "KVCACHE_TOKENIZERS_DIR"
+	kvCacheEventsPortEnvVar     = "KVCACHE_EVENTS_PORT"
+	kvCacheBlockSizeEnvVar      = "KVCACHE_BLOCK_SIZE"
+
+	defaultKVCacheEventsPort = 9004
+)
+
+var (
+	// The KV-cache index and its events endpoint are shared by all scorer instances in the process,
+	// e.g., by the prefill and decode schedulers.
+	sharedKVCacheIndexOnce sync.Once
+	sharedKVCacheIndex     *kvcache.Index
+	kvCacheEventsServerMu  sync.Mutex
+	kvCacheEventsServer    *http.Server
+)
+
+// LocalKVCacheAwareScorer scores pods by the longest prefix of the tokenized prompt that is held in
+// their KV-cache, according to an in-process index fed by the KV-cache events pushed by the model
+// servers. The prompts are tokenized as by the model servers, the messages of chat completions
+// being rendered by the chat template of the model, if any.
+type LocalKVCacheAwareScorer struct {
+	index      *kvcache.Index
+	tokenizers *kvcache.TokenizerPool
+	eventsPort int
+}
+
+var (
+	_ plugins.Scorer          = &LocalKVCacheAwareScorer{}
+	_ plugins.Initializer     = &LocalKVCacheAwareScorer{}
+	_ plugins.Shutdowner      = &LocalKVCacheAwareScorer{}
+	_ plugins.PodEventHandler = &LocalKVCacheAwareScorer{}
+)
+
+// NewLocalKVCacheAwareScorer creates a new LocalKVCacheAwareScorer from environment variables. The
+// HTTP server receiving the KV-cache events is started by Init.
+//
+// An error is returned if the tokenizers directory is not configured.
+func NewLocalKVCacheAwareScorer(ctx context.Context) (plugins.Scorer, error) {
+	logger := log.FromContext(ctx).WithName(localKVCacheAwareScorerName)
+
+	tokenizersDir := envutil.GetEnvString(kvCacheTokenizersDirEnvVar, "", logger)
+	if tokenizersDir == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", kvCacheTokenizersDirEnvVar)
+	}
+
+	sharedKVCacheIndexOnce.Do(func() {
+		sharedKVCacheIndex = kvcache.NewIndex(envutil.GetEnvInt(kvCacheBlockSizeEnvVar, kvcache.DefaultBlockSize, logger))
+	})
+
+	return &LocalKVCacheAwareScorer{
+		index:      sharedKVCacheIndex,
+		tokenizers: kvcache.NewTokenizerPool(tokenizersDir),
+		eventsPort: envutil.GetEnvInt(kvCacheEventsPortEnvVar, defaultKVCacheEventsPort, logger),
+	}, nil
+}
+
+// Init starts the HTTP server receiving the KV-cache events, once for all the scorer instances. An
+// error is returned if the port cannot be listened on.
+func (s *LocalKVCacheAwareScorer) Init(ctx context.Context) error {
+	kvCacheEventsServerMu.Lock()
+	defer kvCacheEventsServerMu.Unlock()
+	if kvCacheEventsServer != nil {
+		return nil
+	}
+
+	logger := log.FromContext(ctx).WithName(localKVCacheAwareScorerName)
+	listener, err := net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(s.eventsPort)))
+	if err != nil {
+		return fmt.Errorf("failed to listen for KV-cache events: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(kvcache.EventsPath, kvcache.NewEventsHandler(s.index, logger))
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		logger.Info("Starting KV-cache events server", "port", s.eventsPort, "path", kvcache.EventsPath)
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(err, "KV-cache events server failed")
+		}
+	}()
+	kvCacheEventsServer = srv
+	return nil
+}
+
+// Shutdown stops the HTTP server receiving the KV-cache events.
+func (s *LocalKVCacheAwareScorer) Shutdown() {
+	kvCacheEventsServerMu.Lock()
+	defer kvCacheEventsServerMu.Unlock()
+	if kvCacheEventsServer != nil {
+		_ = kvCacheEventsServer.Close()
+		kvCacheEventsServer = nil
+	}
+}
+
+// OnPodEvent implements the PodEventHandler interface.
+// It drops the blocks of removed pods from the index, keyed by the pod addresses.
+func (s *LocalKVCacheAwareScorer) OnPodEvent(event datastore.Event) {
+	if event.Type == datastore.PodRemoved {
+		s.index.RemovePod(event.Pod.Address)
+	}
+}
+
+// Name returns the name of the scorer.
+func (s *LocalKVCacheAwareScorer) Name() string {
+	return localKVCacheAwareScorerName
+}
+
+// Score scores the pods by the number of leading prompt blocks cached on them.
+// The returned scores are normalized to a range of 0-1.
+func (s *LocalKVCacheAwareScorer) Score(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
+	loggerDebug := log.FromContext(ctx).WithName(localKVCacheAwareScorerName).V(logutil.DEBUG)
+	if ctx.Req == nil {
+		loggerDebug.Info("Request is nil, skipping scoring")
+		return nil
+	}
+	if ctx.PromptText() == "" {
+		loggerDebug.Info("Request has no prompt, skipping scoring")
+		return nil
+	}
+
+	tokens, err := s.promptTokens(ctx)
+	if err != nil {
+		loggerDebug.Error(err, "Failed to tokenize the prompt", "model", ctx.Req.ResolvedTargetModel)
+		return nil
+	}
+	if len(tokens) == 0 {
+		loggerDebug.Info("Request has no prompt, skipping scoring")
+		return nil
+	}
+
+	scores := s.index.LongestPrefix(tokens)
+	loggerDebug.Info("Got pod scores", "scores", scores)
+
+	return indexerScoresToNormalizedScoredPods(pods, scores)
+}
+
+// promptTokens returns the token IDs of the request prompt, tokenizing it once per scheduling cycle
+// and sharing the tokens through the cycle state.
+func (s *LocalKVCacheAwareScorer) promptTokens(ctx *types.SchedulingContext) ([]uint32, error) {
+	key := types.TokensStateKey(ctx.Req.ResolvedTargetModel)
+	if state, err := types.ReadCycleState[*types.TokensState](ctx.CycleState, key); err == nil {
+		return state.TokenIDs, nil
+	}
+	tokenizer, err := s.tokenizers.Get(ctx.Req.ResolvedTargetModel)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := encodePrompt(ctx, tokenizer)
+	if err != nil {
+		return nil, err
+	}
+	ctx.CycleState.Write(key, &types.TokensState{TokenIDs: tokens})
+	return tokens, nil
+}
+
+// encodePrompt encodes the prompt of the request as the model server does: the messages of chat
+// completions are rendered by the chat template, which holds the special tokens, and the other
+// prompts are encoded with the special tokens, e.g., the BOS token. Without a chat template, the
+// flattened messages are encoded, which does not match the prefixes cached by the model server.
+func encodePrompt(ctx *types.SchedulingContext, tokenizer *kvcache.Tokenizer) ([]uint32, error) {
+	chat := ctx.Req.ChatCompletionRequest
+	if chat == nil {
+		return tokenizer.Encode(ctx.PromptText(), true), nil
+	}
+	if chat.HasMultimodalContent() {
+		// The model server replaces the multimodal content with placeholder tokens.
+		return nil, errors.New("multimodal content is not supported")
+	}
+	messages := make([]kvcache.ChatMessage, 0, len(chat.Messages))
+	for _, msg := range chat.Messages {
+		content := msg.Content
+		for _, part := range msg.MultiContent {
+			content += part.Text
+		}
+		messages = append(messages, kvcache.ChatMessage{Role: msg.Role, Content: content})
+	}
+	prompt, err := tokenizer.ApplyChatTemplate(messages)
+	if errors.Is(err, kvcache.ErrNoChatTemplate) {
+		return tokenizer.Encode(ctx.PromptText(), false), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tokenizer.Encode(prompt, false), nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sashabaranov/go-openai"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/kvcache"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// testKVCacheTokenizerJSON is a byte-level BPE tokenizer encoding the letters a to h as 0 to 7,
+// adding a BOS token.
+const testKVCacheTokenizerJSON = `{
+	"added_tokens": [
+		{"id": 100, "content": "<|bos|>", "special": true},
+		{"id": 101, "content": "<|user|>", "special": true},
+		{"id": 102, "content": "<|assistant|>", "special": true}
+	],
+	"pre_tokenizer": {"type": "ByteLevel", "add_prefix_space": false, "use_regex": true},
+	"post_processor": {"type": "TemplateProcessing",
+		"single": [{"SpecialToken": {"id": "<|bos|>", "type_id": 0}}, {"Sequence": {"id": "A", "type_id": 0}}],
+		"special_tokens": {"<|bos|>": {"id": "<|bos|>", "ids": [100]}}},
+	"model": {
+		"type": "BPE",
+		"vocab": {"a": 0, "b": 1, "c": 2, "d": 3, "e": 4, "f": 5, "g": 6, "h": 7},
+		"merges": []
+	}
+}`
+
+const testKVCacheChatTemplate = "<|bos|>{{range .Messages}}<|{{.Role}}|>{{.Content}}{{end}}{{if .AddGenerationPrompt}}<|assistant|>{{end}}"
+
+func TestLocalKVCacheAwareScorer(t *testing.T) {
+	dir := t.TempDir()
+	modelDir := filepath.Join(dir, "model")
+	if err := os.MkdirAll(modelDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(modelDir, "tokenizer.json"), []byte(testKVCacheTokenizerJSON), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(modelDir, "chat_template.tmpl"), []byte(testKVCacheChatTemplate), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The prompt "abcdefgh" is encoded as 100 0 1 2 3 4 5 6 7, and the message "abcdefg" of a user
+	// as 100 101 0 1 2 3 4 5 6 102, in blocks of 4 tokens.
+	index := kvcache.NewIndex(4)
+	blocks := 0
+	store := func(pod string, tokens ...uint32) {
+		hashes := []kvcache.BlockHash{}
+		for i := 0; i < len(tokens)/4; i++ {
+			hashes = append(hashes, kvcache.BlockHash(fmt.Sprintf("%s-%d", pod, blocks)))
+			blocks++
+		}
+		err := index.Apply(pod, &kvcache.EventBatch{Events: []kvcache.Event{
+			{Type: kvcache.BlockStoredEventType, BlockHashes: hashes, TokenIDs: tokens},
+		}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	store("1.1.1.1", 100, 0, 1, 2, 3, 4, 5, 6)
+	store("2.2.2.2", 100, 0, 1, 2)
+	store("2.2.2.2", 100, 101, 0, 1, 2, 3, 4, 5)
+	store("1.1.1.1", 100, 101, 0, 1)
+
+	newPod := func(name, address string) *types.PodMetrics {
+		return &types.PodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}, Address: address},
+			Metrics: &backendmetrics.Metrics{},
+		}
+	}
+	pod1 := newPod("pod1", "1.1.1.1")
+	pod2 := newPod("pod2", "2.2.2.2")
+	pod3 := newPod("pod3", "3.3.3.3")
+	pods := []types.Pod{pod1, pod2, pod3}
+
+	tests := []struct {
+		name string
+		req  *types.LLMRequest
+		want map[types.Pod]float64
+	}{
+		{
+			name: "completions prompt with BOS",
+			req:  &types.LLMRequest{ResolvedTargetModel: "model", Prompt: "abcdefgh"},
+			want: map[types.Pod]float64{pod1: 1, pod2: 0, pod3: 0},
+		},
+		{
+			name: "chat messages rendered by the chat template",
+			req: &types.LLMRequest{ResolvedTargetModel: "model", ChatCompletionRequest: &types.KVCacheChatCompletionRequest{
+				Messages: []openai.ChatCompletionMessage{{Role: "user", Content: "abcdefg"}},
+			}},
+			want: map[types.Pod]float64{pod1: 0, pod2: 1, pod3: 0},
+		},
+		{
+			name: "no prompt",
+			req:  &types.LLMRequest{ResolvedTargetModel: "model"},
+			want: nil,
+		},
+		{
+			name: "no tokenizer",
+			req:  &types.LLMRequest{ResolvedTargetModel: "other", Prompt: "abcdefgh"},
+			want: nil,
+		},
+	}
+
+	s := &LocalKVCacheAwareScorer{index: index, tokenizers: kvcache.NewTokenizerPool(dir)}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), test.req, pods, 0)
+			got := s.Score(ctx, pods)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+
+	// The blocks of removed pods are dropped from the index.
+	s.OnPodEvent(datastore.Event{Type: datastore.PodRemoved, Pod: pod1.GetPod()})
+	ctx := types.NewSchedulingContext(context.Background(), tests[0].req, pods, 0)
+	if diff := cmp.Diff(map[types.Pod]float64{pod1: 0, pod2: 1, pod3: 0}, s.Score(ctx, pods)); diff != "" {
+		t.Errorf("Unexpected output after the pod removal (-want +got): %v", diff)
+	}
+}
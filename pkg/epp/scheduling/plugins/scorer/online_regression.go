@@ -0,0 +1,133 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import "math"
+
+// initialCovariance is the initial diagonal of the inverse correlation matrix. A large value means
+// little confidence in the initial (zero) coefficients, so the first observations dominate.
+const initialCovariance = 100.0
+
+// onlineRegression is a linear regression fitted online with recursive least squares. Older
+// observations are exponentially discounted by the forgetting factor, so the model tracks changes
+// of the pod behavior over time. It is not safe for concurrent use.
+type onlineRegression struct {
+	forgetting float64
+	// coefficients of the regression, one per feature.
+	coefficients []float64
+	// covariance is the inverse correlation matrix of the features.
+	covariance [][]float64
+	samples    int
+}
+
+func newOnlineRegression(dimension int, forgetting float64) *onlineRegression {
+	r := &onlineRegression{
+		forgetting:   forgetting,
+		coefficients: make([]float64, dimension),
+		covariance:   make([][]float64, dimension),
+	}
+	for i := range r.covariance {
+		r.covariance[i] = make([]float64, dimension)
+	}
+	r.resetCovariance()
+	return r
+}
+
+func (r *onlineRegression) resetCovariance() {
+	for i := range r.covariance {
+		for j := range r.covariance[i] {
+			r.covariance[i][j] = 0
+		}
+		r.covariance[i][i] = initialCovariance
+	}
+}
+
+func (r *onlineRegression) predict(x []float64) float64 {
+	y := 0.0
+	for i, xi := range x {
+		y += r.coefficients[i] * xi
+	}
+	return y
+}
+
+// update fits the regression to the observation y of the features x.
+func (r *onlineRegression) update(x []float64, y float64) {
+	if math.IsNaN(y) || math.IsInf(y, 0) {
+		return
+	}
+	n := len(x)
+
+	// px = P·x
+	px := make([]float64, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			px[i] += r.covariance[i][j] * x[j]
+		}
+	}
+	denominator := r.forgetting
+	for i := 0; i < n; i++ {
+		denominator += x[i] * px[i]
+	}
+
+	// gain = P·x / (λ + xᵀ·P·x)
+	gain := make([]float64, n)
+	for i := 0; i < n; i++ {
+		gain[i] = px[i] / denominator
+	}
+
+	predictionError := y - r.predict(x)
+	for i := 0; i < n; i++ {
+		r.coefficients[i] += gain[i] * predictionError
+	}
+
+	// P = (P - gain·xᵀ·P) / λ, P being symmetric xᵀ·P = (P·x)ᵀ.
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			r.covariance[i][j] = (r.covariance[i][j] - gain[i]*px[j]) / r.forgetting
+		}
+	}
+	r.boundCovariance()
+	r.samples++
+}
+
+// boundCovariance keeps the inverse correlation matrix symmetric and bounded. When the features do
+// not vary, e.g., on a steady load, the forgetting factor inflates the matrix in the unexcited
+// directions by 1/λ on every update, until it overflows. Its trace is thus capped to the initial
+// one, and the matrix is reset if it is no longer finite.
+func (r *onlineRegression) boundCovariance() {
+	n := len(r.covariance)
+	trace := 0.0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			mean := (r.covariance[i][j] + r.covariance[j][i]) / 2
+			r.covariance[i][j], r.covariance[j][i] = mean, mean
+		}
+		trace += r.covariance[i][i]
+	}
+	if math.IsNaN(trace) || math.IsInf(trace, 0) || trace <= 0 {
+		r.resetCovariance()
+		return
+	}
+	if maxTrace := initialCovariance * float64(n); trace > maxTrace {
+		scale := maxTrace / trace
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				r.covariance[i][j] *= scale
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"math"
+	"testing"
+)
+
+// TestOnlineRegressionSteadyLoad checks that the regression stays finite on a long run of identical
+// observations, which do not excite most directions of the feature space.
+func TestOnlineRegressionSteadyLoad(t *testing.T) {
+	r := newOnlineRegression(4, 0.99)
+	x := []float64{1, 2, 0, 0.5}
+	for i := 0; i < 200000; i++ {
+		r.update(x, 0.3)
+	}
+
+	if got := r.predict(x); math.IsNaN(got) || math.IsInf(got, 0) || math.Abs(got-0.3) > 1e-6 {
+		t.Errorf("Unexpected prediction, want 0.3, got %v", got)
+	}
+	for i, c := range r.coefficients {
+		if math.IsNaN(c) || math.IsInf(c, 0) {
+			t.Errorf("Unexpected coefficient %d: %v", i, c)
+		}
+	}
+	trace := 0.0
+	for i, row := range r.covariance {
+		for j, p := range row {
+			if math.IsNaN(p) || math.IsInf(p, 0) {
+				t.Fatalf("Unexpected covariance (%d, %d): %v", i, j, p)
+			}
+		}
+		trace += row[i]
+	}
+	if maxTrace := initialCovariance * float64(len(x)); trace > maxTrace*(1+1e-9) {
+		t.Errorf("Unexpected covariance trace, want at most %v, got %v", maxTrace, trace)
+	}
+}
+
+// TestOnlineRegressionConverges checks that the regression still fits a varying load once bounded.
+func TestOnlineRegressionConverges(t *testing.T) {
+	r := newOnlineRegression(2, 0.99)
+	for i := 0; i < 120000; i++ {
+		x := []float64{1, float64(i % 10)}
+		r.update(x, 0.1+0.05*x[1])
+	}
+	if got := r.predict([]float64{1, 4}); math.Abs(got-0.3) > 1e-6 {
+		t.Errorf("Unexpected prediction, want 0.3, got %v", got)
+	}
+}
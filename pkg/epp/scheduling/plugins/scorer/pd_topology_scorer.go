@@ -0,0 +1,88 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+const (
+	defaultSameRackScore = 0.75
+	defaultSameZoneScore = 0.5
+)
+
+// PDTopologyConfig contains initialization configuration for PDTopologyScorer.
+type PDTopologyConfig struct {
+	// SameRackScore is the score of decode pods in the rack of the prefill pod. Decode pods on the
+	// node of the prefill pod score 1.
+	SameRackScore float64
+	// SameZoneScore is the score of decode pods in the zone of the prefill pod.
+	SameZoneScore float64
+}
+
+// DefaultPDTopologyConfig returns a PDTopologyConfig instance with default configuration.
+func DefaultPDTopologyConfig() *PDTopologyConfig {
+	return &PDTopologyConfig{
+		SameRackScore: defaultSameRackScore,
+		SameZoneScore: defaultSameZoneScore,
+	}
+}
+
+// PDTopologyScorer scores decode pods by their topological proximity to the prefill pod selected
+// for the request, to keep the KV-cache transfer within a node, a rack or a zone. Decode pods on
+// other zones score 0.
+//
+// Requests without a prefill pod are not scored.
+type PDTopologyScorer struct {
+	scores map[backendmetrics.Locality]float64
+}
+
+var _ plugins.Scorer = &PDTopologyScorer{}
+
+// NewPDTopologyScorer creates a new PDTopologyScorer with the given configuration.
+// If the config is nil, default is used.
+func NewPDTopologyScorer(config *PDTopologyConfig) *PDTopologyScorer {
+	if config == nil {
+		config = DefaultPDTopologyConfig()
+	}
+	return &PDTopologyScorer{
+		scores: map[backendmetrics.Locality]float64{
+			backendmetrics.LocalityNode: 1,
+			backendmetrics.LocalityRack: config.SameRackScore,
+			backendmetrics.LocalityZone: config.SameZoneScore,
+		},
+	}
+}
+
+func (s *PDTopologyScorer) Name() string {
+	return "pd-topology-scorer"
+}
+
+func (s *PDTopologyScorer) Score(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
+	if ctx.PrefillPod == nil {
+		return nil
+	}
+
+	prefillPod := ctx.PrefillPod.GetPod()
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		scoredPods[pod] = s.scores[pod.GetPod().LocalityTo(prefillPod)]
+	}
+	return scoredPods
+}
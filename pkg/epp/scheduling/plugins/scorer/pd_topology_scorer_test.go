@@ -0,0 +1,98 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/scorer"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestPDTopologyScorer(t *testing.T) {
+	newPod := func(name, node, rack, zone string) *types.PodMetrics {
+		return &types.PodMetrics{
+			Pod: &backendmetrics.Pod{
+				NamespacedName: k8stypes.NamespacedName{Name: name},
+				NodeName:       node,
+				Rack:           rack,
+				Zone:           zone,
+			},
+			Metrics: &backendmetrics.Metrics{},
+		}
+	}
+	prefill := newPod("prefill", "node-a", "rack-a", "zone-a")
+	sameNode := newPod("same-node", "node-a", "rack-a", "zone-a")
+	sameRack := newPod("same-rack", "node-b", "rack-a", "zone-a")
+	sameZone := newPod("same-zone", "node-c", "rack-c", "zone-a")
+	sameRackOtherZone := newPod("same-rack-other-zone", "node-d", "rack-a", "zone-d")
+	remote := newPod("remote", "node-e", "rack-e", "zone-e")
+	unknown := newPod("unknown", "", "", "")
+	pods := []types.Pod{sameNode, sameRack, sameZone, sameRackOtherZone, remote, unknown}
+
+	tests := []struct {
+		name       string
+		prefillPod types.Pod
+		want       map[types.Pod]float64
+	}{
+		{
+			name:       "decode pods are scored by their proximity to the prefill pod",
+			prefillPod: prefill,
+			want: map[types.Pod]float64{
+				sameNode:          1,
+				sameRack:          0.75,
+				sameZone:          0.5,
+				sameRackOtherZone: 0,
+				remote:            0,
+				unknown:           0,
+			},
+		},
+		{
+			name:       "prefill pod with unknown topology",
+			prefillPod: unknown,
+			want: map[types.Pod]float64{
+				sameNode:          0,
+				sameRack:          0,
+				sameZone:          0,
+				sameRackOtherZone: 0,
+				remote:            0,
+				unknown:           0,
+			},
+		},
+		{
+			name: "no prefill pod",
+			want: nil,
+		},
+	}
+
+	s := scorer.NewPDTopologyScorer(nil)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, pods, 0)
+			ctx.PrefillPod = test.prefillPod
+			got := s.Score(ctx, pods)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
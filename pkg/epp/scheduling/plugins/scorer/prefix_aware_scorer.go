@@ -18,6 +18,7 @@ package scorer
 
 import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
@@ -33,6 +34,7 @@ type PrefixAwareScorer struct {
 }
 
 var _ plugins.Scorer = &PrefixAwareScorer{}
+var _ plugins.PodEventHandler = &PrefixAwareScorer{}
 
 // NewPrefixAwareScorer creates a new PrefixAwareScorer with the given
 // PrefixStoreConfig. If the config is nil, default is used.
@@ -54,14 +56,7 @@ func (s *PrefixAwareScorer) Score(ctx *types.SchedulingContext, pods []types.Pod
 		return nil
 	}
 
-	var prompt string
-	if ctx.Req.ChatCompletionRequest != nil {
-		prompt = ctx.Req.ChatCompletionRequest.ToString()
-	} else {
-		prompt = ctx.Req.Prompt
-	}
-
-	scores := s.prefixStore.FindMatchingPods(prompt, ctx.Req.Model)
+	scores := s.prefixStore.FindMatchingPods(ctx.PromptText(), ctx.Req.Model)
 	loggerDebug.Info("Got pod scores", "scores", scores)
 
 	if len(scores) == 0 {
@@ -99,19 +94,20 @@ func (s *PrefixAwareScorer) PostSchedule(ctx *types.SchedulingContext, res *type
 		return
 	}
 
-	var prompt string
-	if ctx.Req.ChatCompletionRequest != nil {
-		prompt = ctx.Req.ChatCompletionRequest.ToString()
-	} else {
-		prompt = ctx.Req.Prompt
-	}
-
-	if err := s.prefixStore.AddEntry(ctx.Req.Model, prompt, &pod.GetPod().NamespacedName); err != nil {
+	if err := s.prefixStore.AddEntry(ctx.Req.Model, ctx.PromptText(), &pod.GetPod().NamespacedName); err != nil {
 		debugLogger.Error(err, "Failed to add entry to prefix store", "req", ctx.Req, "pod", pod)
 		return
 	}
 }
 
+// OnPodEvent implements the PodEventHandler interface.
+// It purges the prefixes of removed pods, which no longer hold their cache.
+func (s *PrefixAwareScorer) OnPodEvent(event datastore.Event) {
+	if event.Type == datastore.PodRemoved {
+		s.prefixStore.RemovePod(event.Pod.NamespacedName)
+	}
+}
+
 // GetPrefixStore returns the scorer's PrefixStore.
 func (s *PrefixAwareScorer) GetPrefixStore() *PrefixStore {
 	return s.prefixStore
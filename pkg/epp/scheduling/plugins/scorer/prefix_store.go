@@ -148,6 +148,29 @@ func (s *PrefixStore) AddEntry(modelName string, prompt string, pod *types.Names
 	return nil
 }
 
+// RemovePod removes the given pod from all the blocks, dropping the blocks no pod holds anymore.
+func (s *PrefixStore) RemovePod(pod types.NamespacedName) {
+	// The pods of the blocks are mutated, which requires the write lock to exclude the readers.
+	s.Lock()
+	defer s.Unlock()
+	for _, cache := range s.store {
+		for _, blockHash := range cache.Keys() {
+			b, ok := cache.Peek(blockHash)
+			if !ok {
+				continue
+			}
+			if b.Pods.Remove(pod) && b.Pods.Len() == 0 {
+				cache.Remove(blockHash)
+			}
+		}
+	}
+}
+
+// BlockSize returns the number of characters of the prompt each block holds.
+func (s *PrefixStore) BlockSize() int {
+	return s.blockSize
+}
+
 // FindMatchingPods finds all pods that match the given prompt and model name.
 // It returns a map of pods and the number of blocks they match.
 func (s *PrefixStore) FindMatchingPods(prompt, modelName string) map[string]int {
@@ -57,3 +57,32 @@ func TestBasicPrefixOperations(t *testing.T) {
 		t.Errorf("Expected pod %v, scores %v", podName, scores)
 	}
 }
+
+// TestRemovePod tests that removed pods no longer match prefixes, while other pods still do.
+func TestRemovePod(t *testing.T) {
+	config := scorer.DefaultPrefixStoreConfig()
+	config.BlockSize = 5 // set small chunking for testing
+	store := scorer.NewPrefixStore(config)
+
+	pod1 := k8stypes.NamespacedName{Name: "pod1", Namespace: "default"}
+	pod2 := k8stypes.NamespacedName{Name: "pod2", Namespace: "default"}
+	for _, pod := range []k8stypes.NamespacedName{pod1, pod2} {
+		if err := store.AddEntry("model1", "hello world", &pod); err != nil {
+			t.Fatalf("Failed to add prefix: %v", err)
+		}
+	}
+
+	store.RemovePod(pod1)
+	scores := store.FindMatchingPods("hello world", "model1")
+	if _, ok := scores[pod1.String()]; ok {
+		t.Errorf("Unexpected removed pod %v, scores %v", pod1, scores)
+	}
+	if _, ok := scores[pod2.String()]; !ok {
+		t.Errorf("Expected pod %v, scores %v", pod2, scores)
+	}
+
+	store.RemovePod(pod2)
+	if scores := store.FindMatchingPods("hello world", "model1"); len(scores) != 0 {
+		t.Errorf("Expected no pods, scores %v", scores)
+	}
+}
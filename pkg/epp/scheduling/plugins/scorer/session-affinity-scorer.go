@@ -1,9 +1,12 @@
 /*
 Copyright 2025 The Kubernetes Authors.
+
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
 You may obtain a copy of the License at
-	http://www.apache.org/licenses/LICENSE-2.0
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
 Unless required by applicable law or agreed to in writing, software
 distributed under the License is distributed on an "AS IS" BASIS,
 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
@@ -14,66 +17,254 @@ limitations under the License.
 package scorer
 
 import (
-	"encoding/base64"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
 	"time"
 
+	lru "github.com/hashicorp/golang-lru/v2"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/config"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
 )
 
 const (
-	sessionKeepAliveTime           = 60 * time.Minute  // How long should an idle session be kept alive
-	sessionKeepAliveCheckFrequency = 15 * time.Minute  // How often to check for overly idle sessions
-	sessionTokenHeader             = "x-session-token" // name of the session header in request
+	sessionKeepAliveTime           = 60 * time.Minute // How long should an idle session be kept alive
+	sessionKeepAliveCheckFrequency = 15 * time.Minute // How often to check for overly idle sessions
+	sessionKeysReloadFrequency     = 1 * time.Minute  // How often to reload the session token keys
+
+	defaultSessionFallbackScore = 0.5
+	defaultMaxSessions          = 100000
 )
 
+// SessionAffinityConfig contains initialization configuration for SessionAffinity.
+type SessionAffinityConfig struct {
+	// KeysDir is the directory holding the session token keys, typically a mounted secret.
+	// If empty, a random key is generated and tokens are only valid for the current process.
+	KeysDir string
+	// Encrypt enables encryption of the session tokens, in addition to their authentication.
+	Encrypt bool
+	// FallbackScore is the score given to the pinned pod when it is overloaded, letting other
+	// scorers move the session elsewhere.
+	FallbackScore float64
+	// MaxSessions is the maximum number of sessions tracked, the least recently used sessions being
+	// forgotten beyond it.
+	MaxSessions int
+}
+
+// DefaultSessionAffinityConfig returns a SessionAffinityConfig instance with default configuration.
+func DefaultSessionAffinityConfig() *SessionAffinityConfig {
+	return &SessionAffinityConfig{
+		FallbackScore: defaultSessionFallbackScore,
+		MaxSessions:   defaultMaxSessions,
+	}
+}
+
 // sessionAffinity is a routing scorer that routes subsequent
 // requests in a session to the same pod as the first request in the
 // session was sent to, by giving that pod the specified weight and assigning
-// zero score to the rest of the targets
+// zero score to the rest of the targets.
+//
+// The session is carried by the client in a signed (and optionally encrypted) token that expires
+// once the session has been idle for sessionKeepAliveTime. Sessions are also tracked server side,
+// up to the configured maximum, and idle sessions are purged every sessionKeepAliveCheckFrequency.
+// A valid token of a session that is not tracked, e.g., issued by another replica or before a
+// restart, is trusted as long as its pod still exists, and the session is tracked from then on.
 type SessionAffinity struct {
+	codec         *sessionTokenCodec
+	fallbackScore float64
+
+	mu sync.Mutex
+	// key: session ID
+	sessions *lru.Cache[string, sessionState]
+}
+
+// sessionState is the server side state of a session.
+type sessionState struct {
+	// lastUsed is the last time the session was used.
+	lastUsed time.Time
+	// pod is the namespaced name of the pod the session is pinned to.
+	pod string
+}
+
+var _ plugins.Scorer = &SessionAffinity{}
+var _ plugins.PodEventHandler = &SessionAffinity{}
+
+// NewSessionAffinity creates a new SessionAffinity with the given configuration. If the config is
+// nil, default is used. Background maintenance stops when the given context is cancelled.
+func NewSessionAffinity(ctx context.Context, cfg *SessionAffinityConfig) (*SessionAffinity, error) {
+	if cfg == nil {
+		cfg = DefaultSessionAffinityConfig()
+	}
+
+	codec, err := newSessionTokenCodec(cfg.KeysDir, cfg.Encrypt)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := lru.New[string, sessionState](cfg.MaxSessions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the sessions cache: %w", err)
+	}
+
+	s := &SessionAffinity{
+		codec:         codec,
+		fallbackScore: cfg.FallbackScore,
+		sessions:      sessions,
+	}
+	go s.maintain(ctx, cfg.KeysDir)
+	return s, nil
+}
+
+// maintain periodically purges idle sessions and reloads the keys, to pick up rotations.
+func (s *SessionAffinity) maintain(ctx context.Context, keysDir string) {
+	logger := log.FromContext(ctx).WithName("session-affinity")
+
+	idleTicker := time.NewTicker(sessionKeepAliveCheckFrequency)
+	defer idleTicker.Stop()
+	reloadTicker := time.NewTicker(sessionKeysReloadFrequency)
+	defer reloadTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-idleTicker.C:
+			s.purgeIdleSessions(now)
+		case <-reloadTicker.C:
+			if keysDir == "" {
+				continue
+			}
+			if err := s.codec.loadKeys(keysDir); err != nil {
+				logger.Error(err, "Failed to reload session token keys, keeping the previous keys")
+			}
+		}
+	}
 }
 
-func NewSessionAffinity() *SessionAffinity {
-	return &SessionAffinity{}
+func (s *SessionAffinity) purgeIdleSessions(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, session := range s.sessions.Keys() {
+		if state, ok := s.sessions.Peek(session); ok && now.Sub(state.lastUsed) > sessionKeepAliveTime {
+			s.sessions.Remove(session)
+		}
+	}
 }
 
 func (s *SessionAffinity) Name() string {
 	return "session affinity scorer"
 }
 
-func (s *SessionAffinity) Score(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
-	scoredPods := make(map[types.Pod]float64)
-
-	reqHeaders := ctx.Req.Headers
+// pinnedSession returns the claims of the request session token, or nil if the request does not
+// carry a valid token of a live session pinned to an existing pod.
+func (s *SessionAffinity) pinnedSession(ctx *types.SchedulingContext, now time.Time) *sessionClaims {
+	if ctx.Req == nil || ctx.Req.SessionID == "" {
+		return nil
+	}
 
-	var sessionToken = ""
-	v, ok := reqHeaders[sessionTokenHeader]
-	if ok {
-		sessionToken = v
+	claims, err := s.codec.decode(ctx.Req.SessionID, now)
+	if err != nil {
+		ctx.Logger.V(logutil.DEBUG).Info("Ignoring session token", "reason", err)
+		return nil
 	}
 
-	podName := ""
-	if sessionToken != "" {
-		decodedBytes, err := base64.StdEncoding.DecodeString(sessionToken)
-		if err != nil {
-			ctx.Logger.Error(err, "Error decoding")
-		} else {
-			podName = string(decodedBytes)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.sessions.Get(claims.Session)
+	switch {
+	case !ok:
+		// The token was issued within the keep-alive time, as it is not expired, so the session is
+		// pinned to its pod, unless the pod is gone.
+		if !slices.ContainsFunc(ctx.PodsSnapshot, func(pod types.Pod) bool {
+			return pod.GetPod().NamespacedName.String() == claims.Pod
+		}) {
+			return nil
 		}
+		state.pod = claims.Pod
+	case now.Sub(state.lastUsed) > sessionKeepAliveTime:
+		s.sessions.Remove(claims.Session)
+		return nil
 	}
+	s.sessions.Add(claims.Session, sessionState{lastUsed: now, pod: state.pod})
+	return claims
+}
+
+func (s *SessionAffinity) Score(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
+	scoredPods := make(map[types.Pod]float64)
+
+	claims := s.pinnedSession(ctx, time.Now())
 	for _, pod := range pods {
-		if podName == "" {
+		if claims == nil || pod.GetPod().NamespacedName.String() != claims.Pod {
 			scoredPods[pod] = 0.0
+			continue
+		}
+		if isOverloaded(pod) {
+			// Keep a preference for the pinned pod, while letting other scorers take over.
+			scoredPods[pod] = s.fallbackScore
 		} else {
-			if pod.GetPod().NamespacedName.String() == podName {
-				scoredPods[pod] = 1.0
-			}
+			scoredPods[pod] = 1.0
 		}
 	}
 
 	return scoredPods
 }
 
+// isOverloaded checks whether the pod is beyond the capacity thresholds used for sheddable requests.
+func isOverloaded(pod types.Pod) bool {
+	metrics := pod.GetMetrics()
+	return metrics.WaitingQueueSize > config.Conf.QueueThresholdCritical ||
+		metrics.KVCacheUsagePercent > config.Conf.KVCacheThreshold
+}
+
 func (s *SessionAffinity) PostResponse(ctx *types.SchedulingContext, pod types.Pod) {
-	ctx.MutatedHeaders[sessionTokenHeader] = base64.StdEncoding.EncodeToString([]byte(pod.GetPod().NamespacedName.String()))
+	if pod == nil || pod.GetPod() == nil {
+		return
+	}
+
+	now := time.Now()
+	sessionID := ""
+	if claims := s.pinnedSession(ctx, now); claims != nil {
+		sessionID = claims.Session
+	} else {
+		var err error
+		if sessionID, err = newSessionID(); err != nil {
+			ctx.Logger.Error(err, "Failed to create a session")
+			return
+		}
+	}
+	podName := pod.GetPod().NamespacedName.String()
+	s.mu.Lock()
+	s.sessions.Add(sessionID, sessionState{lastUsed: now, pod: podName})
+	s.mu.Unlock()
+
+	token, err := s.codec.encode(&sessionClaims{
+		Session: sessionID,
+		Pod:     podName,
+		Expiry:  now.Add(sessionKeepAliveTime).Unix(),
+	})
+	if err != nil {
+		ctx.Logger.Error(err, "Failed to issue a session token")
+		return
+	}
+	ctx.MutatedHeaders[types.SessionTokenHeader] = token
+}
+
+// OnPodEvent implements the PodEventHandler interface.
+// It forgets the sessions pinned to removed pods, which are moved to other pods by their next request.
+func (s *SessionAffinity) OnPodEvent(event datastore.Event) {
+	if event.Type != datastore.PodRemoved {
+		return
+	}
+	podName := event.Pod.NamespacedName.String()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, session := range s.sessions.Keys() {
+		if state, ok := s.sessions.Peek(session); ok && state.pod == podName {
+			s.sessions.Remove(session)
+		}
+	}
 }
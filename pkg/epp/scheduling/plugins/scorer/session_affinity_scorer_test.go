@@ -0,0 +1,197 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/scorer"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// newSessionAffinity creates a scorer whose background maintenance stops with the test.
+func newSessionAffinity(t *testing.T, cfg *scorer.SessionAffinityConfig) *scorer.SessionAffinity {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	s, err := scorer.NewSessionAffinity(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Failed to create scorer: %v", err)
+	}
+	return s
+}
+
+func newSessionTestPod(name string, waiting int) *types.PodMetrics {
+	return &types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name, Namespace: "default"}},
+		Metrics: &backendmetrics.Metrics{WaitingQueueSize: waiting},
+	}
+}
+
+// issueToken returns the session token issued by the scorer after the given pod served a request.
+func issueToken(t *testing.T, s *scorer.SessionAffinity, sessionToken string, pod types.Pod) string {
+	t.Helper()
+	sCtx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{SessionID: sessionToken}, nil, 0)
+	s.PostResponse(sCtx, pod)
+	token, ok := sCtx.MutatedHeaders[types.SessionTokenHeader]
+	if !ok {
+		t.Fatal("Expected a session token to be issued")
+	}
+	return token
+}
+
+func scoreWithToken(s *scorer.SessionAffinity, token string, pods []types.Pod) map[types.Pod]float64 {
+	sCtx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{SessionID: token}, pods, 0)
+	return s.Score(sCtx, pods)
+}
+
+func writeSessionKey(t *testing.T, dir, name, key string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(key), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSessionAffinityScore(t *testing.T) {
+	pod1 := newSessionTestPod("pod1", 0)
+	pod2 := newSessionTestPod("pod2", 0)
+	busyPod := newSessionTestPod("busy", 100)
+	pods := []types.Pod{pod1, pod2, busyPod}
+
+	for _, encrypt := range []bool{false, true} {
+		cfg := scorer.DefaultSessionAffinityConfig()
+		cfg.Encrypt = encrypt
+		s := newSessionAffinity(t, cfg)
+
+		token := issueToken(t, s, "", pod1)
+		if strings.Contains(token, "pod1") {
+			t.Errorf("Session token %q leaks the pod name", token)
+		}
+
+		tamperedToken := token[:len(token)-2] + "AA"
+		if tamperedToken == token {
+			tamperedToken = token[:len(token)-2] + "BB"
+		}
+
+		tests := []struct {
+			name  string
+			token string
+			want  map[types.Pod]float64
+		}{
+			{
+				name:  "no token",
+				token: "",
+				want:  map[types.Pod]float64{pod1: 0, pod2: 0, busyPod: 0},
+			},
+			{
+				name:  "valid token",
+				token: token,
+				want:  map[types.Pod]float64{pod1: 1, pod2: 0, busyPod: 0},
+			},
+			{
+				name:  "tampered token",
+				token: tamperedToken,
+				want:  map[types.Pod]float64{pod1: 0, pod2: 0, busyPod: 0},
+			},
+			{
+				name:  "legacy base64 token",
+				token: "ZGVmYXVsdC9wb2Qx", // base64 of "default/pod1"
+				want:  map[types.Pod]float64{pod1: 0, pod2: 0, busyPod: 0},
+			},
+			{
+				name:  "overloaded pinned pod",
+				token: issueToken(t, s, "", busyPod),
+				want:  map[types.Pod]float64{pod1: 0, pod2: 0, busyPod: cfg.FallbackScore},
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				if diff := cmp.Diff(test.want, scoreWithToken(s, test.token, pods)); diff != "" {
+					t.Errorf("Unexpected output (encrypt=%t) (-want +got): %v", encrypt, diff)
+				}
+			})
+		}
+
+		// The pinned pod is gone, a token for the new pod is issued within the same session.
+		newToken := issueToken(t, s, token, pod2)
+		if diff := cmp.Diff(map[types.Pod]float64{pod1: 0, pod2: 1}, scoreWithToken(s, newToken, []types.Pod{pod1, pod2})); diff != "" {
+			t.Errorf("Unexpected output after re-pinning (-want +got): %v", diff)
+		}
+	}
+}
+
+func TestSessionAffinitySessions(t *testing.T) {
+	pod1 := newSessionTestPod("pod1", 0)
+	pod2 := newSessionTestPod("pod2", 0)
+	pods := []types.Pod{pod1, pod2}
+	unpinned := map[types.Pod]float64{pod1: 0, pod2: 0}
+
+	cfg := scorer.DefaultSessionAffinityConfig()
+	cfg.KeysDir = t.TempDir()
+	writeSessionKey(t, cfg.KeysDir, "2025-01-01", "secret")
+	cfg.MaxSessions = 2
+	s := newSessionAffinity(t, cfg)
+
+	// A valid token issued by another scorer with the same keys, e.g., another replica or before a
+	// restart, is trusted as long as its pod exists, and its session is tracked from then on.
+	otherToken := issueToken(t, newSessionAffinity(t, cfg), "", pod1)
+	if diff := cmp.Diff(map[types.Pod]float64{pod1: 1, pod2: 0}, scoreWithToken(s, otherToken, pods)); diff != "" {
+		t.Errorf("Unexpected output for a session of another scorer (-want +got): %v", diff)
+	}
+	if reissued := issueToken(t, s, otherToken, pod2); reissued == otherToken {
+		t.Error("Expected a token pinned to the new pod to be issued")
+	} else if diff := cmp.Diff(map[types.Pod]float64{pod1: 0, pod2: 1}, scoreWithToken(s, reissued, pods)); diff != "" {
+		t.Errorf("Unexpected output for a re-pinned session of another scorer (-want +got): %v", diff)
+	}
+
+	// A valid token of a session pinned to a pod that no longer exists is not trusted.
+	goneToken := issueToken(t, newSessionAffinity(t, cfg), "", newSessionTestPod("gone", 0))
+	if diff := cmp.Diff(unpinned, scoreWithToken(s, goneToken, pods)); diff != "" {
+		t.Errorf("Unexpected output for a session of a missing pod (-want +got): %v", diff)
+	}
+
+	// The sessions of a removed pod are forgotten, and not revived by their tokens.
+	token1 := issueToken(t, s, "", pod1)
+	s.OnPodEvent(datastore.Event{Type: datastore.PodRemoved, Pod: pod1.GetPod()})
+	if diff := cmp.Diff(map[types.Pod]float64{pod2: 0}, scoreWithToken(s, token1, []types.Pod{pod2})); diff != "" {
+		t.Errorf("Unexpected output for a session of a removed pod (-want +got): %v", diff)
+	}
+	token1 = issueToken(t, s, token1, pod2)
+	if diff := cmp.Diff(map[types.Pod]float64{pod1: 0, pod2: 1}, scoreWithToken(s, token1, pods)); diff != "" {
+		t.Errorf("Unexpected output for a new session (-want +got): %v", diff)
+	}
+
+	// Beyond the maximum number of sessions, the least recently used ones are forgotten, and tracked
+	// again by their next request.
+	token2 := issueToken(t, s, "", pod1)
+	scoreWithToken(s, token1, pods)
+	issueToken(t, s, "", pod1)
+	if diff := cmp.Diff(map[types.Pod]float64{pod1: 1, pod2: 0}, scoreWithToken(s, token2, pods)); diff != "" {
+		t.Errorf("Unexpected output for an evicted session (-want +got): %v", diff)
+	}
+	if diff := cmp.Diff(map[types.Pod]float64{pod1: 0, pod2: 1}, scoreWithToken(s, token1, pods)); diff != "" {
+		t.Errorf("Unexpected output for a recently used session (-want +got): %v", diff)
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sessionTokenSeparator   = "."
+	sessionKeyLabelSigning  = "session-token-signing"
+	sessionKeyLabelEncrypt  = "session-token-encryption"
+	generatedSessionKeyID   = "generated"
+	generatedSessionKeySize = 32
+)
+
+var (
+	errInvalidSessionToken = errors.New("invalid session token")
+	errExpiredSessionToken = errors.New("expired session token")
+)
+
+// sessionClaims is the content of a session token.
+type sessionClaims struct {
+	// Session is a random identifier of the session.
+	Session string `json:"s"`
+	// Pod is the namespaced name of the pod the session is pinned to.
+	Pod string `json:"p"`
+	// Expiry is the unix time after which the token is no longer accepted.
+	Expiry int64 `json:"e"`
+}
+
+// sessionTokenCodec issues and verifies session tokens.
+//
+// Tokens are signed with HMAC-SHA256, or encrypted with AES-256-GCM when encryption is enabled, so
+// that clients can neither read the pod names nor forge tokens pinning traffic to arbitrary pods.
+// Every token carries the ID of the key that issued it, which allows rotating keys: new tokens are
+// issued with the active key while tokens issued with any other loaded key remain valid.
+type sessionTokenCodec struct {
+	encrypt bool
+
+	mu          sync.RWMutex
+	keys        map[string][]byte
+	activeKeyID string
+}
+
+// newSessionTokenCodec creates a codec with the keys found in the given directory. If the directory
+// is empty, a random key is generated, in which case tokens are only valid for this process.
+func newSessionTokenCodec(keysDir string, encrypt bool) (*sessionTokenCodec, error) {
+	c := &sessionTokenCodec{encrypt: encrypt}
+	if keysDir == "" {
+		key := make([]byte, generatedSessionKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate session token key: %w", err)
+		}
+		c.setKeys(map[string][]byte{generatedSessionKeyID: key}, generatedSessionKeyID)
+		return c, nil
+	}
+	if err := c.loadKeys(keysDir); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loadKeys (re)loads the keys from the given directory, typically a mounted secret. Every regular
+// file is a key, named by its file name. The key with the lexicographically greatest name is used
+// to issue new tokens, e.g., name keys by their creation date.
+func (c *sessionTokenCodec) loadKeys(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read session token keys from %s: %w", dir, err)
+	}
+
+	keys := make(map[string][]byte)
+	names := []string{}
+	for _, entry := range entries {
+		// Skip the hidden files and directories created by the kubelet for mounted secrets.
+		if strings.HasPrefix(entry.Name(), ".") || entry.IsDir() {
+			continue
+		}
+		key, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read session token key %s: %w", entry.Name(), err)
+		}
+		key = []byte(strings.TrimSpace(string(key)))
+		if len(key) == 0 {
+			continue
+		}
+		keys[entry.Name()] = key
+		names = append(names, entry.Name())
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no session token keys found in %s", dir)
+	}
+	sort.Strings(names)
+
+	c.setKeys(keys, names[len(names)-1])
+	return nil
+}
+
+func (c *sessionTokenCodec) setKeys(keys map[string][]byte, activeKeyID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = keys
+	c.activeKeyID = activeKeyID
+}
+
+// deriveKey derives a purpose specific key, so the same secret is never used both for signing and
+// encryption.
+func deriveKey(secret []byte, label string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+func newSessionID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// encode issues a token for the given claims with the active key.
+func (c *sessionTokenCodec) encode(claims *sessionClaims) (string, error) {
+	c.mu.RLock()
+	keyID, secret := c.activeKeyID, c.keys[c.activeKeyID]
+	c.mu.RUnlock()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	encodedKeyID := base64.RawURLEncoding.EncodeToString([]byte(keyID))
+	if c.encrypt {
+		aead, err := newSessionAEAD(secret)
+		if err != nil {
+			return "", err
+		}
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		// The key ID is authenticated as additional data.
+		sealed := aead.Seal(nonce, nonce, payload, []byte(keyID))
+		return encodedKeyID + sessionTokenSeparator + base64.RawURLEncoding.EncodeToString(sealed), nil
+	}
+
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	signed := encodedKeyID + sessionTokenSeparator + encodedPayload
+	return signed + sessionTokenSeparator + base64.RawURLEncoding.EncodeToString(sign(secret, signed)), nil
+}
+
+// decode verifies the given token and returns its claims if the token is valid at the given time.
+func (c *sessionTokenCodec) decode(token string, now time.Time) (*sessionClaims, error) {
+	parts := strings.Split(token, sessionTokenSeparator)
+	if len(parts) == 0 {
+		return nil, errInvalidSessionToken
+	}
+	keyID, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errInvalidSessionToken
+	}
+	c.mu.RLock()
+	secret, ok := c.keys[string(keyID)]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key", errInvalidSessionToken)
+	}
+
+	var payload []byte
+	if c.encrypt {
+		if len(parts) != 2 {
+			return nil, errInvalidSessionToken
+		}
+		payload, err = openSessionToken(secret, parts[1], keyID)
+	} else {
+		if len(parts) != 3 {
+			return nil, errInvalidSessionToken
+		}
+		payload, err = verifySessionToken(secret, parts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &sessionClaims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, errInvalidSessionToken
+	}
+	if now.Unix() > claims.Expiry {
+		return nil, errExpiredSessionToken
+	}
+	return claims, nil
+}
+
+func sign(secret []byte, data string) []byte {
+	mac := hmac.New(sha256.New, deriveKey(secret, sessionKeyLabelSigning))
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func verifySessionToken(secret []byte, parts []string) ([]byte, error) {
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errInvalidSessionToken
+	}
+	if !hmac.Equal(signature, sign(secret, parts[0]+sessionTokenSeparator+parts[1])) {
+		return nil, fmt.Errorf("%w: bad signature", errInvalidSessionToken)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errInvalidSessionToken
+	}
+	return payload, nil
+}
+
+func newSessionAEAD(secret []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveKey(secret, sessionKeyLabelEncrypt))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func openSessionToken(secret []byte, encoded string, keyID []byte) ([]byte, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errInvalidSessionToken
+	}
+	aead, err := newSessionAEAD(secret)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errInvalidSessionToken
+	}
+	payload, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], keyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decryption failed", errInvalidSessionToken)
+	}
+	return payload, nil
+}
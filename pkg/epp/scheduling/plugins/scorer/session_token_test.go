@@ -0,0 +1,88 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSessionTokenKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	writeKey := func(name, key string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(key), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	claims := &sessionClaims{Session: "session", Pod: "default/pod1", Expiry: now.Add(time.Hour).Unix()}
+
+	writeKey("2025-01-01", "old-secret")
+	// Files created by the kubelet for mounted secrets are ignored.
+	if err := os.Mkdir(filepath.Join(dir, "..data"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	codec, err := newSessionTokenCodec(dir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	oldToken, err := codec.encode(claims)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Rotate: the new key issues tokens, the old key still verifies.
+	writeKey("2025-02-01", "new-secret")
+	if err := codec.loadKeys(dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := codec.decode(oldToken, now); err != nil {
+		t.Errorf("Token issued with the old key is not accepted: %v", err)
+	}
+	newToken, err := codec.encode(claims)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	oldDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(oldDir, "2025-01-01"), []byte("old-secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	oldCodec, err := newSessionTokenCodec(oldDir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := oldCodec.decode(newToken, now); err == nil {
+		t.Error("Token issued with an unknown key is accepted")
+	}
+
+	// Retire the old key.
+	if err := os.Remove(filepath.Join(dir, "2025-01-01")); err != nil {
+		t.Fatal(err)
+	}
+	if err := codec.loadKeys(dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := codec.decode(oldToken, now); err == nil {
+		t.Error("Token issued with a retired key is accepted")
+	}
+	if got, err := codec.decode(newToken, now); err != nil || got.Pod != claims.Pod {
+		t.Errorf("Token issued with the active key is not accepted: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// ZoneLocalityScorer scores the pods in the zone of the gateway that received the request 1, and
+// the pods of other or unknown zones 0. Unlike ZoneLocalityFilter, it trades locality off against
+// the other scorers rather than enforcing it.
+//
+// Requests from a gateway of unknown zone are not scored.
+type ZoneLocalityScorer struct{}
+
+var _ plugins.Scorer = &ZoneLocalityScorer{}
+
+func (s *ZoneLocalityScorer) Name() string {
+	return "zone-locality-scorer"
+}
+
+func (s *ZoneLocalityScorer) Score(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
+	if ctx.Req == nil || ctx.Req.GatewayZone == "" {
+		return nil
+	}
+
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		if pod.GetPod().Zone == ctx.Req.GatewayZone {
+			scoredPods[pod] = 1
+		} else {
+			scoredPods[pod] = 0
+		}
+	}
+	return scoredPods
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/scorer"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestZoneLocalityScorer(t *testing.T) {
+	newPod := func(name, zone string) *types.PodMetrics {
+		return &types.PodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}, Zone: zone},
+			Metrics: &backendmetrics.Metrics{},
+		}
+	}
+	local := newPod("local", "zone-a")
+	remote := newPod("remote", "zone-b")
+	unknown := newPod("unknown", "")
+
+	tests := []struct {
+		name  string
+		req   *types.LLMRequest
+		input []types.Pod
+		want  map[types.Pod]float64
+	}{
+		{
+			name:  "pods in the gateway zone score 1",
+			req:   &types.LLMRequest{GatewayZone: "zone-a"},
+			input: []types.Pod{local, remote, unknown},
+			want:  map[types.Pod]float64{local: 1, remote: 0, unknown: 0},
+		},
+		{
+			name:  "no pod in the gateway zone",
+			req:   &types.LLMRequest{GatewayZone: "zone-c"},
+			input: []types.Pod{local, remote},
+			want:  map[types.Pod]float64{local: 0, remote: 0},
+		},
+		{
+			name:  "gateway of unknown zone is not scored",
+			req:   &types.LLMRequest{},
+			input: []types.Pod{local, remote, unknown},
+			want:  nil,
+		},
+	}
+
+	s := &scorer.ZoneLocalityScorer{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), test.req, test.input, 0)
+			got := s.Score(ctx, test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/remote"
+	envutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/env"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+	"sigs.k8s.io/yaml"
+)
+
+const remotePluginsConfigFileEnvVar = "REMOTE_PLUGINS_CONFIG_FILE"
+
+const (
+	remoteScorerType = "scorer"
+	remoteFilterType = "filter"
+)
+
+// remotePluginsConfig declares the remote plugins of the schedulers, e.g.:
+//
+//	plugins:
+//	- name: my-scorer
+//	  type: scorer
+//	  address: unix:///var/run/my-scorer.sock
+//	  timeout: 20ms
+//	  weight: 2
+//	  headers:
+//	  - x-user-tier
+//	- name: my-filter
+//	  type: filter
+//	  address: my-filter.default.svc:9000
+//	  tls:
+//	    caFile: /etc/epp/remote-ca.crt
+//	  failClosed: true
+//	  scheduler: decode
+type remotePluginsConfig struct {
+	Plugins []remotePluginSpec `json:"plugins"`
+}
+
+type remotePluginSpec struct {
+	Name string `json:"name"`
+	// Type is either "scorer" or "filter".
+	Type    string `json:"type"`
+	Address string `json:"address"`
+	// Timeout is the deadline of each call, e.g., "50ms".
+	Timeout string `json:"timeout,omitempty"`
+	// Weight is the weight of a scorer, 1 if not set.
+	Weight     int  `json:"weight,omitempty"`
+	FailClosed bool `json:"failClosed,omitempty"`
+	// Scheduler is the scheduler the plugin is added to, one of "default", "prefill", "decode" and
+	// "encode". Defaults to "default".
+	Scheduler string `json:"scheduler,omitempty"`
+	// Headers is the allow-list of request headers sent to the plugin, none if empty.
+	Headers []string `json:"headers,omitempty"`
+	// TLS secures the connection to the plugin, which is otherwise in plaintext.
+	TLS *remoteTLSSpec `json:"tls,omitempty"`
+}
+
+type remoteTLSSpec struct {
+	CAFile     string `json:"caFile,omitempty"`
+	CertFile   string `json:"certFile,omitempty"`
+	KeyFile    string `json:"keyFile,omitempty"`
+	ServerName string `json:"serverName,omitempty"`
+}
+
+// setRemotePlugins adds the plugins of the remote plugins configuration file to the schedulers.
+func setRemotePlugins() {
+	ctx := context.Background()
+	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
+
+	path := envutil.GetEnvString(remotePluginsConfigFileEnvVar, "", loggerDebug)
+	if path == "" {
+		loggerDebug.Info("Skipping remote plugins configuration as no file is set")
+		return
+	}
+	if err := loadRemotePlugins(path, loggerDebug); err != nil {
+		loggerDebug.Error(err, "Failed to load remote plugins configuration", "path", path)
+		configErrors = append(configErrors, fmt.Errorf("invalid remote plugins configuration %s - %w", path, err))
+	}
+}
+
+// loadRemotePlugins adds the remote plugins declared in the given file to the schedulers. All the
+// plugins are created before any is added, so that an invalid file adds none.
+func loadRemotePlugins(path string, logger logr.Logger) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	cfg := &remotePluginsConfig{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return fmt.Errorf("failed to parse remote plugins - %w", err)
+	}
+
+	schedulers := schedulerConfigsByName()
+	remotePlugins := make([]*remotePlugin, 0, len(cfg.Plugins))
+	for _, spec := range cfg.Plugins {
+		plugin, err := newRemotePlugin(spec, schedulers)
+		if err != nil {
+			for _, created := range remotePlugins {
+				created.shutdown()
+			}
+			return err
+		}
+		remotePlugins = append(remotePlugins, plugin)
+	}
+
+	for _, plugin := range remotePlugins {
+		if plugin.scorer != nil {
+			weight := plugin.spec.Weight
+			if weight <= 0 {
+				weight = 1
+			}
+			plugin.scheduler.scorers[plugin.scorer] = weight
+			logger.Info("Initialized remote scorer", "name", plugin.spec.Name, "address", plugin.spec.Address,
+				"weight", weight, "scheduler", plugin.spec.Scheduler, "tls", plugin.spec.TLS != nil)
+		} else {
+			plugin.scheduler.filters = append(plugin.scheduler.filters, plugin.filter)
+			logger.Info("Initialized remote filter", "name", plugin.spec.Name, "address", plugin.spec.Address,
+				"failClosed", plugin.spec.FailClosed, "scheduler", plugin.spec.Scheduler, "tls", plugin.spec.TLS != nil)
+		}
+	}
+	return nil
+}
+
+// remotePlugin is a remote scorer or filter created from its spec, to be added to its scheduler.
+type remotePlugin struct {
+	spec      remotePluginSpec
+	scheduler *SchedulerConfig
+	scorer    *remote.Scorer
+	filter    *remote.Filter
+}
+
+// newRemotePlugin validates the given spec and creates its plugin.
+func newRemotePlugin(spec remotePluginSpec, schedulers map[string]*SchedulerConfig) (*remotePlugin, error) {
+	if spec.Name == "" || spec.Address == "" {
+		return nil, fmt.Errorf("remote plugin %q: name and address are required", spec.Name)
+	}
+	scheduler, ok := schedulers[spec.Scheduler]
+	if !ok {
+		return nil, fmt.Errorf("remote plugin %s: unknown scheduler %q", spec.Name, spec.Scheduler)
+	}
+	pluginConfig := &remote.Config{Name: spec.Name, Address: spec.Address, FailClosed: spec.FailClosed, Headers: spec.Headers}
+	if spec.Timeout != "" {
+		var err error
+		if pluginConfig.Timeout, err = time.ParseDuration(spec.Timeout); err != nil {
+			return nil, fmt.Errorf("remote plugin %s: invalid timeout - %w", spec.Name, err)
+		}
+	}
+	if spec.TLS != nil {
+		pluginConfig.TLS = &remote.TLSConfig{CAFile: spec.TLS.CAFile, CertFile: spec.TLS.CertFile,
+			KeyFile: spec.TLS.KeyFile, ServerName: spec.TLS.ServerName}
+	}
+
+	plugin := &remotePlugin{spec: spec, scheduler: scheduler}
+	var err error
+	switch spec.Type {
+	case remoteScorerType:
+		plugin.scorer, err = remote.NewScorer(pluginConfig)
+	case remoteFilterType:
+		plugin.filter, err = remote.NewFilter(pluginConfig)
+	default:
+		err = fmt.Errorf("remote plugin %s: unknown type %q", spec.Name, spec.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return plugin, nil
+}
+
+// shutdown closes the connection of the plugin.
+func (p *remotePlugin) shutdown() {
+	if p.scorer != nil {
+		p.scorer.Shutdown()
+	} else {
+		p.filter.Shutdown()
+	}
+}
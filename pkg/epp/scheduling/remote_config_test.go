@@ -0,0 +1,108 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/remote"
+)
+
+func TestLoadRemotePlugins(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		wantErr     bool
+		wantScorers int
+		wantFilters int
+	}{
+		{
+			name: "valid plugins",
+			config: `
+plugins:
+- name: my-scorer
+  type: scorer
+  address: unix:///var/run/my-scorer.sock
+  headers: [x-user-tier]
+- name: my-filter
+  type: filter
+  address: my-filter.default.svc:9000
+  tls:
+    serverName: my-filter
+`,
+			wantScorers: 1,
+			wantFilters: 1,
+		},
+		{
+			name: "invalid plugin after a valid one",
+			config: `
+plugins:
+- name: my-scorer
+  type: scorer
+  address: unix:///var/run/my-scorer.sock
+- name: my-filter
+  type: filter
+  address: my-filter.default.svc:9000
+  tls:
+    caFile: /no/such/ca.crt
+`,
+			wantErr: true,
+		},
+		{
+			name: "unknown type",
+			config: `
+plugins:
+- name: my-plugin
+  type: picker
+  address: unix:///var/run/my-plugin.sock
+`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			saved := defaultConfig
+			defer func() { defaultConfig = saved }()
+			defaultConfig = &SchedulerConfig{scorers: map[plugins.Scorer]int{}}
+
+			path := filepath.Join(t.TempDir(), "remote-plugins.yaml")
+			if err := os.WriteFile(path, []byte(test.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			err := loadRemotePlugins(path, logr.Discard())
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Unexpected error, want %t, got %v", test.wantErr, err)
+			}
+
+			// An invalid file adds no plugin.
+			if len(defaultConfig.scorers) != test.wantScorers || len(defaultConfig.filters) != test.wantFilters {
+				t.Errorf("Unexpected plugins, want %d scorers and %d filters, got %v and %v",
+					test.wantScorers, test.wantFilters, defaultConfig.scorers, defaultConfig.filters)
+			}
+			for scorer := range defaultConfig.scorers {
+				scorer.(*remote.Scorer).Shutdown()
+			}
+			for _, filter := range defaultConfig.filters {
+				filter.(*remote.Filter).Shutdown()
+			}
+		})
+	}
+}
@@ -74,25 +74,33 @@ func NewScheduler(datastore Datastore) *Scheduler {
 }
 
 func NewSchedulerWithConfig(datastore Datastore, config *SchedulerConfig) *Scheduler {
-	return &Scheduler{
-		datastore:           datastore,
-		preSchedulePlugins:  config.preSchedulePlugins,
-		filters:             config.filters,
-		scorers:             config.scorers,
-		picker:              config.picker,
-		postSchedulePlugins: config.postSchedulePlugins,
-		postResponsePlugins: config.postResponsePlugins,
+	s := &Scheduler{
+		datastore:             datastore,
+		preSchedulePlugins:    config.preSchedulePlugins,
+		filters:               config.filters,
+		scorers:               config.scorers,
+		picker:                config.picker,
+		postSchedulePlugins:   config.postSchedulePlugins,
+		postResponsePlugins:   config.postResponsePlugins,
+		postCompletionPlugins: config.postCompletionPlugins,
 	}
+	if weights, ok := shadowWeights[config]; ok {
+		s.shadow = &shadowScheduler{weights: weights, sampleRate: shadowSampleRate}
+	}
+	return s
 }
 
 type Scheduler struct {
-	datastore           Datastore
-	preSchedulePlugins  []plugins.PreSchedule
-	filters             []plugins.Filter
-	scorers             map[plugins.Scorer]int // map from scorer to its weight
-	picker              plugins.Picker
-	postSchedulePlugins []plugins.PostSchedule
-	postResponsePlugins []plugins.PostResponse
+	datastore             Datastore
+	preSchedulePlugins    []plugins.PreSchedule
+	filters               []plugins.Filter
+	scorers               map[plugins.Scorer]int // map from scorer to its weight
+	picker                plugins.Picker
+	postSchedulePlugins   []plugins.PostSchedule
+	postResponsePlugins   []plugins.PostResponse
+	postCompletionPlugins []plugins.PostCompletion
+	// shadow evaluates a candidate configuration on a sample of the scheduling cycles, nil if none.
+	shadow *shadowScheduler
 }
 
 type Datastore interface {
@@ -109,7 +117,25 @@ func createSchedulerContext(ctx context.Context, req *types.LLMRequest, datastor
 	// Snapshot pod metrics from the datastore to:
 	// 1. Reduce concurrent access to the datastore.
 	// 2. Ensure consistent data during the scheduling operation of a request.
-	return types.NewSchedulingContext(ctx, req, types.ToSchedulerPodMetrics(datastore.PodGetAll()), pool.Spec.TargetPortNumber), nil
+	sCtx := types.NewSchedulingContext(ctx, req, types.ToSchedulerPodMetrics(datastore.PodGetAll()), pool.Spec.TargetPortNumber)
+	NotifyPodsSnapshot(ctx, sCtx.PodsSnapshot, sCtx.TargetPort)
+	return sCtx, nil
+}
+
+type podsSnapshotObserverKey struct{}
+
+// WithPodsSnapshotObserver returns a context whose scheduling calls the given function with the
+// snapshot of the pods the request is scheduled on, e.g., to record what the scheduler saw.
+func WithPodsSnapshotObserver(ctx context.Context, observe func(pods []types.Pod, targetPort int32)) context.Context {
+	return context.WithValue(ctx, podsSnapshotObserverKey{}, observe)
+}
+
+// NotifyPodsSnapshot calls the observer of the given context, if any, with the snapshot of the pods
+// a request is scheduled on. Schedulers call it once they took their snapshot.
+func NotifyPodsSnapshot(ctx context.Context, pods []types.Pod, targetPort int32) {
+	if observe, ok := ctx.Value(podsSnapshotObserverKey{}).(func([]types.Pod, int32)); ok {
+		observe(pods, targetPort)
+	}
 }
 
 // Schedule finds the target pod based on metrics and the requested lora adapter.
@@ -122,20 +148,34 @@ func (s *Scheduler) Schedule(ctx context.Context, req *types.LLMRequest) (*types
 		return nil, err
 	}
 
-	return s.scheduleWithContext(ctx, sCtx, req, loggerDebug)
+	return s.scheduleWithContext(sCtx, loggerDebug)
+}
+
+func (s *Scheduler) scheduleWithContext(sCtx *types.SchedulingContext, loggerDebug logr.Logger) (*types.Result, error) {
+	shadowCycle := s.shadow.newCycle()
+	result, err := s.schedule(sCtx, loggerDebug, shadowCycle)
+	if shadowCycle != nil {
+		s.shadow.record(shadowCycle, result)
+	}
+	return result, err
 }
 
-func (s *Scheduler) scheduleWithContext(ctx context.Context, sCtx *types.SchedulingContext, req *types.LLMRequest, loggerDebug logr.Logger) (*types.Result, error) {
+// schedule runs a scheduling cycle, recording the scores of the scorers into the given shadow cycle
+// if not nil.
+func (s *Scheduler) schedule(sCtx *types.SchedulingContext, loggerDebug logr.Logger, shadowCycle *shadowCycle) (*types.Result, error) {
 	loggerDebug.Info(fmt.Sprintf("Scheduling a request, Metrics: %+v", sCtx.PodsSnapshot))
 
 	s.runPreSchedulePlugins(sCtx)
 
 	pods := s.runFilterPlugins(sCtx)
 	if len(pods) == 0 {
+		if sCtx.Rejection != nil {
+			return nil, sCtx.Rejection
+		}
 		return nil, errutil.Error{Code: errutil.InferencePoolResourceExhausted, Msg: "failed to find a target pod"}
 	}
 	// if we got here, there is at least one pod to score
-	weightedScorePerPod := s.runScorerPlugins(sCtx, pods)
+	weightedScorePerPod := s.runScorerPlugins(sCtx, pods, shadowCycle)
 
 	result := s.runPickerPlugin(sCtx, weightedScorePerPod)
 
@@ -174,7 +214,7 @@ func (s *Scheduler) runFilterPlugins(ctx *types.SchedulingContext) []types.Pod {
 	return filteredPods
 }
 
-func (s *Scheduler) runScorerPlugins(ctx *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
+func (s *Scheduler) runScorerPlugins(ctx *types.SchedulingContext, pods []types.Pod, shadowCycle *shadowCycle) map[types.Pod]float64 {
 	loggerDebug := ctx.Logger.V(logutil.DEBUG)
 	loggerDebug.Info("Before running scorer plugins", "pods", pods)
 
@@ -188,12 +228,18 @@ func (s *Scheduler) runScorerPlugins(ctx *types.SchedulingContext, pods []types.
 		before := time.Now()
 		scores := scorer.Score(ctx, pods)
 		metrics.RecordSchedulerPluginProcessingLatency(plugins.ScorerPluginType, scorer.Name(), time.Since(before))
+		if shadowCycle != nil {
+			shadowCycle.scores[scorer] = scores
+		}
 		for pod, score := range scores { // weight is relative to the sum of weights
 			weightedScorePerPod[pod] += score * float64(weight) // TODO normalize score before multiply with weight
 		}
 		loggerDebug.Info("After running scorer", "scorer", scorer.Name())
 	}
 	loggerDebug.Info("After running scorer plugins")
+	if shadowCycle != nil {
+		shadowCycle.pods = pods
+	}
 
 	return weightedScorePerPod
 }
@@ -254,11 +300,52 @@ func (s *Scheduler) RunPostResponsePlugins(ctx context.Context, req *types.LLMRe
 		metrics.RecordSchedulerPluginProcessingLatency(plugins.PostResponsePluginType, plugin.Name(), time.Since(before))
 	}
 
-	return &types.Result{TargetPod: nil, MutatedHeaders: sCtx.MutatedHeaders}, nil
+	return &types.Result{TargetPod: nil, MutatedHeaders: sCtx.MutatedHeaders, NeedsCompletion: len(s.postCompletionPlugins) > 0}, nil
+}
+
+func (s *Scheduler) RunPostCompletionPlugins(ctx context.Context, req *types.LLMRequest, targetPodName string, latency *types.ResponseLatency) error {
+	return runPostCompletionPlugins(ctx, s.datastore, s.postCompletionPlugins, req, targetPodName, latency)
+}
+
+func runPostCompletionPlugins(ctx context.Context, datastore Datastore, postCompletionPlugins []plugins.PostCompletion,
+	req *types.LLMRequest, targetPodName string, latency *types.ResponseLatency) error {
+	if len(postCompletionPlugins) == 0 {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+
+	pool, err := datastore.PoolGet()
+	if err != nil {
+		return errutil.Error{Code: errutil.Internal, Msg: "failed to find a target pod"} // pool not defined, no pods
+	}
+
+	pods := types.ToSchedulerPodMetrics(datastore.PodGetAll())
+	var targetPod types.Pod
+	for _, pod := range pods {
+		if pod.GetPod().NamespacedName.String() == targetPodName {
+			targetPod = pod
+			break
+		}
+	}
+
+	sCtx := types.NewSchedulingContext(ctx, req, pods, pool.Spec.TargetPortNumber)
+
+	for _, plugin := range postCompletionPlugins {
+		logger.V(logutil.DEBUG).Info("Running post-completion plugin", "plugin", plugin.Name())
+		before := time.Now()
+		plugin.PostCompletion(sCtx, targetPod, latency)
+		metrics.RecordSchedulerPluginProcessingLatency(plugins.PostCompletionPluginType, plugin.Name(), time.Since(before))
+	}
+
+	return nil
 }
 
 type defaultPlugin struct {
 	picker.RandomPicker
+	// criticalFilter and sheddableFilter override lowLatencyFilter and sheddableRequestFilter
+	// when set.
+	criticalFilter  plugins.Filter
+	sheddableFilter plugins.Filter
 }
 
 func (p *defaultPlugin) Name() string {
@@ -267,8 +354,14 @@ func (p *defaultPlugin) Name() string {
 
 func (p *defaultPlugin) Filter(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
 	if ctx.Req.Critical {
+		if p.criticalFilter != nil {
+			return p.criticalFilter.Filter(ctx, pods)
+		}
 		return lowLatencyFilter.Filter(ctx, pods)
 	}
 
+	if p.sheddableFilter != nil {
+		return p.sheddableFilter.Filter(ctx, pods)
+	}
 	return sheddableRequestFilter.Filter(ctx, pods)
 }
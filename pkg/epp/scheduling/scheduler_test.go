@@ -491,11 +491,12 @@ func TestPostResponse(t *testing.T) {
 	}
 
 	tests := []struct {
-		name               string
-		config             SchedulerConfig
-		input              []*backendmetrics.FakePodMetrics
-		responseHeaders    map[string]string
-		wantMutatedHeaders map[string]string
+		name                string
+		config              SchedulerConfig
+		input               []*backendmetrics.FakePodMetrics
+		responseHeaders     map[string]string
+		wantMutatedHeaders  map[string]string
+		wantNeedsCompletion bool
 	}{
 		{
 			name: "Simple postResponse test",
@@ -508,6 +509,19 @@ func TestPostResponse(t *testing.T) {
 			responseHeaders:    map[string]string{"Content-type": "application/json", "Content-Length": "1234"},
 			wantMutatedHeaders: map[string]string{"x-session-id": "qwer-asdf-zxcv"},
 		},
+		{
+			name: "post-completion plugins need the completion",
+			config: SchedulerConfig{
+				postResponsePlugins:   []plugins.PostResponse{pr1},
+				postCompletionPlugins: []plugins.PostCompletion{&testPostCompletion{}},
+			},
+			input: []*backendmetrics.FakePodMetrics{
+				{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}},
+			},
+			responseHeaders:     map[string]string{"Content-type": "application/json", "Content-Length": "1234"},
+			wantMutatedHeaders:  map[string]string{"x-session-id": "qwer-asdf-zxcv"},
+			wantNeedsCompletion: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -530,6 +544,9 @@ func TestPostResponse(t *testing.T) {
 		if diff := cmp.Diff(test.wantMutatedHeaders, result.MutatedHeaders); diff != "" {
 			t.Errorf("Unexpected output (-wantedMutatedHeaders +MutatedHeaders): %v", diff)
 		}
+		if result.NeedsCompletion != test.wantNeedsCompletion {
+			t.Errorf("Unexpected NeedsCompletion, want %t, got %t", test.wantNeedsCompletion, result.NeedsCompletion)
+		}
 	}
 }
 
@@ -638,6 +655,13 @@ func (pr *testPostResponse) PostResponse(ctx *types.SchedulingContext, pod types
 	}
 }
 
+type testPostCompletion struct{}
+
+func (pc *testPostCompletion) Name() string { return "test-post-completion" }
+
+func (pc *testPostCompletion) PostCompletion(_ *types.SchedulingContext, _ types.Pod, _ *types.ResponseLatency) {
+}
+
 func findPods(ctx *types.SchedulingContext, names ...k8stypes.NamespacedName) []types.Pod {
 	res := []types.Pod{}
 	for _, pod := range ctx.PodsSnapshot {
@@ -0,0 +1,266 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	envutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/env"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+	"sigs.k8s.io/yaml"
+)
+
+const shadowSchedulerConfigFileEnvVar = "SHADOW_SCHEDULER_CONFIG_FILE"
+
+// noPod labels the metrics of scheduling cycles where no pod was picked.
+const noPod = "none"
+
+// shadowSchedulerConfig declares a candidate configuration evaluated on live traffic, by overriding
+// the weights of the scorers of the schedulers, e.g.:
+//
+//	sampleRate: 0.1
+//	scorers:
+//	- name: prefix-aware-scorer
+//	  weight: 3
+//	- name: load-aware-scorer
+//	  weight: 0
+//	  scheduler: decode
+type shadowSchedulerConfig struct {
+	// SampleRate is the fraction of the scheduling cycles evaluated by the shadow schedulers, in (0, 1].
+	SampleRate float64            `json:"sampleRate"`
+	Scorers    []scorerWeightSpec `json:"scorers"`
+}
+
+// scorerWeightSpec overrides the weight of a scorer of a scheduler.
+type scorerWeightSpec struct {
+	// Name is the name of a scorer of the scheduler.
+	Name string `json:"name"`
+	// Weight overrides the weight of the scorer, zero to disable it.
+	Weight int `json:"weight"`
+	// Scheduler is the scheduler of the scorer, one of "default", "prefill", "decode" and "encode".
+	// Defaults to "default".
+	Scheduler string `json:"scheduler,omitempty"`
+}
+
+var (
+	// shadowWeights maps the configurations of the schedulers with a shadow to the scorer weights of
+	// their shadows. The configurations of the experiment arms are copies of these configurations, so
+	// the schedulers of the arms have no shadow.
+	shadowWeights    = map[*SchedulerConfig]map[plugins.Scorer]int{}
+	shadowSampleRate float64
+)
+
+// setShadowSchedulers sets the shadows of the schedulers, derived from their complete configurations.
+func setShadowSchedulers() {
+	ctx := context.Background()
+	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
+
+	path := envutil.GetEnvString(shadowSchedulerConfigFileEnvVar, "", loggerDebug)
+	if path == "" {
+		loggerDebug.Info("Skipping shadow scheduler configuration as no file is set")
+		return
+	}
+	weights, sampleRate, err := loadShadowConfigs(path, schedulerConfigsByName())
+	if err != nil {
+		loggerDebug.Error(err, "Failed to load shadow scheduler configuration", "path", path)
+		configErrors = append(configErrors, fmt.Errorf("invalid shadow scheduler configuration %s - %w", path, err))
+		return
+	}
+	shadowWeights, shadowSampleRate = weights, sampleRate
+	loggerDebug.Info("Initialized shadow schedulers", "path", path, "schedulers", len(weights), "sampleRate", sampleRate)
+}
+
+// loadShadowConfigs returns the scorer weights of the shadows declared in the given file, and the
+// sample rate.
+func loadShadowConfigs(path string, schedulers map[string]*SchedulerConfig) (map[*SchedulerConfig]map[plugins.Scorer]int, float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	return parseShadowConfigs(data, schedulers)
+}
+
+// parseShadowConfigs returns the scorer weights of the shadows of the given schedulers, for the
+// schedulers whose scorer weights are overridden, and the sample rate.
+func parseShadowConfigs(data []byte, schedulers map[string]*SchedulerConfig) (map[*SchedulerConfig]map[plugins.Scorer]int, float64, error) {
+	cfg := &shadowSchedulerConfig{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse shadow scheduler configuration - %w", err)
+	}
+	if cfg.SampleRate <= 0 || cfg.SampleRate > 1 {
+		return nil, 0, fmt.Errorf("sample rate %v is not in (0, 1]", cfg.SampleRate)
+	}
+	if len(cfg.Scorers) == 0 {
+		return nil, 0, errors.New("no scorer weight is overridden")
+	}
+
+	weights, err := parseScorerWeights(cfg.Scorers, schedulers)
+	if err != nil {
+		return nil, 0, err
+	}
+	shadows := make(map[*SchedulerConfig]map[plugins.Scorer]int, len(weights))
+	for scheduler, schedulerWeights := range weights {
+		shadows[scheduler] = withScorerWeights(scheduler, schedulerWeights).scorers
+	}
+	return shadows, cfg.SampleRate, nil
+}
+
+// parseScorerWeights returns the overridden scorer weights by name of the given schedulers.
+func parseScorerWeights(specs []scorerWeightSpec, schedulers map[string]*SchedulerConfig) (map[*SchedulerConfig]map[string]int, error) {
+	weights := map[*SchedulerConfig]map[string]int{}
+	for _, spec := range specs {
+		scheduler, ok := schedulers[spec.Scheduler]
+		if !ok {
+			return nil, fmt.Errorf("scorer %s: unknown scheduler %q", spec.Name, spec.Scheduler)
+		}
+		if spec.Weight < 0 {
+			return nil, fmt.Errorf("scorer %s: negative weight %d", spec.Name, spec.Weight)
+		}
+		found := false
+		for scorer := range scheduler.scorers {
+			found = found || scorer.Name() == spec.Name
+		}
+		if !found {
+			return nil, fmt.Errorf("scorer %s: no such scorer in scheduler %q", spec.Name, spec.Scheduler)
+		}
+		if weights[scheduler] == nil {
+			weights[scheduler] = map[string]int{}
+		}
+		weights[scheduler][spec.Name] = spec.Weight
+	}
+	return weights, nil
+}
+
+// withScorerWeights returns a copy of the given configuration sharing its plugins, with the given
+// scorer weights. Scorers of zero weight are removed.
+func withScorerWeights(config *SchedulerConfig, weights map[string]int) *SchedulerConfig {
+	scorers := map[plugins.Scorer]int{}
+	for scorer, weight := range config.scorers {
+		if override, ok := weights[scorer.Name()]; ok {
+			weight = override
+		}
+		if weight > 0 {
+			scorers[scorer] = weight
+		}
+	}
+	return &SchedulerConfig{
+		preSchedulePlugins:    config.preSchedulePlugins,
+		filters:               config.filters,
+		scorers:               scorers,
+		picker:                config.picker,
+		postSchedulePlugins:   config.postSchedulePlugins,
+		postResponsePlugins:   config.postResponsePlugins,
+		postCompletionPlugins: config.postCompletionPlugins,
+	}
+}
+
+// shadowScheduler evaluates candidate scorer weights on a sample of the scheduling cycles of a
+// scheduler, and exports whether they agree with the pod picked by the scheduler. Rather than
+// scheduling again, the shadow weighs the scores computed by the scorers of the scheduler in the
+// same cycle, on the same filtered pods, so that the plugins are called once and their state and
+// metrics are those of the scheduler. In PD mode, the decode shadow is thus evaluated on the prefill
+// pod picked by the scheduler.
+type shadowScheduler struct {
+	// weights are the scorer weights of the shadow, scorers of zero weight excluded.
+	weights    map[plugins.Scorer]int
+	sampleRate float64
+}
+
+// shadowCycle holds the scores of a scheduling cycle evaluated by the shadow.
+type shadowCycle struct {
+	// pods are the pods left by the filters, none if the scheduler failed before scoring.
+	pods   []types.Pod
+	scores map[plugins.Scorer]map[types.Pod]float64
+}
+
+// newCycle returns the cycle the scheduler records its scores into, or nil if the scheduling cycle
+// is not sampled.
+func (s *shadowScheduler) newCycle() *shadowCycle {
+	if s == nil || rand.Float64() >= s.sampleRate {
+		return nil
+	}
+	return &shadowCycle{scores: map[plugins.Scorer]map[types.Pod]float64{}}
+}
+
+// record records the pods picked by the scheduler and its shadow in the given cycle.
+func (s *shadowScheduler) record(cycle *shadowCycle, primary *types.Result) {
+	primaryPod := resultPod(primary)
+	shadowPod := s.pick(cycle, primaryPod)
+
+	primaryName, primaryRole := podLabels(primaryPod)
+	shadowName, shadowRole := podLabels(shadowPod)
+	metrics.RecordShadowPodDecision("primary", primaryName, primaryRole)
+	metrics.RecordShadowPodDecision("shadow", shadowName, shadowRole)
+	metrics.RecordShadowDecision(primaryRole, primaryName == shadowName)
+}
+
+// pick returns the pod of highest score with the weights of the shadow, nil if none. Ties are not
+// disagreements: the pod picked by the scheduler is kept if it is among the best pods of the
+// shadow, otherwise the first of them by name is picked.
+func (s *shadowScheduler) pick(cycle *shadowCycle, primaryPod types.Pod) types.Pod {
+	var best []types.Pod
+	bestScore := math.Inf(-1)
+	for _, pod := range cycle.pods {
+		score := float64(0)
+		for scorer, weight := range s.weights {
+			score += cycle.scores[scorer][pod] * float64(weight)
+		}
+		switch {
+		case score > bestScore:
+			best, bestScore = []types.Pod{pod}, score
+		case score == bestScore:
+			best = append(best, pod)
+		}
+	}
+	if len(best) == 0 {
+		return nil
+	}
+	primaryName, _ := podLabels(primaryPod)
+	for _, pod := range best {
+		if name, _ := podLabels(pod); name == primaryName {
+			return pod
+		}
+	}
+	return slices.MinFunc(best, func(a, b types.Pod) int {
+		return strings.Compare(a.GetPod().NamespacedName.String(), b.GetPod().NamespacedName.String())
+	})
+}
+
+func resultPod(res *types.Result) types.Pod {
+	if res == nil || res.TargetPod == nil || res.TargetPod.GetPod() == nil {
+		return nil
+	}
+	return res.TargetPod
+}
+
+func podLabels(pod types.Pod) (string, string) {
+	if pod == nil {
+		return noPod, noPod
+	}
+	return pod.GetPod().NamespacedName.String(), pod.GetPod().Role.String()
+}
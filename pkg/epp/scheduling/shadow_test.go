@@ -0,0 +1,307 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/picker"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// podScorer gives the maximum score to a single pod.
+type podScorer struct {
+	name string
+	pod  string
+}
+
+func (s *podScorer) Name() string { return s.name }
+
+func (s *podScorer) Score(_ *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
+	scores := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		if pod.GetPod().NamespacedName.Name == s.pod {
+			scores[pod] = 1
+		} else {
+			scores[pod] = 0
+		}
+	}
+	return scores
+}
+
+// countingPostSchedule counts the post-schedule calls.
+type countingPostSchedule struct {
+	calls atomic.Int32
+}
+
+func (p *countingPostSchedule) Name() string { return "counting-post-schedule" }
+
+func (p *countingPostSchedule) PostSchedule(_ *types.SchedulingContext, _ *types.Result) {
+	p.calls.Add(1)
+}
+
+func TestParseShadowConfigs(t *testing.T) {
+	pod1Scorer := &podScorer{name: "pod1-scorer", pod: "pod1"}
+	pod2Scorer := &podScorer{name: "pod2-scorer", pod: "pod2"}
+	primary := &SchedulerConfig{scorers: map[plugins.Scorer]int{pod1Scorer: 2, pod2Scorer: 1}}
+	schedulers := map[string]*SchedulerConfig{"": primary, "default": primary, "decode": {scorers: map[plugins.Scorer]int{}}}
+
+	tests := []struct {
+		name           string
+		config         string
+		wantErr        bool
+		wantSampleRate float64
+		wantWeights    map[string]int
+	}{
+		{
+			name: "weights overridden",
+			config: `
+sampleRate: 0.5
+scorers:
+- name: pod1-scorer
+  weight: 0
+- name: pod2-scorer
+  weight: 3
+  scheduler: default
+`,
+			wantSampleRate: 0.5,
+			wantWeights:    map[string]int{"pod2-scorer": 3},
+		},
+		{
+			name: "weights partially overridden",
+			config: `
+sampleRate: 1
+scorers:
+- name: pod2-scorer
+  weight: 4
+`,
+			wantSampleRate: 1,
+			wantWeights:    map[string]int{"pod1-scorer": 2, "pod2-scorer": 4},
+		},
+		{
+			name:    "invalid sample rate",
+			config:  "sampleRate: 2\nscorers:\n- name: pod1-scorer\n  weight: 1\n",
+			wantErr: true,
+		},
+		{
+			name:    "no scorer",
+			config:  "sampleRate: 0.5\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown scheduler",
+			config:  "sampleRate: 0.5\nscorers:\n- name: pod1-scorer\n  weight: 1\n  scheduler: prefill\n",
+			wantErr: true,
+		},
+		{
+			name:    "scorer not in scheduler",
+			config:  "sampleRate: 0.5\nscorers:\n- name: pod1-scorer\n  weight: 1\n  scheduler: decode\n",
+			wantErr: true,
+		},
+		{
+			name:    "negative weight",
+			config:  "sampleRate: 0.5\nscorers:\n- name: pod1-scorer\n  weight: -1\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			config:  "sampleRate: 0.5\nweights: {}\n",
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shadows, sampleRate, err := parseShadowConfigs([]byte(test.config), schedulers)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Unexpected error, want %v, got %v", test.wantErr, err)
+			}
+			if test.wantErr {
+				return
+			}
+			if sampleRate != test.wantSampleRate {
+				t.Errorf("Unexpected sample rate, want %v, got %v", test.wantSampleRate, sampleRate)
+			}
+			if len(shadows) != 1 || shadows[primary] == nil {
+				t.Fatalf("Unexpected shadow weights, want a shadow of the default scheduler only, got %v", shadows)
+			}
+			gotWeights := map[string]int{}
+			for scorer, weight := range shadows[primary] {
+				gotWeights[scorer.Name()] = weight
+			}
+			if diff := cmp.Diff(test.wantWeights, gotWeights); diff != "" {
+				t.Errorf("Unexpected shadow weights (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestSetShadowSchedulers(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		noFile  bool
+		wantErr bool
+	}{
+		{
+			name:   "valid configuration",
+			config: "sampleRate: 0.5\nscorers:\n- name: pod1-scorer\n  weight: 0\n",
+		},
+		{
+			name:    "invalid configuration",
+			config:  "sampleRate: 0.5\nscorers:\n- name: no-such-scorer\n  weight: 1\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			noFile:  true,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			savedConfig, savedErrors, savedWeights := defaultConfig, configErrors, shadowWeights
+			defer func() { defaultConfig, configErrors, shadowWeights = savedConfig, savedErrors, savedWeights }()
+			defaultConfig = &SchedulerConfig{scorers: map[plugins.Scorer]int{&podScorer{name: "pod1-scorer", pod: "pod1"}: 1}}
+			configErrors, shadowWeights = nil, map[*SchedulerConfig]map[plugins.Scorer]int{}
+
+			path := filepath.Join(t.TempDir(), "shadow-scheduler.yaml")
+			if !test.noFile {
+				if err := os.WriteFile(path, []byte(test.config), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv(shadowSchedulerConfigFileEnvVar, path)
+			setShadowSchedulers()
+
+			// An invalid file fails the startup and sets no shadow.
+			err := ConfigError()
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Unexpected configuration error, want %t, got %v", test.wantErr, err)
+			}
+			if test.wantErr && !strings.Contains(err.Error(), "invalid shadow scheduler configuration "+path) {
+				t.Errorf("Unexpected configuration error %v", err)
+			}
+			if _, ok := shadowWeights[defaultConfig]; ok == test.wantErr {
+				t.Errorf("Unexpected shadow schedulers, want a shadow %t, got %v", !test.wantErr, shadowWeights)
+			}
+		})
+	}
+}
+
+// countingScorer scores all the pods equally and counts its calls.
+type countingScorer struct {
+	calls atomic.Int32
+}
+
+func (s *countingScorer) Name() string { return "counting-scorer" }
+
+func (s *countingScorer) Score(_ *types.SchedulingContext, pods []types.Pod) map[types.Pod]float64 {
+	s.calls.Add(1)
+	scores := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		scores[pod] = 1
+	}
+	return scores
+}
+
+func TestShadowScheduler(t *testing.T) {
+	postSchedule := &countingPostSchedule{}
+	counting := &countingScorer{}
+	primaryConfig := &SchedulerConfig{
+		filters: []plugins.Filter{},
+		scorers: map[plugins.Scorer]int{
+			&podScorer{name: "pod1-scorer", pod: "pod1"}: 2,
+			&podScorer{name: "pod2-scorer", pod: "pod2"}: 1,
+			counting: 1,
+		},
+		picker:              picker.NewMaxScorePicker(),
+		postSchedulePlugins: []plugins.PostSchedule{postSchedule},
+	}
+	weights, sampleRate, err := parseShadowConfigs([]byte("sampleRate: 1\nscorers:\n- name: pod1-scorer\n  weight: 0\n"),
+		map[string]*SchedulerConfig{"": primaryConfig})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	previousWeights, previousSampleRate := shadowWeights, shadowSampleRate
+	shadowWeights, shadowSampleRate = weights, sampleRate
+	defer func() { shadowWeights, shadowSampleRate = previousWeights, previousSampleRate }()
+
+	datastore := &fakeDataStore{pods: []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}}, Metrics: &backendmetrics.Metrics{}},
+	}}
+	scheduler := NewSchedulerWithConfig(datastore, primaryConfig)
+	if scheduler.shadow == nil {
+		t.Fatal("Expected the scheduler to have a shadow")
+	}
+	req := &types.LLMRequest{Model: "my-model"}
+
+	// The shadow never changes the decision of the scheduler, and never runs the plugins.
+	res, err := scheduler.Schedule(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := res.TargetPod.GetPod().NamespacedName.Name; got != "pod1" {
+		t.Errorf("Unexpected target pod, want pod1, got %s", got)
+	}
+	if got := postSchedule.calls.Load(); got != 1 {
+		t.Errorf("Unexpected post-schedule calls, want 1, got %d", got)
+	}
+	if got := counting.calls.Load(); got != 1 {
+		t.Errorf("Unexpected scorer calls, want 1, got %d", got)
+	}
+
+	// The shadow weighs the scores of the cycle.
+	sCtx, err := createSchedulerContext(context.Background(), req, datastore)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cycle := scheduler.shadow.newCycle()
+	res, err = scheduler.schedule(sCtx, sCtx.Logger, cycle)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	shadowPod := scheduler.shadow.pick(cycle, res.TargetPod)
+	if shadowPod == nil || shadowPod.GetPod().NamespacedName.Name != "pod2" {
+		t.Errorf("Unexpected shadow pod, want pod2, got %v", shadowPod)
+	}
+
+	// Ties are not disagreements, and are otherwise broken by name.
+	tied := &shadowScheduler{weights: map[plugins.Scorer]int{counting: 1}, sampleRate: 1}
+	pod3 := sCtx.PodsSnapshot[2]
+	if got := tied.pick(cycle, pod3); got != pod3 {
+		t.Errorf("Unexpected shadow pod, want the pod of the scheduler pod3, got %v", got)
+	}
+	if got := tied.pick(cycle, nil); got == nil || got.GetPod().NamespacedName.Name != "pod1" {
+		t.Errorf("Unexpected shadow pod, want the first pod by name pod1, got %v", got)
+	}
+
+	// No pod is picked when the scheduler failed before scoring.
+	if got := scheduler.shadow.pick(scheduler.shadow.newCycle(), nil); got != nil {
+		t.Errorf("Unexpected shadow pod, want none, got %v", got)
+	}
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	envutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/env"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+	"sigs.k8s.io/yaml"
+)
+
+const experimentConfigFileEnvVar = "EXPERIMENT_CONFIG_FILE"
+
+const (
+	randomAssignment = "random"
+	headerAssignment = "header"
+	userAssignment   = "user"
+)
+
+// experimentConfig declares an experiment splitting the requests between scheduler profiles, the
+// arms, which override the weights of the scorers of the schedulers, e.g.:
+//
+//	assignment: header
+//	header: x-tenant
+//	arms:
+//	- name: prefix-aware
+//	  percentage: 50
+//	- name: load-only
+//	  percentage: 50
+//	  scorers:
+//	  - name: prefix-aware-scorer
+//	    weight: 0
+type experimentConfig struct {
+	// Assignment is how requests are assigned to the arms: "random" (the default), or by the hash of
+	// a request header ("header") or of the user of the request ("user"), so that all the requests of
+	// a tenant or user are assigned to the same arm. Requests without the header or user are assigned
+	// randomly.
+	Assignment string `json:"assignment,omitempty"`
+	// Header is the request header hashed by the header assignment.
+	Header string              `json:"header,omitempty"`
+	Arms   []experimentArmSpec `json:"arms"`
+}
+
+type experimentArmSpec struct {
+	Name string `json:"name"`
+	// Percentage is the percentage of the requests assigned to the arm. The percentages of the arms
+	// add up to 100.
+	Percentage int `json:"percentage"`
+	// Scorers override the scorer weights of the schedulers for the requests of the arm.
+	Scorers []scorerWeightSpec `json:"scorers,omitempty"`
+}
+
+// experiment is a parsed experiment configuration.
+type experiment struct {
+	assignment string
+	header     string
+	arms       []*experimentArmProfile
+}
+
+type experimentArmProfile struct {
+	name       string
+	percentage int
+	// configs maps the configurations of the schedulers whose weights the arm overrides to the
+	// configurations used for the arm.
+	configs map[*SchedulerConfig]*SchedulerConfig
+}
+
+// ExperimentEnabled is true if an experiment is configured, in which case the requests are
+// scheduled by the ExperimentScheduler.
+var ExperimentEnabled = false
+
+var configuredExperiment *experiment
+
+// setExperiment sets the configured experiment, whose arms override the weights of the complete
+// scheduler configurations.
+func setExperiment() {
+	ctx := context.Background()
+	loggerDebug := log.FromContext(ctx).WithName("scheduler_config").V(logutil.DEBUG)
+
+	path := envutil.GetEnvString(experimentConfigFileEnvVar, "", loggerDebug)
+	if path == "" {
+		loggerDebug.Info("Skipping experiment configuration as no file is set")
+		return
+	}
+	exp, err := loadExperiment(path, schedulerConfigsByName())
+	if err != nil {
+		loggerDebug.Error(err, "Failed to load experiment configuration", "path", path)
+		configErrors = append(configErrors, fmt.Errorf("invalid experiment configuration %s - %w", path, err))
+		return
+	}
+	configuredExperiment, ExperimentEnabled = exp, true
+	loggerDebug.Info("Initialized experiment", "path", path, "assignment", exp.assignment, "arms", len(exp.arms))
+}
+
+// loadExperiment returns the experiment declared in the given file.
+func loadExperiment(path string, schedulers map[string]*SchedulerConfig) (*experiment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseExperiment(data, schedulers)
+}
+
+func parseExperiment(data []byte, schedulers map[string]*SchedulerConfig) (*experiment, error) {
+	cfg := &experimentConfig{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse experiment configuration - %w", err)
+	}
+	exp := &experiment{assignment: cfg.Assignment, header: strings.ToLower(cfg.Header)}
+	switch exp.assignment {
+	case "":
+		exp.assignment = randomAssignment
+	case randomAssignment, userAssignment:
+	case headerAssignment:
+		if exp.header == "" {
+			return nil, errors.New("the header assignment requires a header")
+		}
+	default:
+		return nil, fmt.Errorf("unknown assignment %q", cfg.Assignment)
+	}
+	if len(cfg.Arms) < 2 {
+		return nil, errors.New("an experiment requires at least two arms")
+	}
+
+	total := 0
+	names := map[string]bool{}
+	for _, spec := range cfg.Arms {
+		if spec.Name == "" || names[spec.Name] {
+			return nil, fmt.Errorf("arm %q: arms require a unique name", spec.Name)
+		}
+		names[spec.Name] = true
+		if spec.Percentage < 0 {
+			return nil, fmt.Errorf("arm %s: negative percentage %d", spec.Name, spec.Percentage)
+		}
+		total += spec.Percentage
+		weights, err := parseScorerWeights(spec.Scorers, schedulers)
+		if err != nil {
+			return nil, fmt.Errorf("arm %s: %w", spec.Name, err)
+		}
+		arm := &experimentArmProfile{name: spec.Name, percentage: spec.Percentage, configs: map[*SchedulerConfig]*SchedulerConfig{}}
+		for config, configWeights := range weights {
+			arm.configs[config] = withScorerWeights(config, configWeights)
+		}
+		exp.arms = append(exp.arms, arm)
+	}
+	if total != 100 {
+		return nil, fmt.Errorf("the percentages of the arms add up to %d instead of 100", total)
+	}
+	return exp, nil
+}
+
+// config returns the configuration the arm uses in place of the given one.
+func (a *experimentArmProfile) config(config *SchedulerConfig) *SchedulerConfig {
+	if armConfig, ok := a.configs[config]; ok {
+		return armConfig
+	}
+	return config
+}
+
+// armScheduler schedules the requests of an arm, e.g., Scheduler or PDScheduler.
+type armScheduler interface {
+	Schedule(ctx context.Context, req *types.LLMRequest) (*types.Result, error)
+	RunPostResponsePlugins(ctx context.Context, req *types.LLMRequest, targetPodName string) (*types.Result, error)
+	RunPostCompletionPlugins(ctx context.Context, req *types.LLMRequest, targetPodName string, latency *types.ResponseLatency) error
+	Plugins() []plugins.Plugin
+}
+
+type experimentArm struct {
+	name       string
+	percentage int
+	scheduler  armScheduler
+}
+
+// ExperimentScheduler splits the requests between the arms of an experiment, each scheduled by its
+// own profile, and tags the requests with their arm.
+type ExperimentScheduler struct {
+	assignment string
+	header     string
+	arms       []*experimentArm
+}
+
+// NewExperimentScheduler creates the scheduler of the configured experiment, scheduling the arms
+// with prefill/decode disaggregation if PDEnabled.
+func NewExperimentScheduler(datastore Datastore) *ExperimentScheduler {
+	return newExperimentScheduler(datastore, configuredExperiment, PDEnabled)
+}
+
+func newExperimentScheduler(datastore Datastore, exp *experiment, pd bool) *ExperimentScheduler {
+	s := &ExperimentScheduler{assignment: exp.assignment, header: exp.header}
+	for _, profile := range exp.arms {
+		arm := &experimentArm{name: profile.name, percentage: profile.percentage}
+		if pd {
+			arm.scheduler = NewPDSchedulerWithConfig(datastore, pdStages(profile.config), profile.config(decodeConfig),
+				profile.config(defaultConfig), pdDecider)
+		} else {
+			arm.scheduler = NewSchedulerWithConfig(datastore, profile.config(defaultConfig))
+		}
+		s.arms = append(s.arms, arm)
+	}
+	return s
+}
+
+// Schedule assigns the request to an arm, recorded in its ExperimentArm, and schedules it with the
+// profile of the arm.
+func (s *ExperimentScheduler) Schedule(ctx context.Context, req *types.LLMRequest) (*types.Result, error) {
+	arm := s.assign(req)
+	req.ExperimentArm = arm.name
+	metrics.RecordExperimentRequest(req.Model, arm.name)
+	return arm.scheduler.Schedule(ctx, req)
+}
+
+// assign picks the arm of the request, by the hash of its header or user if configured, or else
+// randomly.
+func (s *ExperimentScheduler) assign(req *types.LLMRequest) *experimentArm {
+	key := ""
+	switch s.assignment {
+	case headerAssignment:
+		key = req.Headers[s.header]
+	case userAssignment:
+		key = req.User
+	}
+	var bucket int
+	if key == "" {
+		bucket = rand.Intn(100)
+	} else {
+		bucket = int(xxhash.Sum64String(key) % 100)
+	}
+	for _, arm := range s.arms {
+		if bucket < arm.percentage {
+			return arm
+		}
+		bucket -= arm.percentage
+	}
+	return s.arms[len(s.arms)-1]
+}
+
+// arm returns the arm the request was assigned to, or the first arm if unknown.
+func (s *ExperimentScheduler) arm(req *types.LLMRequest) *experimentArm {
+	for _, arm := range s.arms {
+		if arm.name == req.ExperimentArm {
+			return arm
+		}
+	}
+	return s.arms[0]
+}
+
+func (s *ExperimentScheduler) RunPostResponsePlugins(ctx context.Context, req *types.LLMRequest, targetPodName string) (*types.Result, error) {
+	return s.arm(req).scheduler.RunPostResponsePlugins(ctx, req, targetPodName)
+}
+
+func (s *ExperimentScheduler) RunPostCompletionPlugins(ctx context.Context, req *types.LLMRequest, targetPodName string, latency *types.ResponseLatency) error {
+	return s.arm(req).scheduler.RunPostCompletionPlugins(ctx, req, targetPodName, latency)
+}
+
+// Plugins returns the plugins of the schedulers of all the arms.
+func (s *ExperimentScheduler) Plugins() []plugins.Plugin {
+	ps := []plugins.Plugin{}
+	for _, arm := range s.arms {
+		ps = append(ps, arm.scheduler.Plugins()...)
+	}
+	return uniquePlugins(ps)
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/picker"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestParseExperiment(t *testing.T) {
+	pod1Scorer := &podScorer{name: "pod1-scorer", pod: "pod1"}
+	pod2Scorer := &podScorer{name: "pod2-scorer", pod: "pod2"}
+	primary := &SchedulerConfig{scorers: map[plugins.Scorer]int{pod1Scorer: 2, pod2Scorer: 1}}
+	schedulers := map[string]*SchedulerConfig{"": primary, "default": primary}
+
+	tests := []struct {
+		name           string
+		config         string
+		wantErr        bool
+		wantAssignment string
+		wantHeader     string
+		// wantWeights are the scorer weights of the default scheduler by arm.
+		wantWeights map[string]map[string]int
+	}{
+		{
+			name: "random assignment",
+			config: `
+arms:
+- name: pod1
+  percentage: 30
+- name: pod2
+  percentage: 70
+  scorers:
+  - name: pod1-scorer
+    weight: 0
+`,
+			wantAssignment: randomAssignment,
+			wantWeights: map[string]map[string]int{
+				"pod1": {"pod1-scorer": 2, "pod2-scorer": 1},
+				"pod2": {"pod2-scorer": 1},
+			},
+		},
+		{
+			name: "header assignment",
+			config: `
+assignment: header
+header: X-Tenant
+arms:
+- name: a
+  percentage: 50
+- name: b
+  percentage: 50
+`,
+			wantAssignment: headerAssignment,
+			wantHeader:     "x-tenant",
+			wantWeights: map[string]map[string]int{
+				"a": {"pod1-scorer": 2, "pod2-scorer": 1},
+				"b": {"pod1-scorer": 2, "pod2-scorer": 1},
+			},
+		},
+		{
+			name:    "header assignment without header",
+			config:  "assignment: header\narms:\n- name: a\n  percentage: 50\n- name: b\n  percentage: 50\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown assignment",
+			config:  "assignment: cookie\narms:\n- name: a\n  percentage: 50\n- name: b\n  percentage: 50\n",
+			wantErr: true,
+		},
+		{
+			name:    "single arm",
+			config:  "arms:\n- name: a\n  percentage: 100\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate arm",
+			config:  "arms:\n- name: a\n  percentage: 50\n- name: a\n  percentage: 50\n",
+			wantErr: true,
+		},
+		{
+			name:    "percentages not adding up to 100",
+			config:  "arms:\n- name: a\n  percentage: 50\n- name: b\n  percentage: 40\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown scorer",
+			config:  "arms:\n- name: a\n  percentage: 50\n- name: b\n  percentage: 50\n  scorers:\n  - name: queue-scorer\n    weight: 1\n",
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exp, err := parseExperiment([]byte(test.config), schedulers)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Unexpected error, want %v, got %v", test.wantErr, err)
+			}
+			if test.wantErr {
+				return
+			}
+			if exp.assignment != test.wantAssignment || exp.header != test.wantHeader {
+				t.Errorf("Unexpected assignment, want %q on header %q, got %q on header %q",
+					test.wantAssignment, test.wantHeader, exp.assignment, exp.header)
+			}
+			gotWeights := map[string]map[string]int{}
+			for _, arm := range exp.arms {
+				gotWeights[arm.name] = map[string]int{}
+				for scorer, weight := range arm.config(primary).scorers {
+					gotWeights[arm.name][scorer.Name()] = weight
+				}
+			}
+			if diff := cmp.Diff(test.wantWeights, gotWeights); diff != "" {
+				t.Errorf("Unexpected arm weights (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestSetExperiment(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		noFile  bool
+		wantErr bool
+	}{
+		{
+			name:   "valid configuration",
+			config: "arms:\n- name: a\n  percentage: 50\n- name: b\n  percentage: 50\n",
+		},
+		{
+			name:    "invalid configuration",
+			config:  "arms:\n- name: a\n  percentage: 40\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			noFile:  true,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			savedErrors, savedExperiment, savedEnabled := configErrors, configuredExperiment, ExperimentEnabled
+			defer func() {
+				configErrors, configuredExperiment, ExperimentEnabled = savedErrors, savedExperiment, savedEnabled
+			}()
+			configErrors, configuredExperiment, ExperimentEnabled = nil, nil, false
+
+			path := filepath.Join(t.TempDir(), "experiment.yaml")
+			if !test.noFile {
+				if err := os.WriteFile(path, []byte(test.config), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv(experimentConfigFileEnvVar, path)
+			setExperiment()
+
+			// An invalid file fails the startup and enables no experiment.
+			err := ConfigError()
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Unexpected configuration error, want %t, got %v", test.wantErr, err)
+			}
+			if test.wantErr && !strings.Contains(err.Error(), "invalid experiment configuration "+path) {
+				t.Errorf("Unexpected configuration error %v", err)
+			}
+			if ExperimentEnabled == test.wantErr || (configuredExperiment != nil) == test.wantErr {
+				t.Errorf("Unexpected experiment, want enabled %t, got %t", !test.wantErr, ExperimentEnabled)
+			}
+		})
+	}
+}
+
+func TestExperimentScheduler(t *testing.T) {
+	primaryConfig := &SchedulerConfig{
+		preSchedulePlugins: []plugins.PreSchedule{},
+		filters:            []plugins.Filter{},
+		scorers: map[plugins.Scorer]int{
+			&podScorer{name: "pod1-scorer", pod: "pod1"}: 2,
+			&podScorer{name: "pod2-scorer", pod: "pod2"}: 1,
+		},
+		picker:                picker.NewMaxScorePicker(),
+		postSchedulePlugins:   []plugins.PostSchedule{},
+		postResponsePlugins:   []plugins.PostResponse{},
+		postCompletionPlugins: []plugins.PostCompletion{},
+	}
+	exp, err := parseExperiment([]byte(`
+assignment: header
+header: x-tenant
+arms:
+- name: pod1
+  percentage: 50
+- name: pod2
+  percentage: 50
+  scorers:
+  - name: pod1-scorer
+    weight: 0
+`), map[string]*SchedulerConfig{"": primaryConfig})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	previousConfig := defaultConfig
+	defaultConfig = primaryConfig
+	defer func() { defaultConfig = previousConfig }()
+
+	datastore := &fakeDataStore{pods: []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+	}}
+	scheduler := newExperimentScheduler(datastore, exp, false)
+
+	arms := map[string]int{}
+	for i := range 100 {
+		tenant := fmt.Sprintf("tenant-%d", i)
+		req := &types.LLMRequest{Model: "my-model", Headers: map[string]string{"x-tenant": tenant}}
+		res, err := scheduler.Schedule(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		arms[req.ExperimentArm]++
+
+		// Each arm schedules with its own profile.
+		if got := res.TargetPod.GetPod().NamespacedName.Name; got != req.ExperimentArm {
+			t.Errorf("Unexpected target pod of arm %s, want %s, got %s", req.ExperimentArm, req.ExperimentArm, got)
+		}
+		// The requests of a tenant are always assigned to the same arm.
+		again := &types.LLMRequest{Model: "my-model", Headers: map[string]string{"x-tenant": tenant}}
+		if _, err := scheduler.Schedule(context.Background(), again); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if again.ExperimentArm != req.ExperimentArm {
+			t.Errorf("Unexpected arm of %s, want %s, got %s", tenant, req.ExperimentArm, again.ExperimentArm)
+		}
+	}
+	if arms["pod1"] == 0 || arms["pod2"] == 0 {
+		t.Errorf("Unexpected assignment, want requests in both arms, got %v", arms)
+	}
+
+	if got := scheduler.arm(&types.LLMRequest{ExperimentArm: "pod2"}).name; got != "pod2" {
+		t.Errorf("Unexpected arm, want pod2, got %s", got)
+	}
+	if got := scheduler.arm(&types.LLMRequest{ExperimentArm: "unknown"}).name; got != "pod1" {
+		t.Errorf("Unexpected arm of an unknown arm, want the first arm pod1, got %s", got)
+	}
+}
@@ -27,7 +27,8 @@ import (
 // OpenAI API ChatCompletionRequest that are relevant for KV cache generation.
 // Model is not included as it is contained in the LLMRequest struct.
 //
-// Multimodal requests are not supported in the current implementation.
+// Multimodal content is not taken into account for KV cache generation in the current
+// implementation.
 type KVCacheChatCompletionRequest struct {
 	Messages    []openai.ChatCompletionMessage `json:"messages"`
 	Tools       []openai.Tool                  `json:"tools,omitempty"`
@@ -75,6 +76,118 @@ func NewKVCacheChatCompletionRequest(input map[string]interface{}) (*KVCacheChat
 	return &req, nil
 }
 
+// NewKVCacheChatCompletionRequestFromJSON creates a new KVCacheChatCompletionRequest from the raw
+// JSON values of the messages, tools and tool choices of a request, nil if not set, sparing the
+// decoding of the rest of the request.
+func NewKVCacheChatCompletionRequestFromJSON(messages, tools, toolChoices []byte) (*KVCacheChatCompletionRequest, error) {
+	var req KVCacheChatCompletionRequest
+	if messages != nil {
+		if err := json.Unmarshal(messages, &req.Messages); err != nil {
+			return nil, err
+		}
+	}
+	if tools != nil {
+		if err := json.Unmarshal(tools, &req.Tools); err != nil {
+			return nil, err
+		}
+	}
+	if toolChoices != nil {
+		if err := json.Unmarshal(toolChoices, &req.ToolChoices); err != nil {
+			return nil, err
+		}
+	}
+	return &req, nil
+}
+
+// responsesInputItem is an item of the input of a responses API request, either a message or another
+// item, e.g., a function call output.
+type responsesInputItem struct {
+	Type    string          `json:"type"`
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// responsesContentPart is a part of the content of a responses API message, e.g., an input text.
+type responsesContentPart struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// NewKVCacheChatCompletionRequestFromResponsesJSON creates a new KVCacheChatCompletionRequest from
+// the raw JSON values of the instructions and input of a responses API request, nil if not set. The
+// instructions make a system message, a string input a user message, and the items of an array
+// input a message each, the items other than messages holding their raw JSON value as content.
+func NewKVCacheChatCompletionRequestFromResponsesJSON(instructions, input []byte) (*KVCacheChatCompletionRequest, error) {
+	var req KVCacheChatCompletionRequest
+	if instructions != nil && string(instructions) != "null" {
+		msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem}
+		if err := json.Unmarshal(instructions, &msg.Content); err != nil {
+			return nil, err
+		}
+		req.Messages = append(req.Messages, msg)
+	}
+	if input == nil || string(input) == "null" {
+		return &req, nil
+	}
+	if input[0] == '"' {
+		msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser}
+		if err := json.Unmarshal(input, &msg.Content); err != nil {
+			return nil, err
+		}
+		req.Messages = append(req.Messages, msg)
+		return &req, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(input, &items); err != nil {
+		return nil, err
+	}
+	for _, raw := range items {
+		var item responsesInputItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return nil, err
+		}
+		if item.Role == "" {
+			req.Messages = append(req.Messages, openai.ChatCompletionMessage{Role: item.Type, Content: string(raw)})
+			continue
+		}
+		msg := openai.ChatCompletionMessage{Role: item.Role}
+		if len(item.Content) > 0 && item.Content[0] == '"' {
+			if err := json.Unmarshal(item.Content, &msg.Content); err != nil {
+				return nil, err
+			}
+		} else if len(item.Content) > 0 && string(item.Content) != "null" {
+			var parts []responsesContentPart
+			if err := json.Unmarshal(item.Content, &parts); err != nil {
+				return nil, err
+			}
+			for _, part := range parts {
+				// Text parts are input_text or output_text ones, and the others, e.g., input_image,
+				// are kept as multimodal content.
+				partType := openai.ChatMessagePartType(part.Type)
+				if strings.HasSuffix(part.Type, "text") {
+					partType = openai.ChatMessagePartTypeText
+				}
+				msg.MultiContent = append(msg.MultiContent, openai.ChatMessagePart{Type: partType, Text: part.Text})
+			}
+		}
+		req.Messages = append(req.Messages, msg)
+	}
+	return &req, nil
+}
+
+// HasMultimodalContent returns true if any message holds non-text content, e.g., an image.
+func (r *KVCacheChatCompletionRequest) HasMultimodalContent() bool {
+	for _, msg := range r.Messages {
+		for _, part := range msg.MultiContent {
+			if part.Type != openai.ChatMessagePartTypeText {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ToString generates a string representation of the KVCacheChatCompletionRequest.
 func (r *KVCacheChatCompletionRequest) ToString() string {
 	var builder strings.Builder
@@ -83,6 +196,9 @@ func (r *KVCacheChatCompletionRequest) ToString() string {
 		builder.WriteString(msg.Role)
 		builder.WriteString(":")
 		builder.WriteString(msg.Content)
+		for _, part := range msg.MultiContent {
+			builder.WriteString(part.Text)
+		}
 		builder.WriteString("\n")
 	}
 
@@ -0,0 +1,130 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrStateNotFound is returned when reading a key missing from the cycle state.
+var ErrStateNotFound = errors.New("state not found")
+
+// StateKey is the key of data stored in the cycle state. Plugins should prefix their keys with
+// their name, to avoid collisions.
+type StateKey string
+
+// StateData is data stored in the cycle state.
+type StateData interface {
+	// Clone returns a copy of the data, which must not share mutable state with the original.
+	Clone() StateData
+}
+
+// CycleState holds the data shared by the plugins during a scheduling cycle, e.g., computed by a
+// pre-schedule plugin and read by the filters, scorers and post-schedule plugins. It is safe for
+// concurrent use.
+type CycleState struct {
+	mu      sync.RWMutex
+	storage map[StateKey]StateData
+}
+
+// NewCycleState creates an empty CycleState.
+func NewCycleState() *CycleState {
+	return &CycleState{storage: map[StateKey]StateData{}}
+}
+
+// Read returns the data stored under the given key, or ErrStateNotFound.
+func (c *CycleState) Read(key StateKey) (StateData, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if data, ok := c.storage[key]; ok {
+		return data, nil
+	}
+	return nil, ErrStateNotFound
+}
+
+// Write stores the data under the given key, replacing any previous data.
+func (c *CycleState) Write(key StateKey, data StateData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.storage[key] = data
+}
+
+// Delete deletes the data stored under the given key, if any.
+func (c *CycleState) Delete(key StateKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.storage, key)
+}
+
+// Clone returns a copy of the cycle state holding clones of its data, so that writes to either do
+// not affect the other. A nil cycle state is cloned into an empty one.
+func (c *CycleState) Clone() *CycleState {
+	clone := NewCycleState()
+	if c == nil {
+		return clone
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for key, data := range c.storage {
+		clone.storage[key] = data.Clone()
+	}
+	return clone
+}
+
+// ReadCycleState returns the data of type T stored under the given key, or an error if the key is
+// missing or holds data of another type.
+func ReadCycleState[T StateData](c *CycleState, key StateKey) (T, error) {
+	var zero T
+	data, err := c.Read(key)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := data.(T)
+	if !ok {
+		return zero, fmt.Errorf("state %q holds %T rather than %T", key, data, zero)
+	}
+	return typed, nil
+}
+
+// PromptStateKey is the key of the PromptState of the request.
+const PromptStateKey StateKey = "prompt"
+
+// PromptState holds the rendered prompt of the request, see SchedulingContext.PromptText.
+type PromptState struct {
+	Text string
+}
+
+func (s *PromptState) Clone() StateData {
+	return &PromptState{Text: s.Text}
+}
+
+// TokensStateKey returns the key of the TokensState of the request prompt, tokenized for the
+// given model.
+func TokensStateKey(model string) StateKey {
+	return StateKey("tokens/" + model)
+}
+
+// TokensState holds the token IDs of the request prompt.
+type TokensState struct {
+	TokenIDs []uint32
+}
+
+func (s *TokensState) Clone() StateData {
+	return &TokensState{TokenIDs: append([]uint32(nil), s.TokenIDs...)}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCycleState(t *testing.T) {
+	state := NewCycleState()
+	if _, err := ReadCycleState[*TokensState](state, TokensStateKey("model")); !errors.Is(err, ErrStateNotFound) {
+		t.Errorf("Expected ErrStateNotFound, got %v", err)
+	}
+
+	state.Write(TokensStateKey("model"), &TokensState{TokenIDs: []uint32{1, 2, 3}})
+	state.Write(PromptStateKey, &PromptState{Text: "hello"})
+	if _, err := ReadCycleState[*TokensState](state, PromptStateKey); err == nil {
+		t.Error("Expected an error reading a state of another type")
+	}
+
+	clone := state.Clone()
+	tokens, err := ReadCycleState[*TokensState](clone, TokensStateKey("model"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tokens.TokenIDs[0] = 42
+	clone.Delete(PromptStateKey)
+
+	// The original is not affected by changes to the clone.
+	original, err := ReadCycleState[*TokensState](state, TokensStateKey("model"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]uint32{1, 2, 3}, original.TokenIDs); diff != "" {
+		t.Errorf("Unexpected tokens (-want +got): %v", diff)
+	}
+	if _, err := state.Read(PromptStateKey); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestSchedulingContextPromptText(t *testing.T) {
+	req := &LLMRequest{Prompt: "hello"}
+	ctx := NewSchedulingContext(context.Background(), req, nil, 0)
+	if got := ctx.PromptText(); got != "hello" {
+		t.Errorf("Unexpected prompt %q", got)
+	}
+
+	// The prompt is rendered once per cycle.
+	req.Prompt = "changed"
+	if got := ctx.PromptText(); got != "hello" {
+		t.Errorf("Unexpected prompt %q, expected the rendered prompt to be reused", got)
+	}
+}
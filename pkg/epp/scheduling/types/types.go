@@ -19,12 +19,34 @@ package types
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
 )
 
+// SessionTokenHeader is the name of the request header carrying the client session token, and of
+// the response header returning a refreshed token to the client.
+const SessionTokenHeader = "x-session-token"
+
+// RequestIDHeader is the name of the request header carrying the request ID, as set by Envoy.
+const RequestIDHeader = "x-request-id"
+
+// GatewayZoneHeader is the name of the request header carrying the topology zone of the gateway
+// instance that received the request.
+const GatewayZoneHeader = "x-gateway-zone"
+
+// The API types of requests, from the OpenAI compatible endpoint they are sent to.
+const (
+	CompletionsAPI     = "completions"
+	ChatCompletionsAPI = "chat_completions"
+	EmbeddingsAPI      = "embeddings"
+	RerankAPI          = "rerank"
+	ResponsesAPI       = "responses"
+	TranscriptionsAPI  = "audio_transcriptions"
+)
+
 // LLMRequest is a structured representation of the fields we parse out of the LLMRequest body.
 type LLMRequest struct {
 	Model                 string
@@ -36,7 +58,80 @@ type LLMRequest struct {
 	// Resolved target model is the final target model after traffic split.
 	ResolvedTargetModel string
 	Critical            bool
-	SessionID           string
+	// SessionID is the opaque session token sent by the client, if any.
+	SessionID string
+	// User is the end-user identifier from the request body "user" field, if any.
+	User string
+	// RequestID identifies the request, correlating its scheduling with its response.
+	RequestID string
+	// Sheddable is true for requests of models with Sheddable criticality.
+	Sheddable bool
+	// MaxTokens is the maximum number of tokens to generate from the request body "max_tokens"
+	// (or "max_completion_tokens") field, zero if not set.
+	MaxTokens int
+	// TTFTObjective and TPOTObjective are the latency objectives of the model, zero if none.
+	TTFTObjective time.Duration
+	TPOTObjective time.Duration
+	// GatewayZone is the topology zone of the gateway instance that received the request, empty if
+	// unknown.
+	GatewayZone string
+	// ExperimentArm is the experiment arm the request was assigned to, empty if none.
+	ExperimentArm string
+	// Stream is true if the request body asks for a streamed response.
+	Stream bool
+	// APIType is the API of the request, e.g., ChatCompletionsAPI.
+	APIType string
+	// BatchSize is the number of inputs of requests given an array of them, e.g., the texts to embed
+	// or the documents to rerank, zero otherwise. The prompt of such requests is their longest input,
+	// as each input is a separate sequence.
+	BatchSize int
+	// PromptTokens is the number of tokens of the input given as token IDs instead of text, which is
+	// not part of the prompt.
+	PromptTokens int
+}
+
+// estimatedCharsPerToken is the average number of characters per token, used to estimate the
+// number of tokens of a prompt without tokenizing it.
+const estimatedCharsPerToken = 4
+
+// EstimatedPromptTokens estimates the number of tokens of the request prompt, including the inputs
+// given as token IDs.
+func (r *LLMRequest) EstimatedPromptTokens() int {
+	return EstimatedTokens(len(r.PromptText())) + r.PromptTokens
+}
+
+// HasMultimodalInput returns true if the request holds inputs other than text, e.g., images.
+func (r *LLMRequest) HasMultimodalInput() bool {
+	return r.ChatCompletionRequest != nil && r.ChatCompletionRequest.HasMultimodalContent()
+}
+
+// EstimatedTokens estimates the number of tokens of a text of the given length in characters.
+func EstimatedTokens(chars int) int {
+	return (chars + estimatedCharsPerToken - 1) / estimatedCharsPerToken
+}
+
+// PromptText returns the prompt of the request, or the flattened messages of chat completions.
+func (r *LLMRequest) PromptText() string {
+	if r.ChatCompletionRequest != nil {
+		return r.ChatCompletionRequest.ToString()
+	}
+	return r.Prompt
+}
+
+// RequiredKVCacheTokens estimates the number of KV-cache tokens the request needs to complete
+// without preemption: its prompt plus its maximum number of generated tokens. The given default is
+// used when the request does not set a maximum.
+func (r *LLMRequest) RequiredKVCacheTokens(defaultMaxTokens int) int {
+	maxTokens := r.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+	return r.EstimatedPromptTokens() + maxTokens
+}
+
+// HasObjectives returns whether the request has latency objectives.
+func (r *LLMRequest) HasObjectives() bool {
+	return r.TTFTObjective > 0 || r.TPOTObjective > 0
 }
 
 func (r *LLMRequest) String() string {
@@ -68,6 +163,13 @@ type SchedulingContext struct {
 	PodsSnapshot   []Pod
 	TargetPort     int32
 	MutatedHeaders map[string]string
+	// PrefillPod is the pod selected for the prefill stage of a prefill/decode request, nil otherwise.
+	PrefillPod Pod
+	// Rejection is the error returned when the filters leave no pod, set by filters that reject the
+	// request itself rather than the lack of capacity, e.g., as a bad request. Nil if not rejected.
+	Rejection error
+	// CycleState holds the data the plugins share during the scheduling cycle.
+	CycleState *CycleState
 }
 
 func (pm *PodMetrics) String() string {
@@ -99,9 +201,27 @@ func NewSchedulingContext(ctx context.Context, req *LLMRequest, pods []Pod, targ
 		PodsSnapshot:   pods,
 		TargetPort:     targetPort,
 		MutatedHeaders: make(map[string]string),
+		CycleState:     NewCycleState(),
 	}
 }
 
+// PromptText returns the prompt of the request, or its flattened chat completion messages. The
+// prompt is rendered once per scheduling cycle and shared by the plugins through the cycle state.
+func (c *SchedulingContext) PromptText() string {
+	if c.Req == nil {
+		return ""
+	}
+	if c.CycleState == nil {
+		return c.Req.PromptText()
+	}
+	if state, err := ReadCycleState[*PromptState](c.CycleState, PromptStateKey); err == nil {
+		return state.Text
+	}
+	text := c.Req.PromptText()
+	c.CycleState.Write(PromptStateKey, &PromptState{Text: text})
+	return text
+}
+
 func ToSchedulerPodMetrics(pods []backendmetrics.PodMetrics) []Pod {
 	pm := make([]Pod, 0, len(pods))
 	for _, pod := range pods {
@@ -110,8 +230,35 @@ func ToSchedulerPodMetrics(pods []backendmetrics.PodMetrics) []Pod {
 	return pm
 }
 
+// ResponseLatency holds the latencies observed for a request once its response completed.
+type ResponseLatency struct {
+	// TTFT is the time to first token, zero if the response was not streamed.
+	TTFT time.Duration
+	// E2E is the time from receiving the request to completing its response.
+	E2E              time.Duration
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// TPOT returns the time per output token after the first one, or zero if it cannot be computed.
+func (l *ResponseLatency) TPOT() time.Duration {
+	if l.TTFT <= 0 || l.CompletionTokens < 2 || l.E2E <= l.TTFT {
+		return 0
+	}
+	return (l.E2E - l.TTFT) / time.Duration(l.CompletionTokens-1)
+}
+
+// LatencyPrediction holds the latencies a request is predicted to be served with on a pod.
+type LatencyPrediction struct {
+	TTFT time.Duration
+	TPOT time.Duration
+}
+
 // Result captures the scheduler result.
 type Result struct {
 	TargetPod      Pod
 	MutatedHeaders map[string]string
+	// NeedsCompletion is set by the post-response plugins run, and is true if post-completion
+	// plugins observe the completion of the response, which requires its body.
+	NeedsCompletion bool
 }
@@ -35,7 +35,9 @@ import (
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/controller"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/handlers"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/recorder"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
 )
 
 // ExtProcServerRunner provides methods to manage an external process server.
@@ -48,7 +50,14 @@ type ExtProcServerRunner struct {
 	SecureServing                            bool
 	CertPath                                 string
 	UseStreaming                             bool
-	RefreshPrometheusMetricsInterval         time.Duration
+	// SkipResponseBody asks Envoy not to send the response bodies to the EPP.
+	SkipResponseBody bool
+	// BodylessPolicy routes the requests without a body other than the /v1/models ones.
+	BodylessPolicy                   string
+	RefreshPrometheusMetricsInterval time.Duration
+	GatewayZone                      string
+	// Recorder records sampled scheduling decisions, nil if disabled.
+	Recorder *recorder.Recorder
 
 	// This should only be used in tests. We won't need this once we don't inject metrics in the tests.
 	// TODO:(https://github.com/kubernetes-sigs/gateway-api-inference-extension/issues/432) Cleanup
@@ -65,6 +74,8 @@ const (
 	DefaultRefreshMetricsInterval                   = 50 * time.Millisecond            // default for --refreshMetricsInterval
 	DefaultRefreshPrometheusMetricsInterval         = 5 * time.Second                  // default for --refreshPrometheusMetricsInterval
 	DefaultSecureServing                            = true                             // default for --secureServing
+	DefaultUseStreaming                             = true                             // default for --streaming
+	DefaultBodylessPolicy                           = handlers.BodylessRandomPolicy    // default for --bodylessRequestPolicy
 )
 
 func NewDefaultExtProcServerRunner() *ExtProcServerRunner {
@@ -74,6 +85,8 @@ func NewDefaultExtProcServerRunner() *ExtProcServerRunner {
 		DestinationEndpointHintMetadataNamespace: DefaultDestinationEndpointHintMetadataNamespace,
 		PoolNamespacedName:                       types.NamespacedName{Name: DefaultPoolName, Namespace: DefaultPoolNamespace},
 		SecureServing:                            DefaultSecureServing,
+		UseStreaming:                             DefaultUseStreaming,
+		BodylessPolicy:                           DefaultBodylessPolicy,
 		RefreshPrometheusMetricsInterval:         DefaultRefreshPrometheusMetricsInterval,
 		// Datastore can be assigned later.
 	}
@@ -139,12 +152,25 @@ func (r *ExtProcServerRunner) AsRunnable(logger logr.Logger) manager.Runnable {
 		}
 
 		var scheduler handlers.Scheduler
-		if scheduling.PDEnabled {
-			scheduler = scheduling.NewPDScheduler(r.Datastore)
+		var schedulerPlugins []plugins.Plugin
+		if scheduling.ExperimentEnabled {
+			experimentScheduler := scheduling.NewExperimentScheduler(r.Datastore)
+			scheduler, schedulerPlugins = experimentScheduler, experimentScheduler.Plugins()
+		} else if scheduling.PDEnabled {
+			pdScheduler := scheduling.NewPDScheduler(r.Datastore)
+			scheduler, schedulerPlugins = pdScheduler, pdScheduler.Plugins()
 		} else {
-			scheduler = scheduling.NewScheduler(r.Datastore)
+			defaultScheduler := scheduling.NewScheduler(r.Datastore)
+			scheduler, schedulerPlugins = defaultScheduler, defaultScheduler.Plugins()
 		}
-		extProcServer := handlers.NewStreamingServer(scheduler, r.DestinationEndpointHintMetadataNamespace, r.DestinationEndpointHintKey, r.Datastore)
+		if err := scheduling.StartPlugins(ctx, r.Datastore, schedulerPlugins); err != nil {
+			logger.Error(err, "Failed to start the scheduler plugins")
+			return err
+		}
+		if r.Recorder != nil {
+			scheduler = r.Recorder.Wrap(scheduler)
+		}
+		extProcServer := handlers.NewStreamingServer(scheduler, r.DestinationEndpointHintMetadataNamespace, r.DestinationEndpointHintKey, r.GatewayZone, r.Datastore, r.UseStreaming, r.SkipResponseBody, r.BodylessPolicy)
 		extProcPb.RegisterExternalProcessorServer(
 			srv,
 			extProcServer,
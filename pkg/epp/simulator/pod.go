@@ -0,0 +1,263 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	lru "github.com/hashicorp/golang-lru/v2"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// simMaxLoRAs is the number of LoRA adapters the simulated pods report they can load.
+const simMaxLoRAs = 4
+
+// EngineConfig models the continuous batching engine of the simulated pods. The latencies default
+// to the profile of the Python simulator under tools/simulations.
+type EngineConfig struct {
+	// KVCacheTokens is the number of tokens the KV cache holds.
+	KVCacheTokens int
+	// BlockSize is the number of tokens of the KV cache blocks, the unit of prefix caching.
+	BlockSize int
+	// MaxNumSeqs is the maximum number of requests running concurrently.
+	MaxNumSeqs int
+	// MaxBatchTokens is the maximum number of tokens prefilled by a step. A longer prompt is
+	// prefilled alone.
+	MaxBatchTokens int
+	// PreemptionThreshold is the KV cache usage beyond which running requests are preempted, newest
+	// first, to be recomputed later.
+	PreemptionThreshold float64
+	// MaxModelLen is the context length reported by the pods, zero if unknown.
+	MaxModelLen int
+
+	// The duration of a prefill step is max(PrefillMin, PrefillBase + PrefillPerToken * tokens).
+	PrefillBase     time.Duration
+	PrefillPerToken time.Duration
+	PrefillMin      time.Duration
+	// The duration of a decode step is DecodeBase + DecodePerSeq * requests + DecodePerKVToken * tokens.
+	DecodeBase       time.Duration
+	DecodePerSeq     time.Duration
+	DecodePerKVToken time.Duration
+}
+
+// DefaultEngineConfig returns an EngineConfig instance with default configuration.
+func DefaultEngineConfig() *EngineConfig {
+	return &EngineConfig{
+		KVCacheTokens:       2810 * 16,
+		BlockSize:           16,
+		MaxNumSeqs:          256,
+		MaxBatchTokens:      512,
+		PreemptionThreshold: 0.9,
+
+		PrefillBase:      19690 * time.Microsecond,
+		PrefillPerToken:  67694 * time.Nanosecond,
+		PrefillMin:       40 * time.Millisecond,
+		DecodeBase:       14 * time.Millisecond,
+		DecodePerSeq:     102649 * time.Nanosecond,
+		DecodePerKVToken: 535 * time.Nanosecond,
+	}
+}
+
+func (c *EngineConfig) prefillDuration(tokens int) time.Duration {
+	return max(c.PrefillMin, c.PrefillBase+time.Duration(tokens)*c.PrefillPerToken)
+}
+
+func (c *EngineConfig) decodeDuration(requests, kvTokens int) time.Duration {
+	return c.DecodeBase + time.Duration(requests)*c.DecodePerSeq + time.Duration(kvTokens)*c.DecodePerKVToken
+}
+
+// simRequest is a request served by the simulated pods.
+type simRequest struct {
+	trace *TraceRequest
+	req   *types.LLMRequest
+	// blocks are the hashes of the full prompt blocks, for prefix caching.
+	blocks       []uint64
+	promptTokens int
+
+	// target is the pod decoding the request, and prefill the pod prefilling it if disaggregated.
+	target  *simPod
+	prefill *simPod
+
+	arrival    time.Duration
+	firstToken time.Duration
+	generated  int
+	// prefilled is set once the KV cache of the prompt was computed, on the decode pod or transferred
+	// from the prefill pod.
+	prefilled bool
+}
+
+// contextTokens returns the number of tokens held in the KV cache for the request.
+func (r *simRequest) contextTokens() int {
+	return r.promptTokens + r.generated
+}
+
+// simPod simulates a model server running a continuous batching engine: each step either prefills
+// waiting requests, or decodes a token of every running request.
+type simPod struct {
+	pod    *backendmetrics.Pod
+	config *EngineConfig
+
+	waiting []*simRequest
+	running []*simRequest
+	// kvTokens is the number of tokens in the KV cache of the running requests.
+	kvTokens int
+	// prefixCache holds the hashes of the cached prompt blocks.
+	prefixCache *lru.Cache[uint64, struct{}]
+
+	// step is the step in flight, nil if the pod is idle.
+	step *podStep
+
+	stats podStats
+}
+
+type podStats struct {
+	requests     int
+	promptTokens int
+	cachedTokens int
+	preemptions  int
+}
+
+// podStep is a step of the engine, whose effects are applied once its duration elapsed.
+type podStep struct {
+	prefill  bool
+	requests []*simRequest
+	// cachedTokens are the prompt tokens of the prefilled requests found in the prefix cache.
+	cachedTokens []int
+}
+
+func newSimPod(pod *backendmetrics.Pod, config *EngineConfig) *simPod {
+	cache, _ := lru.New[uint64, struct{}](max(1, config.KVCacheTokens/config.BlockSize))
+	return &simPod{pod: pod, config: config, prefixCache: cache}
+}
+
+// metrics returns the metrics scraped from the pod by the EPP. The simulated requests target the
+// base model, which the model servers do not report among the active LoRA adapters.
+func (p *simPod) metrics() *backendmetrics.Metrics {
+	return &backendmetrics.Metrics{
+		ActiveModels:            map[string]int{},
+		WaitingModels:           map[string]int{},
+		MaxActiveModels:         simMaxLoRAs,
+		RunningQueueSize:        len(p.running),
+		WaitingQueueSize:        len(p.waiting),
+		KVCacheUsagePercent:     float64(p.kvTokens) / float64(p.config.KVCacheTokens),
+		KvCacheMaxTokenCapacity: p.config.KVCacheTokens,
+		MaxModelLen:             p.config.MaxModelLen,
+		UpdateTime:              time.Now(),
+	}
+}
+
+// enqueue queues a request for prefill, or for decode if its KV cache was transferred.
+func (p *simPod) enqueue(r *simRequest) {
+	p.waiting = append(p.waiting, r)
+	p.stats.requests++
+}
+
+// nextStep plans the next step of the pod, or returns nil if the pod has nothing to do. Prefilling
+// takes precedence over decoding, as long as the KV cache has room for the prompts.
+func (p *simPod) nextStep() (*podStep, time.Duration) {
+	step := &podStep{prefill: true}
+	prefillTokens := 0
+	kvTokens := p.kvTokens
+	for len(p.waiting) > 0 && len(p.running)+len(step.requests) < p.config.MaxNumSeqs {
+		r := p.waiting[0]
+		cached, tokens := 0, 0
+		if !r.prefilled {
+			cached = p.cachedTokens(r)
+			tokens = r.contextTokens() - cached
+		}
+		if len(step.requests) > 0 && prefillTokens+tokens > p.config.MaxBatchTokens {
+			break
+		}
+		if float64(kvTokens+r.contextTokens()) > p.config.PreemptionThreshold*float64(p.config.KVCacheTokens) && len(p.running)+len(step.requests) > 0 {
+			break // wait for running requests to free the KV cache
+		}
+		p.waiting = p.waiting[1:]
+		step.requests = append(step.requests, r)
+		step.cachedTokens = append(step.cachedTokens, cached)
+		prefillTokens += tokens
+		kvTokens += r.contextTokens()
+	}
+	if len(step.requests) > 0 && prefillTokens == 0 {
+		return step, p.config.DecodeBase // only requests whose KV cache was transferred
+	}
+	if len(step.requests) > 0 {
+		return step, p.config.prefillDuration(prefillTokens)
+	}
+
+	if len(p.running) == 0 {
+		return nil, 0
+	}
+	// Preempt the newest requests until the next token of every running request fits the cache.
+	for len(p.running) > 1 && float64(p.kvTokens+len(p.running)) > p.config.PreemptionThreshold*float64(p.config.KVCacheTokens) {
+		newest := p.running[len(p.running)-1]
+		p.running = p.running[:len(p.running)-1]
+		p.kvTokens -= newest.contextTokens()
+		newest.prefilled = false
+		p.waiting = append([]*simRequest{newest}, p.waiting...)
+		p.stats.preemptions++
+	}
+	step = &podStep{requests: append([]*simRequest{}, p.running...)}
+	return step, p.config.decodeDuration(len(step.requests), p.kvTokens)
+}
+
+// cachedTokens returns the number of prompt tokens of the request found in the prefix cache.
+func (p *simPod) cachedTokens(r *simRequest) int {
+	cached := 0
+	for _, block := range r.blocks {
+		if _, ok := p.prefixCache.Get(block); !ok {
+			break
+		}
+		cached += p.config.BlockSize
+	}
+	// The last token of the prompt is always computed, to produce the first output token.
+	return min(cached, r.promptTokens-1)
+}
+
+// admit records the prefix cache hits of a request prefilled for the first time, and caches its
+// prompt blocks.
+func (p *simPod) admit(r *simRequest, cachedTokens int) {
+	if r.generated == 0 {
+		p.stats.promptTokens += r.promptTokens
+		p.stats.cachedTokens += cachedTokens
+	}
+	for _, block := range r.blocks {
+		p.prefixCache.Add(block, struct{}{})
+	}
+}
+
+// promptBlocks returns the chained hashes of the full blocks of the prompt, as the prefix cache of
+// the model servers, estimating the characters of a block from its number of tokens.
+func promptBlocks(prompt string, promptTokens, blockSize int) []uint64 {
+	if promptTokens <= 0 {
+		return nil
+	}
+	charsPerBlock := max(1, len(prompt)*blockSize/promptTokens)
+	blocks := []uint64{}
+	previous := uint64(0)
+	digest := xxhash.New()
+	for start := 0; start+charsPerBlock <= len(prompt); start += charsPerBlock {
+		digest.Reset()
+		_ = binary.Write(digest, binary.LittleEndian, previous)
+		_, _ = digest.WriteString(prompt[start : start+charsPerBlock])
+		previous = digest.Sum64()
+		blocks = append(blocks, previous)
+	}
+	return blocks
+}
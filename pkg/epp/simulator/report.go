@@ -0,0 +1,164 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// Report summarizes the results of a simulation.
+type Report struct {
+	Requests  int `json:"requests"`
+	Completed int `json:"completed"`
+	Rejected  int `json:"rejected"`
+	// Duration is the simulated time until the last request completed.
+	Duration time.Duration `json:"duration"`
+	// RequestThroughput and OutputTokenThroughput are the completed requests and generated tokens
+	// per second.
+	RequestThroughput     float64 `json:"requestThroughput"`
+	OutputTokenThroughput float64 `json:"outputTokenThroughput"`
+	// TTFT is the time to first token, E2E the time to complete the request, and TPOT the time per
+	// output token after the first one.
+	TTFT LatencySummary `json:"ttft"`
+	E2E  LatencySummary `json:"e2e"`
+	TPOT LatencySummary `json:"tpot"`
+	// PrefixCacheHitRate is the fraction of the prompt tokens found in the prefix cache of the pods.
+	PrefixCacheHitRate float64     `json:"prefixCacheHitRate"`
+	Preemptions        int         `json:"preemptions"`
+	Pods               []PodReport `json:"pods"`
+}
+
+// LatencySummary summarizes the distribution of a latency.
+type LatencySummary struct {
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P99  time.Duration `json:"p99"`
+}
+
+// PodReport summarizes the load of a pod.
+type PodReport struct {
+	Name               string  `json:"name"`
+	Role               string  `json:"role"`
+	Requests           int     `json:"requests"`
+	PrefixCacheHitRate float64 `json:"prefixCacheHitRate"`
+	Preemptions        int     `json:"preemptions"`
+}
+
+func (s *Simulation) report(requests int) *Report {
+	report := &Report{Requests: requests, Completed: len(s.results), Rejected: s.rejected}
+	ttfts := make([]time.Duration, 0, len(s.results))
+	e2es := make([]time.Duration, 0, len(s.results))
+	tpots := []time.Duration{}
+	outputTokens := 0
+	for _, result := range s.results {
+		ttfts = append(ttfts, result.ttft)
+		e2es = append(e2es, result.e2e)
+		if result.outputTokens > 1 {
+			tpots = append(tpots, (result.e2e-result.ttft)/time.Duration(result.outputTokens-1))
+		}
+		outputTokens += result.outputTokens
+		report.Duration = max(report.Duration, result.e2e)
+	}
+	report.Duration = max(report.Duration, s.now)
+	if seconds := report.Duration.Seconds(); seconds > 0 {
+		report.RequestThroughput = float64(report.Completed) / seconds
+		report.OutputTokenThroughput = float64(outputTokens) / seconds
+	}
+	report.TTFT = summarize(ttfts)
+	report.E2E = summarize(e2es)
+	report.TPOT = summarize(tpots)
+
+	promptTokens, cachedTokens := 0, 0
+	for _, pod := range s.pods {
+		report.Pods = append(report.Pods, PodReport{
+			Name:               pod.pod.NamespacedName.Name,
+			Role:               pod.pod.Role.String(),
+			Requests:           pod.stats.requests,
+			PrefixCacheHitRate: ratio(pod.stats.cachedTokens, pod.stats.promptTokens),
+			Preemptions:        pod.stats.preemptions,
+		})
+		promptTokens += pod.stats.promptTokens
+		cachedTokens += pod.stats.cachedTokens
+		report.Preemptions += pod.stats.preemptions
+	}
+	report.PrefixCacheHitRate = ratio(cachedTokens, promptTokens)
+	return report
+}
+
+func summarize(latencies []time.Duration) LatencySummary {
+	if len(latencies) == 0 {
+		return LatencySummary{}
+	}
+	slices.Sort(latencies)
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	percentile := func(p int) time.Duration {
+		return latencies[min(len(latencies)-1, len(latencies)*p/100)]
+	}
+	return LatencySummary{
+		Mean: total / time.Duration(len(latencies)),
+		P50:  percentile(50),
+		P90:  percentile(90),
+		P99:  percentile(99),
+	}
+}
+
+func ratio(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
+
+// WriteText writes the report as human readable text.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Requests:\t%d (completed %d, rejected %d)\n", r.Requests, r.Completed, r.Rejected)
+	fmt.Fprintf(tw, "Duration:\t%v\n", r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(tw, "Throughput:\t%.2f req/s, %.1f output tokens/s\n", r.RequestThroughput, r.OutputTokenThroughput)
+	fmt.Fprintf(tw, "Prefix cache hit rate:\t%.1f%%\n", 100*r.PrefixCacheHitRate)
+	fmt.Fprintf(tw, "Preemptions:\t%d\n", r.Preemptions)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "Latency\tmean\tp50\tp90\tp99")
+	for _, latency := range []struct {
+		name    string
+		summary LatencySummary
+	}{{"TTFT", r.TTFT}, {"TPOT", r.TPOT}, {"E2E", r.E2E}} {
+		s := latency.summary
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%v\n", latency.name, round(s.Mean), round(s.P50), round(s.P90), round(s.P99))
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "Pod\trole\trequests\tprefix cache hit rate\tpreemptions")
+	for _, pod := range r.Pods {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.1f%%\t%d\n", pod.Name, pod.Role, pod.Requests, 100*pod.PrefixCacheHitRate, pod.Preemptions)
+	}
+	return tw.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond / 10)
+}
@@ -0,0 +1,374 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package simulator replays request traces against the scheduler of the EPP, routing the requests
+// to simulated model servers whose queues, KV cache and latencies evolve under continuous batching.
+package simulator
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+const (
+	simNamespace  = "simulation"
+	simTargetPort = 8000
+)
+
+// Scheduler schedules the simulated requests, e.g., scheduling.Scheduler or scheduling.PDScheduler.
+type Scheduler interface {
+	Schedule(ctx context.Context, req *types.LLMRequest) (*types.Result, error)
+	RunPostResponsePlugins(ctx context.Context, req *types.LLMRequest, targetPodName string) (*types.Result, error)
+	RunPostCompletionPlugins(ctx context.Context, req *types.LLMRequest, targetPodName string, latency *types.ResponseLatency) error
+	Plugins() []plugins.Plugin
+}
+
+// Config contains the configuration of a simulation.
+type Config struct {
+	// Pods is the number of pods serving both prefill and decode.
+	Pods int
+	// PrefillPods and DecodePods are the numbers of pods dedicated to prefill and decode.
+	PrefillPods int
+	DecodePods  int
+	// Engine models the engine of the pods. If nil, default is used.
+	Engine *EngineConfig
+	// NewScheduler creates the scheduler under test for the simulated datastore. If nil, the
+	// scheduler of the EPP is used, as configured by the environment.
+	NewScheduler func(datastore scheduling.Datastore) Scheduler
+}
+
+// Simulation replays a trace against a scheduler and simulated pods, in simulated time. The
+// scheduler plugins still observe the wall clock, e.g., to expire sessions.
+type Simulation struct {
+	pods      []*simPod
+	scheduler Scheduler
+	events    eventQueue
+	now       time.Duration
+	// sessionTokens holds the last session token issued per trace session.
+	sessionTokens map[string]string
+	results       []*requestResult
+	rejected      int
+}
+
+// requestResult is the outcome of a completed request.
+type requestResult struct {
+	ttft         time.Duration
+	e2e          time.Duration
+	outputTokens int
+}
+
+// NewSimulation creates a simulation of the given configuration.
+func NewSimulation(config *Config) (*Simulation, error) {
+	if config.Pods < 0 || config.PrefillPods < 0 || config.DecodePods < 0 || config.Pods+config.PrefillPods+config.DecodePods == 0 {
+		return nil, errors.New("at least one pod is required")
+	}
+	engine := config.Engine
+	if engine == nil {
+		engine = DefaultEngineConfig()
+	}
+	if engine.KVCacheTokens <= 0 || engine.BlockSize <= 0 || engine.MaxNumSeqs <= 0 {
+		return nil, errors.New("the KV cache size, block size and maximum number of sequences must be positive")
+	}
+
+	s := &Simulation{sessionTokens: map[string]string{}}
+	addPods := func(count int, role backendmetrics.PodRole, prefix string) {
+		for i := range count {
+			s.pods = append(s.pods, newSimPod(&backendmetrics.Pod{
+				NamespacedName: k8stypes.NamespacedName{Name: fmt.Sprintf("%s-%d", prefix, i), Namespace: simNamespace},
+				Address:        fmt.Sprintf("10.0.0.%d", len(s.pods)+1),
+				Role:           role,
+				MaxModelLen:    engine.MaxModelLen,
+			}, engine))
+		}
+	}
+	addPods(config.Pods, backendmetrics.Both, "pod")
+	addPods(config.PrefillPods, backendmetrics.Prefill, "prefill")
+	addPods(config.DecodePods, backendmetrics.Decode, "decode")
+
+	if config.NewScheduler != nil {
+		s.scheduler = config.NewScheduler(s)
+	} else if scheduling.PDEnabled {
+		s.scheduler = scheduling.NewPDScheduler(s)
+	} else {
+		s.scheduler = scheduling.NewScheduler(s)
+	}
+	return s, nil
+}
+
+// PoolGet implements the scheduling.Datastore interface.
+func (s *Simulation) PoolGet() (*v1alpha2.InferencePool, error) {
+	return &v1alpha2.InferencePool{Spec: v1alpha2.InferencePoolSpec{TargetPortNumber: simTargetPort}}, nil
+}
+
+// PodGetAll implements the scheduling.Datastore interface, returning the current metrics of the pods.
+func (s *Simulation) PodGetAll() []backendmetrics.PodMetrics {
+	pods := make([]backendmetrics.PodMetrics, 0, len(s.pods))
+	for _, pod := range s.pods {
+		pods = append(pods, &backendmetrics.FakePodMetrics{Pod: pod.pod, Metrics: pod.metrics()})
+	}
+	return pods
+}
+
+// Subscribe implements the scheduling.EventSource interface. The simulated pods never change, so
+// the handler only receives their added events.
+func (s *Simulation) Subscribe(ctx context.Context, handler datastore.EventHandler) func() {
+	for _, pod := range s.pods {
+		handler(datastore.Event{Type: datastore.PodAdded, Pod: pod.pod})
+	}
+	return func() {}
+}
+
+// Run replays the trace until all its requests completed or were rejected, and reports the results.
+func (s *Simulation) Run(ctx context.Context, trace []*TraceRequest) (*Report, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := scheduling.StartPlugins(ctx, s, s.scheduler.Plugins()); err != nil {
+		return nil, err
+	}
+
+	for i, traceReq := range trace {
+		s.events.push(&event{at: traceReq.arrival(), request: s.newRequest(i, traceReq)})
+	}
+	for s.events.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		e := heap.Pop(&s.events).(*event)
+		s.now = e.at
+		if e.request != nil {
+			s.schedule(ctx, e.request)
+		} else {
+			s.completeStep(ctx, e.pod)
+		}
+	}
+	return s.report(len(trace)), nil
+}
+
+func (s *Simulation) newRequest(i int, traceReq *TraceRequest) *simRequest {
+	prompt := traceReq.Prompt
+	if prompt == "" {
+		prompt = syntheticPrompt(i, traceReq.PromptTokens)
+	}
+	promptTokens := traceReq.PromptTokens
+	if promptTokens <= 0 {
+		promptTokens = types.EstimatedTokens(len(prompt))
+	}
+	return &simRequest{
+		trace: traceReq,
+		req: &types.LLMRequest{
+			Model:               traceReq.Model,
+			ResolvedTargetModel: traceReq.Model,
+			Prompt:              prompt,
+			Critical:            !traceReq.Sheddable,
+			Sheddable:           traceReq.Sheddable,
+			MaxTokens:           traceReq.OutputTokens,
+			RequestID:           fmt.Sprintf("request-%d", i),
+		},
+		blocks:       promptBlocks(prompt, promptTokens, s.pods[0].config.BlockSize),
+		promptTokens: max(1, promptTokens),
+		arrival:      traceReq.arrival(),
+	}
+}
+
+// syntheticPrompt generates a prompt of the given number of tokens, sharing no prefix with the
+// prompts of other requests.
+func syntheticPrompt(i, tokens int) string {
+	chars := tokens * 4
+	word := fmt.Sprintf("request-%d ", i)
+	return strings.Repeat(word, chars/len(word)+1)[:chars]
+}
+
+// schedule routes an arriving request to its pods.
+func (s *Simulation) schedule(ctx context.Context, r *simRequest) {
+	logger := log.FromContext(ctx)
+	if r.trace.Session != "" {
+		r.req.SessionID = s.sessionTokens[r.trace.Session]
+	}
+	res, err := s.scheduler.Schedule(ctx, r.req)
+	if err != nil || res.TargetPod == nil {
+		logger.V(logutil.VERBOSE).Info("Request rejected", "request", r.req.RequestID, "error", err)
+		s.rejected++
+		return
+	}
+	r.target = s.podByName(res.TargetPod.GetPod().NamespacedName)
+	if url, ok := res.MutatedHeaders[scheduling.PrefillPodHeader]; ok {
+		r.prefill = s.podByURL(url)
+	}
+	if r.target == nil {
+		s.rejected++
+		return
+	}
+
+	pod := r.target
+	if r.prefill != nil && r.prefill != r.target {
+		pod = r.prefill
+	}
+	pod.enqueue(r)
+	s.startStep(pod)
+}
+
+func (s *Simulation) podByName(name k8stypes.NamespacedName) *simPod {
+	for _, pod := range s.pods {
+		if pod.pod.NamespacedName == name {
+			return pod
+		}
+	}
+	return nil
+}
+
+func (s *Simulation) podByURL(url string) *simPod {
+	for _, pod := range s.pods {
+		if url == fmt.Sprintf("http://%s:%d", pod.pod.Address, simTargetPort) {
+			return pod
+		}
+	}
+	return nil
+}
+
+// startStep starts the next step of the pod, unless a step is in flight or the pod is idle.
+func (s *Simulation) startStep(pod *simPod) {
+	if pod.step != nil {
+		return
+	}
+	step, duration := pod.nextStep()
+	if step == nil {
+		return
+	}
+	pod.step = step
+	s.events.push(&event{at: s.now + duration, pod: pod})
+}
+
+// completeStep applies the effects of the step in flight of the pod, then starts its next step.
+func (s *Simulation) completeStep(ctx context.Context, pod *simPod) {
+	step := pod.step
+	pod.step = nil
+
+	if step.prefill {
+		for i, r := range step.requests {
+			if !r.prefilled {
+				pod.admit(r, step.cachedTokens[i])
+				r.prefilled = true
+			}
+			if r.prefill == pod && r.target != pod {
+				// The KV cache is transferred to the decode pod, which produces the first token.
+				r.prefill = nil
+				r.target.enqueue(r)
+				s.startStep(r.target)
+				continue
+			}
+			pod.kvTokens += r.contextTokens()
+			s.generateToken(ctx, pod, r)
+			if !s.completed(ctx, pod, r) {
+				pod.running = append(pod.running, r)
+			}
+		}
+	} else {
+		running := pod.running[:0]
+		for _, r := range pod.running {
+			s.generateToken(ctx, pod, r)
+			if !s.completed(ctx, pod, r) {
+				running = append(running, r)
+			}
+		}
+		pod.running = running
+	}
+	s.startStep(pod)
+}
+
+func (s *Simulation) generateToken(ctx context.Context, pod *simPod, r *simRequest) {
+	r.generated++
+	pod.kvTokens++
+	if r.firstToken > 0 {
+		return
+	}
+	r.firstToken = s.now
+	res, err := s.scheduler.RunPostResponsePlugins(ctx, r.req, pod.pod.NamespacedName.String())
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to run the post-response plugins", "request", r.req.RequestID)
+		return
+	}
+	if token, ok := res.MutatedHeaders[types.SessionTokenHeader]; ok && r.trace.Session != "" {
+		s.sessionTokens[r.trace.Session] = token
+	}
+}
+
+// completed completes the request once all its tokens were generated, freeing its KV cache.
+func (s *Simulation) completed(ctx context.Context, pod *simPod, r *simRequest) bool {
+	if r.generated < r.trace.OutputTokens {
+		return false
+	}
+	pod.kvTokens -= r.contextTokens()
+	result := &requestResult{ttft: r.firstToken - r.arrival, e2e: s.now - r.arrival, outputTokens: r.generated}
+	s.results = append(s.results, result)
+	latency := &types.ResponseLatency{TTFT: result.ttft, E2E: result.e2e, PromptTokens: r.promptTokens, CompletionTokens: r.generated}
+	if err := s.scheduler.RunPostCompletionPlugins(ctx, r.req, pod.pod.NamespacedName.String(), latency); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to run the post-completion plugins", "request", r.req.RequestID)
+	}
+	return true
+}
+
+// event is either the arrival of a request or the completion of the step of a pod.
+type event struct {
+	at      time.Duration
+	seq     int
+	request *simRequest
+	pod     *simPod
+}
+
+// eventQueue is a priority queue of events, ordered by time and then by insertion.
+type eventQueue struct {
+	events []*event
+	seq    int
+}
+
+func (q *eventQueue) push(e *event) {
+	e.seq = q.seq
+	q.seq++
+	heap.Push(q, e)
+}
+
+func (q *eventQueue) Len() int { return len(q.events) }
+
+func (q *eventQueue) Less(i, j int) bool {
+	if q.events[i].at != q.events[j].at {
+		return q.events[i].at < q.events[j].at
+	}
+	return q.events[i].seq < q.events[j].seq
+}
+
+func (q *eventQueue) Swap(i, j int) { q.events[i], q.events[j] = q.events[j], q.events[i] }
+
+func (q *eventQueue) Push(x any) { q.events = append(q.events, x.(*event)) }
+
+func (q *eventQueue) Pop() any {
+	last := q.events[len(q.events)-1]
+	q.events = q.events[:len(q.events)-1]
+	return last
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadTrace(t *testing.T) {
+	tests := []struct {
+		name        string
+		trace       string
+		wantErr     bool
+		wantArrival []float64
+	}{
+		{
+			name: "requests sorted by arrival",
+			trace: `{"arrival": 1.5, "model": "m", "prompt": "hello", "outputTokens": 10}
+
+{"arrival": 0.5, "model": "m", "promptTokens": 100, "outputTokens": 10}`,
+			wantArrival: []float64{0.5, 1.5},
+		},
+		{
+			name:    "missing prompt",
+			trace:   `{"arrival": 0, "model": "m", "outputTokens": 10}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing output tokens",
+			trace:   `{"arrival": 0, "model": "m", "prompt": "hello"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			trace:   `{"arrival": 0,`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trace, err := ReadTrace(strings.NewReader(test.trace))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Unexpected error, want %v, got %v", test.wantErr, err)
+			}
+			gotArrival := []float64{}
+			for _, req := range trace {
+				gotArrival = append(gotArrival, req.Arrival)
+			}
+			if diff := cmp.Diff(test.wantArrival, gotArrival); !test.wantErr && diff != "" {
+				t.Errorf("Unexpected arrivals (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestSimulation(t *testing.T) {
+	systemPrompt := strings.Repeat("You are a helpful assistant. ", 100)
+	trace := []*TraceRequest{}
+	for i := range 50 {
+		trace = append(trace, &TraceRequest{
+			Arrival:      float64(i) * 0.05,
+			Model:        "my-model",
+			Prompt:       systemPrompt + strings.Repeat("question ", i+1),
+			OutputTokens: 20,
+		})
+	}
+	// A long request arriving last, with a prompt of its own.
+	trace = append(trace, &TraceRequest{Arrival: 2.5, Model: "my-model", PromptTokens: 2000, OutputTokens: 100})
+
+	sim, err := NewSimulation(&Config{Pods: 2})
+	if err != nil {
+		t.Fatalf("Failed to create simulation: %v", err)
+	}
+	report, err := sim.Run(context.Background(), trace)
+	if err != nil {
+		t.Fatalf("Simulation failed: %v", err)
+	}
+
+	if report.Requests != len(trace) || report.Completed != len(trace) || report.Rejected != 0 {
+		t.Errorf("Expected all %d requests to complete, got %+v", len(trace), report)
+	}
+	if report.PrefixCacheHitRate <= 0.5 {
+		t.Errorf("Expected the shared system prompt to hit the prefix cache, got hit rate %v", report.PrefixCacheHitRate)
+	}
+	if report.TTFT.P50 <= 0 || report.TTFT.P50 > report.E2E.P50 || report.TPOT.P50 <= 0 {
+		t.Errorf("Unexpected latencies, TTFT %+v, TPOT %+v, E2E %+v", report.TTFT, report.TPOT, report.E2E)
+	}
+	requests := 0
+	for _, pod := range report.Pods {
+		requests += pod.Requests
+	}
+	if requests != len(trace) {
+		t.Errorf("Expected the pods to serve %d requests, got %+v", len(trace), report.Pods)
+	}
+
+	var text strings.Builder
+	if err := report.WriteText(&text); err != nil || !strings.Contains(text.String(), "pod-1") {
+		t.Errorf("Unexpected text report %q, error %v", text.String(), err)
+	}
+}
+
+func TestNewSimulationValidation(t *testing.T) {
+	if _, err := NewSimulation(&Config{}); err == nil {
+		t.Error("Expected an error for a simulation without pods")
+	}
+	engine := DefaultEngineConfig()
+	engine.BlockSize = 0
+	if _, err := NewSimulation(&Config{Pods: 1, Engine: engine}); err == nil {
+		t.Error("Expected an error for an invalid engine")
+	}
+}
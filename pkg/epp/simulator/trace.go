@@ -0,0 +1,96 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxTraceLineSize is the maximum size of a trace line, i.e., of a request with its prompt.
+const maxTraceLineSize = 16 * 1024 * 1024
+
+// TraceRequest is a request of a trace, one JSON object per line, e.g.:
+//
+//	{"arrival": 0.25, "model": "llama", "prompt": "You are a helpful assistant...", "outputTokens": 128, "session": "s1"}
+type TraceRequest struct {
+	// Arrival is the arrival time of the request in seconds, relative to the start of the trace.
+	Arrival float64 `json:"arrival"`
+	Model   string  `json:"model"`
+	// Prompt is the prompt of the request. Requests sharing a prompt prefix share the prefix cache of
+	// the pods. If empty, a prompt unique to the request is generated from PromptTokens.
+	Prompt string `json:"prompt,omitempty"`
+	// PromptTokens is the number of tokens of the prompt, estimated from the prompt if not set.
+	PromptTokens int `json:"promptTokens,omitempty"`
+	// OutputTokens is the number of tokens generated for the request.
+	OutputTokens int `json:"outputTokens"`
+	// Sheddable marks the requests that may be dropped under load, all requests are critical otherwise.
+	Sheddable bool `json:"sheddable,omitempty"`
+	// Session groups the requests of a conversation, which carry the session token issued for the
+	// previous request of their session.
+	Session string `json:"session,omitempty"`
+}
+
+// ReadTrace reads a trace of requests, sorted by arrival time.
+func ReadTrace(r io.Reader) ([]*TraceRequest, error) {
+	trace := []*TraceRequest{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTraceLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		req := &TraceRequest{}
+		if err := json.Unmarshal([]byte(text), req); err != nil {
+			return nil, fmt.Errorf("line %d: failed to parse request - %w", line, err)
+		}
+		if err := req.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		trace = append(trace, req)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read trace - %w", err)
+	}
+	sort.SliceStable(trace, func(i, j int) bool { return trace[i].Arrival < trace[j].Arrival })
+	return trace, nil
+}
+
+func (r *TraceRequest) validate() error {
+	switch {
+	case r.Model == "":
+		return errors.New("model is required")
+	case r.Arrival < 0:
+		return errors.New("arrival must not be negative")
+	case r.OutputTokens <= 0:
+		return errors.New("outputTokens must be positive")
+	case r.Prompt == "" && r.PromptTokens <= 0:
+		return errors.New("prompt or promptTokens is required")
+	}
+	return nil
+}
+
+func (r *TraceRequest) arrival() time.Duration {
+	return time.Duration(r.Arrival * float64(time.Second))
+}
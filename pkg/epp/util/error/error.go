@@ -17,6 +17,9 @@ const (
 	ModelServerError               = "ModelServerError"
 	BadConfiguration               = "BadConfiguration"
 	InferencePoolResourceExhausted = "InferencePoolResourceExhausted"
+	// ContextLengthExceeded is a bad request no pod can fit in its maximum context length. Unlike
+	// other errors, its message is returned to the user.
+	ContextLengthExceeded = "ContextLengthExceeded"
 )
 
 // Error returns a string version of the error.
@@ -17,9 +17,22 @@ limitations under the License.
 package pod
 
 import (
+	"context"
+
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// TopologyZoneKey is the node label holding the zone of the node.
+	TopologyZoneKey = corev1.LabelTopologyZone
+	// TopologyRackKey is the node label holding the rack of the node.
+	TopologyRackKey = "llm-d.ai/rack"
 )
 
+var topologyKeys = []string{TopologyZoneKey, TopologyRackKey}
+
 func IsPodReady(pod *corev1.Pod) bool {
 	if !pod.DeletionTimestamp.IsZero() {
 		return false
@@ -34,3 +47,45 @@ func IsPodReady(pod *corev1.Pod) bool {
 	}
 	return false
 }
+
+// WithNodeTopology returns a copy of the pod with the topology labels of its node copied to its
+// annotations, unless the pod already sets them. The pod is returned as is if it is not scheduled
+// yet, or along with an error if its node cannot be read.
+func WithNodeTopology(ctx context.Context, reader client.Reader, pod *corev1.Pod) (*corev1.Pod, error) {
+	if pod.Spec.NodeName == "" {
+		return pod, nil
+	}
+	node := &corev1.Node{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+		return pod, err
+	}
+
+	pod = pod.DeepCopy()
+	for _, key := range topologyKeys {
+		value, ok := node.Labels[key]
+		if !ok {
+			continue
+		}
+		if _, ok := pod.Annotations[key]; ok {
+			continue
+		}
+		if _, ok := pod.Labels[key]; ok {
+			continue
+		}
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[key] = value
+	}
+	return pod, nil
+}
+
+// NodeTopologyChanged returns true if the topology labels of the given versions of a node differ.
+func NodeTopologyChanged(oldNode, newNode *corev1.Node) bool {
+	for _, key := range topologyKeys {
+		if oldNode.Labels[key] != newNode.Labels[key] {
+			return true
+		}
+	}
+	return false
+}
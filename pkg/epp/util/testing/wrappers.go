@@ -155,6 +155,11 @@ func (m *InferenceModelWrapper) Criticality(criticality v1alpha2.Criticality) *I
 	return m
 }
 
+func (m *InferenceModelWrapper) Objectives(ttftMilliseconds, tpotMilliseconds int32) *InferenceModelWrapper {
+	m.Spec.Objectives = &v1alpha2.Objectives{TTFTMilliseconds: &ttftMilliseconds, TPOTMilliseconds: &tpotMilliseconds}
+	return m
+}
+
 func (m *InferenceModelWrapper) DeletionTimestamp() *InferenceModelWrapper {
 	now := metav1.Now()
 	m.ObjectMeta.DeletionTimestamp = &now